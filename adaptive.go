@@ -0,0 +1,131 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DebugLogger receives diagnostic messages about the inner workings of the hook, such as adaptations of the batch
+// duration. It is satisfied by *log.Logger and by logrus loggers, but must not be a logger which the hook itself is
+// attached to.
+type DebugLogger interface {
+	Printf(format string, args ...interface{})
+}
+
+// debugf writes a diagnostic message to the debug logger and the ops stream, if they are configured.
+func (h *CloudWatchLogsHook) debugf(format string, args ...interface{}) {
+	if h.debugLogger != nil {
+		h.debugLogger.Printf("cloudwatchhook: "+format, args...)
+	}
+	h.opsf("debug", "debug", format, args...)
+}
+
+// batchAdapter detects a slow consumer, where events are queued faster than they are sent for several consecutive
+// intervals, and shortens the batch duration in response, restoring it once sending keeps up again. The counts are
+// only touched by the batching worker, except for sent, which is updated atomically by the goroutines sending the
+// batches.
+type batchAdapter struct {
+	current     int64 // the batch duration in nanoseconds, accessed atomically
+	adaptations int64 // accessed atomically
+	sent        int64 // accessed atomically
+	queued      int64
+	intervals   int
+	min         time.Duration
+	max         time.Duration
+	slow        int
+	fast        int
+	checkAt     time.Time
+}
+
+// newBatchAdapter creates an adapter which shortens the batch duration from max down to min after the given number of
+// slow intervals.
+func newBatchAdapter(intervals int, min, max time.Duration) *batchAdapter {
+	return &batchAdapter{
+		current:     int64(max),
+		adaptations: 0,
+		sent:        0,
+		queued:      0,
+		intervals:   intervals,
+		min:         min,
+		max:         max,
+		slow:        0,
+		fast:        0,
+		checkAt:     time.Time{},
+	}
+}
+
+// duration returns the current batch duration.
+func (a *batchAdapter) duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&a.current))
+}
+
+// check compares the number of events queued and sent during the interval which ended at now and adapts the batch
+// duration once either has been ahead for the configured number of intervals. It returns the new duration and
+// whether it changed.
+func (a *batchAdapter) check(now time.Time) (time.Duration, bool) {
+	current := a.duration()
+	if a.checkAt.IsZero() {
+		a.checkAt = now.Add(current)
+		return current, false
+	}
+	if now.Before(a.checkAt) {
+		return current, false
+	}
+	a.checkAt = now.Add(current)
+	queued, sent := a.queued, atomic.SwapInt64(&a.sent, 0)
+	a.queued = 0
+	if queued > sent {
+		a.slow, a.fast = a.slow+1, 0
+	} else {
+		a.slow, a.fast = 0, a.fast+1
+	}
+
+	next := current
+	if a.slow >= a.intervals && current > a.min {
+		next = current / 2
+		if next < a.min {
+			next = a.min
+		}
+	} else if a.fast >= a.intervals && current < a.max {
+		next = current * 2
+		if next > a.max {
+			next = a.max
+		}
+	}
+	if next == current {
+		return current, false
+	}
+	a.slow, a.fast = 0, 0
+	atomic.StoreInt64(&a.current, int64(next))
+	atomic.AddInt64(&a.adaptations, 1)
+	return next, true
+}
+
+// batchDuration returns the time events are collected for before their batch is sent, which is shortened by adaptive
+// batching while events are queued faster than they are sent.
+func (h *CloudWatchLogsHook) batchDuration() time.Duration {
+	if h.adapter != nil {
+		return h.adapter.duration()
+	}
+	return h.logFrequency
+}
+
+// adaptBatching checks whether the batch duration needs to be adapted to the rate at which events are queued and
+// reports any change.
+func (h *CloudWatchLogsHook) adaptBatching() {
+	if h.adapter == nil {
+		return
+	}
+	previous := h.adapter.duration()
+	duration, changed := h.adapter.check(h.clock.Now())
+	if !changed {
+		return
+	}
+	if duration < previous {
+		h.debugf("events were queued faster than they were sent for %d intervals; shortening the batch duration "+
+			"from %v to %v", h.adapter.intervals, previous, duration)
+	} else {
+		h.debugf("sending kept up with queued events for %d intervals; restoring the batch duration from %v to %v",
+			h.adapter.intervals, previous, duration)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !go1.24 && !cloudwatchhook_nocrypto
+// +build !go1.24,!cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// batchEncryptionAvailable reports whether the hook was built with support for batch encryption.
+const batchEncryptionAvailable = true
+
+// sealGCM encrypts the plaintext with AES-GCM under a new random nonce.
+func sealGCM(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("Unable to generate nonce: %v", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// openGCM decrypts ciphertext sealed by sealGCM.
+func openGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("Invalid envelope nonce: expected %d bytes, got %d", gcm.NonceSize(), len(nonce))
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt envelope: %v", err)
+	}
+	return plaintext, nil
+}
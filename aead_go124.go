@@ -0,0 +1,50 @@
+//go:build go1.24 && !cloudwatchhook_nocrypto
+// +build go1.24,!cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// batchEncryptionAvailable reports whether the hook was built with support for batch encryption.
+const batchEncryptionAvailable = true
+
+// sealGCM encrypts the plaintext with AES-GCM under a new random nonce. The nonce is generated by the cipher, rather
+// than by the hook, since GCM with nonces chosen by the caller is not allowed in FIPS 140-only mode.
+func sealGCM(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	sealed := gcm.Seal(nil, nil, plaintext, nil)
+	return sealed[:gcmNonceSize], sealed[gcmNonceSize:], nil
+}
+
+// openGCM decrypts ciphertext sealed by sealGCM.
+func openGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		return nil, fmt.Errorf("Invalid envelope nonce: expected %d bytes, got %d", gcmNonceSize, len(nonce))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create cipher: %v", err)
+	}
+	sealed := make([]byte, 0, len(nonce)+len(ciphertext))
+	sealed = append(append(sealed, nonce...), ciphertext...)
+	plaintext, err := gcm.Open(nil, nil, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt envelope: %v", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,23 @@
+//go:build cloudwatchhook_nocrypto
+// +build cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import "errors"
+
+// batchEncryptionAvailable reports whether the hook was built with support for batch encryption.
+const batchEncryptionAvailable = false
+
+// errNoCrypto is returned by the encryption functions when the hook was built without them.
+var errNoCrypto = errors.New("Batch encryption is not available: the hook was built with the " +
+	"cloudwatchhook_nocrypto tag")
+
+// sealGCM is not available without encryption support.
+func sealGCM(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	return nil, nil, errNoCrypto
+}
+
+// openGCM is not available without encryption support.
+func openGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+	return nil, errNoCrypto
+}
@@ -0,0 +1,58 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// writeAmplification returns the ratio of the bytes shipped to the bytes logged, or 0 if nothing has been logged.
+func writeAmplification(logged, shipped int64) float64 {
+	if logged <= 0 {
+		return 0
+	}
+	return float64(shipped) / float64(logged)
+}
+
+// countLogged counts the bytes logged by the application for a message which is about to be queued: the message of
+// the entry along with the names and values of its fields for Fire, and the bytes given for Write and Send. Fields
+// added by the enrichers of the hook are not counted, since they are part of the amplification.
+func (h *CloudWatchLogsHook) countLogged(n int, entry *logrus.Entry) {
+	if entry != nil {
+		n = len(entry.Message)
+		for key, value := range entry.Data {
+			n += len(key) + fieldSize(value)
+		}
+	}
+	atomic.AddInt64(&h.stats.loggedBytes, int64(n))
+}
+
+// fieldSize returns the length of the value of a field as it is printed.
+func fieldSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case error:
+		return len(v.Error())
+	case fmt.Stringer:
+		return len(v.String())
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+// countShipped counts the bytes of the events accepted by Amazon CloudWatch, as they were sent, once formatted,
+// enriched and sealed in envelopes. Each event counts the length of its message plus EventOverhead bytes, the way it
+// counts against the PutLogEvents limits.
+func (h *CloudWatchLogsHook) countShipped(events []types.InputLogEvent) {
+	var n int64
+	for _, event := range events {
+		n += int64(len(aws.ToString(event.Message)) + EventOverhead)
+	}
+	atomic.AddInt64(&h.stats.shippedBytes, n)
+}
@@ -0,0 +1,61 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWriteAmplification(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithOpsStream("ops"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	logger.WithField("user", "alice").Info("hello")
+	if _, err := hook.Write([]byte("written")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = hook.Send(context.Background(), []cloudwatchhook.Event{{Message: "sent", Timestamp: time.Now()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the entry counts its message and fields as logged and its formatted line as shipped
+	var shipped int64
+	for _, event := range client.Events("group", "stream") {
+		shipped += int64(len(aws.ToString(event.Message)) + cloudwatchhook.EventOverhead)
+	}
+	logged := int64(len("hello") + len("user") + len("alice") + len("written") + len("sent"))
+	stats := hook.Stats()
+	if stats.LoggedBytes != logged || stats.ShippedBytes != shipped {
+		t.Errorf("expected %d bytes logged and %d shipped, got %d and %d", logged, shipped, stats.LoggedBytes,
+			stats.ShippedBytes)
+	}
+	if expected := float64(shipped) / float64(stats.LoggedBytes); stats.WriteAmplification != expected {
+		t.Errorf("expected a write amplification of %.2f, got %.2f", expected, stats.WriteAmplification)
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	found := false
+	for _, event := range client.Events("group", "ops") {
+		found = found || strings.Contains(aws.ToString(event.Message), `"kind":"amplification"`)
+	}
+	if !found {
+		t.Errorf("expected the write amplification to be reported to the ops stream")
+	}
+}
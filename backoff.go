@@ -0,0 +1,120 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// Backoff is used to determine how long to wait before retrying a failed upload to Amazon CloudWatch.
+type Backoff interface {
+	// Next returns the delay before the given retry attempt (starting at 1) based on the previous delay, along with
+	// whether or not the attempt should be made at all.
+	Next(attempt int, previous time.Duration) (time.Duration, bool)
+}
+
+// ConstantBackoff waits the same amount of time between each retry.
+type ConstantBackoff struct {
+	Interval   time.Duration
+	MaxRetries int
+}
+
+// Next returns the constant interval until the maximum number of retries is reached.
+func (b ConstantBackoff) Next(attempt int, previous time.Duration) (time.Duration, bool) {
+	if attempt > b.MaxRetries {
+		return 0, false
+	}
+	return b.Interval, true
+}
+
+// ExponentialBackoff doubles the time between each retry starting at Base and never waiting longer than Max.
+type ExponentialBackoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// Next returns the exponential delay until the maximum number of retries is reached.
+func (b ExponentialBackoff) Next(attempt int, previous time.Duration) (time.Duration, bool) {
+	if attempt > b.MaxRetries {
+		return 0, false
+	}
+	delay := b.Base
+	for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+		if b.Max > 0 && delay >= b.Max {
+			break
+		}
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return delay, true
+}
+
+// DecorrelatedJitterBackoff waits a random amount of time between Base and three times the previous delay, never
+// waiting longer than Max. This spreads retries from many clients more evenly than plain exponential backoff.
+type DecorrelatedJitterBackoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// Next returns the jittered delay until the maximum number of retries is reached.
+func (b DecorrelatedJitterBackoff) Next(attempt int, previous time.Duration) (time.Duration, bool) {
+	if attempt > b.MaxRetries {
+		return 0, false
+	}
+	if previous < b.Base {
+		previous = b.Base
+	}
+	delay := b.Base + randDuration(previous*3-b.Base)
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return delay, true
+}
+
+// jitter is the random source used for backoff calculations. It is seeded so that separate processes do not follow
+// the same sequence.
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// randDuration returns a random duration in the range [0, max).
+func randDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	jitter.Lock()
+	defer jitter.Unlock()
+	return time.Duration(jitter.Int63n(int64(max)))
+}
+
+// randFloat returns a random number in the range [0, 1).
+func randFloat() float64 {
+	jitter.Lock()
+	defer jitter.Unlock()
+	return jitter.Float64()
+}
+
+// isRetryable determines whether or not a failed PutLogEvents call should be attempted again.
+func isRetryable(err error) bool {
+	var tokenErr *types.InvalidSequenceTokenException
+	var unavailableErr *types.ServiceUnavailableException
+	var abortedErr *types.OperationAbortedException
+	if errors.As(err, &tokenErr) || errors.As(err, &unavailableErr) || errors.As(err, &abortedErr) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ThrottlingException" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second, MaxRetries: 5}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+	}
+	for i, want := range expected {
+		got, ok := b.Next(i+1, 0)
+		if !ok || got != want {
+			t.Errorf("attempt %d: got (%v, %v), want (%v, true)", i+1, got, ok, want)
+		}
+	}
+	if _, ok := b.Next(6, 0); ok {
+		t.Errorf("attempt 6: expected backoff to give up")
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second, MaxRetries: 100}
+	var delay time.Duration
+	for attempt := 1; attempt <= 100; attempt++ {
+		next, ok := b.Next(attempt, delay)
+		if !ok {
+			t.Fatalf("attempt %d: expected retry", attempt)
+		}
+		upper := delay * 3
+		if upper < b.Base*3 {
+			upper = b.Base * 3
+		}
+		if upper > b.Max {
+			upper = b.Max
+		}
+		if next < b.Base || next > upper {
+			t.Fatalf("attempt %d: delay %v outside of [%v, %v]", attempt, next, b.Base, upper)
+		}
+		delay = next
+	}
+}
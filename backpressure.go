@@ -0,0 +1,30 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// backpressureGate drops less important entries while the batch queue is backed up.
+type backpressureGate struct {
+	level         logrus.Level
+	highWaterMark int
+	lowWaterMark  int
+	active        int32
+}
+
+// drop determines whether or not an entry at the given level should be dropped given the number of queued events.
+// Once the queue reaches the high-water mark, entries less severe than the gate level are dropped until the queue has
+// drained to the low-water mark.
+func (g *backpressureGate) drop(level logrus.Level, queued int) bool {
+	if level <= g.level {
+		return false
+	}
+	if queued >= g.highWaterMark {
+		atomic.StoreInt32(&g.active, 1)
+	} else if queued <= g.lowWaterMark {
+		atomic.StoreInt32(&g.active, 0)
+	}
+	return atomic.LoadInt32(&g.active) == 1
+}
@@ -0,0 +1,57 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBackpressureGate(t *testing.T) {
+	g := &backpressureGate{level: logrus.InfoLevel, highWaterMark: 100, lowWaterMark: 10}
+	steps := []struct {
+		level  logrus.Level
+		queued int
+		drop   bool
+	}{
+		{logrus.DebugLevel, 50, false},
+		{logrus.DebugLevel, 100, true},
+		{logrus.InfoLevel, 200, false},
+		{logrus.ErrorLevel, 200, false},
+		{logrus.DebugLevel, 50, true},
+		{logrus.DebugLevel, 10, false},
+		{logrus.DebugLevel, 50, false},
+	}
+	for i, step := range steps {
+		if drop := g.drop(step.level, step.queued); drop != step.drop {
+			t.Errorf("step %d: drop(%v, %d) = %v, want %v", i, step.level, step.queued, drop, step.drop)
+		}
+	}
+}
+
+func TestQueueCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []CloudWatchLogsHookOption
+		capacity int
+	}{
+		{"default", []CloudWatchLogsHookOption{WithBatchDuration(time.Minute)}, defaultQueueCapacity},
+		{"configured", []CloudWatchLogsHookOption{WithBatchDuration(time.Minute), WithQueueCapacity(100)}, 100},
+		{"without batching", nil, 0},
+	}
+	for _, test := range tests {
+		options := append([]CloudWatchLogsHookOption{WithTransport(&testTransport{})}, test.options...)
+		h, err := NewCloudWatchLogsHook(aws.Config{}, "group", "stream", options...)
+		if err != nil {
+			t.Fatalf("%s: unable to create hook: %v", test.name, err)
+		}
+		if n := cap(h.ch); n != test.capacity {
+			t.Errorf("%s: expected a queue holding %d events, got %d", test.name, test.capacity, n)
+		}
+		if n := h.Config().QueueCapacity; n != test.capacity {
+			t.Errorf("%s: expected a queue capacity of %d to be reported, got %d", test.name, test.capacity, n)
+		}
+		h.Close()
+	}
+}
@@ -0,0 +1,203 @@
+package cloudwatchhook
+
+import (
+	"sort"
+	"time"
+)
+
+// The limits of a single PutLogEvents call. The size of each event counts its message plus EventOverhead bytes, and
+// events larger than MaxEventSize are rejected on their own, whichever batch they are in.
+const (
+	MaxBatchBytes  = 1048576
+	MaxBatchEvents = 10000
+	MaxBatchSpan   = 24 * time.Hour
+	MaxEventSize   = 262144
+	EventOverhead  = 26
+)
+
+// maxMessageSize is the largest message accepted by Amazon CloudWatch, once the overhead of its event is counted
+// against MaxEventSize.
+const maxMessageSize = MaxEventSize - EventOverhead
+
+// BatchLimits bounds the batches collected by an EventBatcher. A limit of zero is not enforced.
+type BatchLimits struct {
+	// MaxBytes is the largest total size of the events of a batch, each counting its message plus EventOverhead.
+	MaxBytes      int
+	EventOverhead int
+
+	// MaxEvents is the largest number of events in a batch.
+	MaxEvents int
+
+	// MaxSpan is the time between the oldest and newest events of a batch, which must be shorter than this.
+	MaxSpan time.Duration
+
+	// MaxEventSize is the largest size of a single event, counting its message plus EventOverhead.
+	MaxEventSize int
+}
+
+// putLogEventsLimits are the limits of a single PutLogEvents call, which the hook batches events within.
+var putLogEventsLimits = BatchLimits{
+	MaxBytes:      MaxBatchBytes,
+	EventOverhead: EventOverhead,
+	MaxEvents:     MaxBatchEvents,
+	MaxSpan:       MaxBatchSpan,
+	MaxEventSize:  MaxEventSize,
+}
+
+// PutLogEventsLimits returns the limits of a single PutLogEvents call, which the hook batches events within. Each call
+// returns a copy, which the caller is free to change.
+func PutLogEventsLimits() BatchLimits {
+	return putLogEventsLimits
+}
+
+// oversized reports whether an event of the given size can never be sent within the limits, whichever batch it is in.
+func (l BatchLimits) oversized(eventSize int) bool {
+	return (l.MaxEventSize > 0 && eventSize > l.MaxEventSize) || (l.MaxBytes > 0 && eventSize > l.MaxBytes)
+}
+
+// batchExtent tracks the size and the oldest and newest timestamps, in milliseconds, of the events of a batch.
+type batchExtent struct {
+	size  int
+	first int64
+	last  int64
+}
+
+// fits reports whether an event of the given size and timestamp can be added to a batch of count events without
+// exceeding the limits.
+func (e batchExtent) fits(limits BatchLimits, count, eventSize int, timestamp int64) bool {
+	if count > 0 && limits.MaxSpan > 0 {
+		first, last := e.first, e.last
+		if timestamp < first {
+			first = timestamp
+		}
+		if timestamp > last {
+			last = timestamp
+		}
+		if last-first >= int64(limits.MaxSpan/time.Millisecond) {
+			return false
+		}
+	}
+	if limits.MaxBytes > 0 && e.size+eventSize > limits.MaxBytes {
+		return false
+	}
+	return limits.MaxEvents <= 0 || count < limits.MaxEvents
+}
+
+// extend accounts for an event added to a batch which held count events.
+func (e *batchExtent) extend(count, eventSize int, timestamp int64) {
+	if count == 0 || timestamp < e.first {
+		e.first = timestamp
+	}
+	if count == 0 || timestamp > e.last {
+		e.last = timestamp
+	}
+	e.size += eventSize
+}
+
+// EventBatcher is a Batcher which collects events into batches the same way the hook batches events for PutLogEvents.
+// A batch is complete when the next event would exceed the limits, or once the batch duration has passed since its
+// first event was added. It has no dependency on Logrus or on a client, so it can be reused by other integrations and
+// in tests. The events of each batch are returned in chronological order, and events with the same timestamp keep the
+// order they were added in. It is not safe for concurrent use.
+type EventBatcher struct {
+	limits   BatchLimits
+	duration time.Duration
+	clock    Clock
+	events   []Event
+	extent   batchExtent
+	deadline time.Time
+}
+
+// NewEventBatcher creates a batcher which completes batches after the given duration or when they reach the limits.
+// A duration of zero makes each batch due as soon as it is started.
+func NewEventBatcher(duration time.Duration, limits BatchLimits) *EventBatcher {
+	return &EventBatcher{
+		limits:   limits,
+		duration: duration,
+		clock:    systemClock{},
+		events:   nil,
+		extent:   batchExtent{},
+		deadline: time.Time{},
+	}
+}
+
+// Add adds the event to the current batch. If it does not fit, the current batch is completed and returned, and the
+// event starts the next one. An event which exceeds the limits on its own is batched by itself.
+func (b *EventBatcher) Add(event Event) []Event {
+	eventSize := len(event.Message) + b.limits.EventOverhead
+	timestamp := event.Timestamp.UnixNano() / int64(time.Millisecond)
+	var complete []Event
+	if len(b.events) > 0 && !b.extent.fits(b.limits, len(b.events), eventSize, timestamp) {
+		complete = b.Flush()
+	}
+	if len(b.events) == 0 {
+		b.deadline = b.clock.Now().Add(b.duration)
+	}
+	b.extent.extend(len(b.events), eventSize, timestamp)
+	b.events = append(b.events, event)
+	return complete
+}
+
+// Due completes and returns the current batch if its duration has passed at the given time, or returns nil.
+func (b *EventBatcher) Due(now time.Time) []Event {
+	if len(b.events) == 0 || now.Before(b.deadline) {
+		return nil
+	}
+	return b.Flush()
+}
+
+// Deadline returns when the current batch is due, or false if no events are being batched.
+func (b *EventBatcher) Deadline() (time.Time, bool) {
+	return b.deadline, len(b.events) > 0
+}
+
+// Len returns the number of events in the current batch.
+func (b *EventBatcher) Len() int {
+	return len(b.events)
+}
+
+// Flush completes and returns the current batch, which may be empty, regardless of its duration.
+func (b *EventBatcher) Flush() []Event {
+	events := b.events
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	b.events, b.extent, b.deadline = nil, batchExtent{}, time.Time{}
+	return events
+}
+
+// SplitBatches splits the events into batches within the limits, the same way the hook and EventBatcher do, for
+// integrations which send events they have already collected. The events are sorted chronologically first, as
+// required by PutLogEvents, with events with the same timestamp keeping their order, and each batch is filled before
+// the next one is started. Events which can never be sent, because they exceed the maximum event size or the maximum
+// batch size on their own, are returned separately in the order they were given rather than in a batch. The events
+// are not modified.
+func SplitBatches(events []Event, limits BatchLimits) (batches [][]Event, oversized []Event) {
+	sorted := make([]Event, 0, len(events))
+	for _, event := range events {
+		if limits.oversized(len(event.Message) + limits.EventOverhead) {
+			oversized = append(oversized, event)
+			continue
+		}
+		sorted = append(sorted, event)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var extent batchExtent
+	start := 0
+	for i, event := range sorted {
+		eventSize := len(event.Message) + limits.EventOverhead
+		timestamp := event.Timestamp.UnixNano() / int64(time.Millisecond)
+		if i > start && !extent.fits(limits, i-start, eventSize, timestamp) {
+			batches = append(batches, sorted[start:i:i])
+			start, extent = i, batchExtent{}
+		}
+		extent.extend(i-start, eventSize, timestamp)
+	}
+	if start < len(sorted) {
+		batches = append(batches, sorted[start:])
+	}
+	return batches, oversized
+}
@@ -0,0 +1,130 @@
+package cloudwatchhook_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+func TestEventBatcher(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := func(message string, offset time.Duration) cloudwatchhook.Event {
+		return cloudwatchhook.Event{Message: message, Timestamp: start.Add(offset)}
+	}
+	limits := cloudwatchhook.PutLogEventsLimits()
+	limits.MaxEvents = 3
+	batcher := cloudwatchhook.NewEventBatcher(time.Second, limits)
+	var _ cloudwatchhook.Batcher = batcher
+
+	// events are returned in chronological order once the batch is full
+	for i, message := range []string{"b", "a", "c"} {
+		offset := time.Duration(i) * time.Millisecond
+		if message == "a" {
+			offset = -time.Millisecond
+		}
+		if batch := batcher.Add(event(message, offset)); batch != nil {
+			t.Fatalf("expected the batch to hold %d events, got %d", limits.MaxEvents, len(batch))
+		}
+	}
+	batch := batcher.Add(event("d", time.Second))
+	if len(batch) != 3 || batch[0].Message != "a" || batch[2].Message != "c" {
+		t.Errorf("expected the full batch in chronological order, got %v", batch)
+	}
+
+	// the next batch is due a batch duration after its first event was added
+	deadline, ok := batcher.Deadline()
+	if !ok {
+		t.Fatalf("expected a batch to be pending")
+	}
+	if batch := batcher.Due(deadline.Add(-time.Nanosecond)); batch != nil {
+		t.Errorf("expected the batch not to be due yet, got %v", batch)
+	}
+	if batch := batcher.Due(deadline); len(batch) != 1 || batcher.Len() != 0 {
+		t.Errorf("expected the batch to be due, got %v", batch)
+	}
+
+	// batches span less than 24 hours
+	batcher.Add(event("e", 0))
+	if batch := batcher.Add(event("f", 24*time.Hour)); len(batch) != 1 {
+		t.Errorf("expected the batch to be completed before it spans 24 hours, got %v", batch)
+	}
+	if batch := batcher.Flush(); len(batch) != 1 || batch[0].Message != "f" {
+		t.Errorf("expected the last event to be flushed, got %v", batch)
+	}
+}
+
+func TestSplitBatches(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	limits := cloudwatchhook.PutLogEventsLimits()
+	sizes := func(batches [][]cloudwatchhook.Event) []int {
+		var sizes []int
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch))
+		}
+		return sizes
+	}
+
+	// the count limit
+	var events []cloudwatchhook.Event
+	for i := 0; i < cloudwatchhook.MaxBatchEvents+1; i++ {
+		events = append(events, cloudwatchhook.Event{Message: "event", Timestamp: start})
+	}
+	batches, oversized := cloudwatchhook.SplitBatches(events, limits)
+	if got := sizes(batches); len(got) != 2 || got[0] != 10000 || got[1] != 1 || len(oversized) != 0 {
+		t.Errorf("expected batches of 10000 and 1 events, got %v and %d oversized", got, len(oversized))
+	}
+
+	// the size limit, which counts the overhead of each event, and events which can never be sent
+	large := strings.Repeat("a", 100000)
+	events = nil
+	for i := 0; i < 11; i++ {
+		events = append(events, cloudwatchhook.Event{Message: large, Timestamp: start})
+	}
+	events = append(events,
+		cloudwatchhook.Event{Message: strings.Repeat("a", cloudwatchhook.MaxEventSize-cloudwatchhook.EventOverhead),
+			Timestamp: start},
+		cloudwatchhook.Event{Message: strings.Repeat("b", cloudwatchhook.MaxEventSize-cloudwatchhook.EventOverhead+1),
+			Timestamp: start},
+		cloudwatchhook.Event{Message: strings.Repeat("c", cloudwatchhook.MaxBatchBytes), Timestamp: start})
+	batches, oversized = cloudwatchhook.SplitBatches(events, limits)
+	if got := sizes(batches); len(got) != 2 || got[0] != 10 || got[1] != 2 {
+		t.Errorf("expected batches of 10 and 2 events, got %v", got)
+	}
+	if len(oversized) != 2 || oversized[0].Message[0] != 'b' || oversized[1].Message[0] != 'c' {
+		t.Errorf("expected the events over the maximum event size to be returned separately, got %d", len(oversized))
+	}
+	for _, batch := range batches {
+		for _, event := range batch {
+			if len(event.Message)+cloudwatchhook.EventOverhead > cloudwatchhook.MaxEventSize {
+				t.Errorf("expected no batch to hold an event over the maximum event size")
+			}
+		}
+	}
+
+	// without a maximum event size, an event exceeding the batch size on its own still cannot be batched
+	_, oversized = cloudwatchhook.SplitBatches(events[len(events)-1:], cloudwatchhook.BatchLimits{
+		MaxBytes: cloudwatchhook.MaxBatchBytes, EventOverhead: cloudwatchhook.EventOverhead})
+	if len(oversized) != 1 {
+		t.Errorf("expected an event over the batch size to be returned separately, got %d", len(oversized))
+	}
+
+	// the span limit, applied after sorting events chronologically
+	events = []cloudwatchhook.Event{
+		{Message: "c", Timestamp: start.Add(cloudwatchhook.MaxBatchSpan)},
+		{Message: "a", Timestamp: start},
+		{Message: "b", Timestamp: start.Add(cloudwatchhook.MaxBatchSpan - time.Millisecond)},
+	}
+	batches, _ = cloudwatchhook.SplitBatches(events, limits)
+	if got := sizes(batches); len(got) != 2 || got[0] != 2 || batches[0][0].Message != "a" ||
+		batches[1][0].Message != "c" {
+		t.Errorf("expected batches spanning less than 24 hours in chronological order, got %v", batches)
+	}
+	if events[0].Message != "c" {
+		t.Errorf("expected the events not to be modified")
+	}
+	if batches, _ := cloudwatchhook.SplitBatches(nil, limits); len(batches) != 0 {
+		t.Errorf("expected no batches, got %v", batches)
+	}
+}
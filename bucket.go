@@ -0,0 +1,40 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// LateField is the name of the field added with a value of true to events which arrive after the batch for their
+// timestamp bucket was sent, when the WithTimestampBuckets option is used.
+const LateField = "late"
+
+// timestampBucket returns the start, in milliseconds since the Unix epoch, of the bucket of the given width which the
+// timestamp falls in.
+func timestampBucket(timestamp int64, width time.Duration) int64 {
+	millis := int64(width / time.Millisecond)
+	return timestamp - timestamp%millis
+}
+
+// markLate adds the late field to the message, unless it already has one. Messages holding a JSON object get it as a
+// property, and other messages get it appended in the key=value form of logrus.TextFormatter.
+func markLate(msg string) string {
+	trimmed := strings.TrimSpace(msg)
+	if !strings.HasPrefix(trimmed, "{") || !strings.HasSuffix(trimmed, "}") {
+		if strings.HasPrefix(trimmed, LateField+"=") || strings.Contains(msg, " "+LateField+"=") {
+			return msg
+		}
+		return msg + " " + LateField + "=true"
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+		if _, ok := fields[LateField]; ok {
+			return msg
+		}
+	}
+	if strings.TrimSpace(trimmed[1:len(trimmed)-1]) == "" {
+		return `{"` + LateField + `":true}`
+	}
+	return `{"` + LateField + `":true,` + trimmed[1:]
+}
@@ -0,0 +1,76 @@
+package cloudwatchhook_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// steppedClock is a fake clock whose time of day can be stepped back, as the system clock is by NTP, without moving
+// its timers.
+type steppedClock struct {
+	*chaos.Clock
+	step int64 // nanoseconds, accessed atomically
+}
+
+func (c *steppedClock) Now() time.Time {
+	return c.Clock.Now().Add(time.Duration(atomic.LoadInt64(&c.step)))
+}
+
+func TestTimestampBuckets(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := &steppedClock{Clock: chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(5*time.Second),
+		cloudwatchhook.WithTimestampBuckets(time.Minute), cloudwatchhook.WithClock(clock))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	deliver := func(want int, step time.Duration) {
+		for i := 0; i < 100 && len(client.Events("group", "stream")) < want; i++ {
+			clock.Advance(step)
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := len(client.Events("group", "stream")); n != want {
+			t.Fatalf("expected %d events, got %d", want, n)
+		}
+	}
+
+	// the bucket is held past the batch duration and sent once it ends
+	for _, msg := range []string{`{"msg":"first"}`, `{"msg":"second"}`} {
+		if _, err := hook.Write([]byte(msg)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	clock.Advance(5 * time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if n := client.Calls("PutLogEvents"); n != 0 {
+		t.Errorf("expected the bucket to be held until it ends, got %d uploads", n)
+	}
+	deliver(2, time.Minute)
+
+	// events for the bucket which was already sent are flagged as late
+	atomic.StoreInt64(&clock.step, int64(-45*time.Second))
+	for _, msg := range []string{`{"msg":"third"}`, "fourth"} {
+		if _, err := hook.Write([]byte(msg)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	deliver(4, 5*time.Second)
+	events := client.Events("group", "stream")
+	if msg := aws.ToString(events[2].Message); msg != `{"late":true,"msg":"third"}` {
+		t.Errorf("expected the JSON event to be flagged as late, got %s", msg)
+	}
+	if msg := aws.ToString(events[3].Message); msg != "fourth late=true" {
+		t.Errorf("expected the text event to be flagged as late, got %s", msg)
+	}
+	if n := client.Calls("PutLogEvents"); n != 2 {
+		t.Errorf("expected a single upload for each batch, got %d", n)
+	}
+}
@@ -0,0 +1,78 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TruncatedFieldsField is the name of the field listing the fields truncated by the WithFieldBudget option.
+const TruncatedFieldsField = "truncated_fields"
+
+// truncationMarker is appended to truncated field values.
+const truncationMarker = "…"
+
+// FieldBudgetEnricher returns an enricher which truncates the value of any field larger than the given number of bytes,
+// replacing it with a string holding as much of the value as fits followed by an ellipsis, and lists the truncated
+// fields in the truncated_fields field. Values other than strings are measured and truncated in their JSON form. Since
+// it also truncates fields added by the enrichers before it, it should be the last enricher.
+func FieldBudgetEnricher(maxBytesPerField int) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		var truncated []string
+		budget := func(k string, v interface{}) {
+			s := fieldText(v)
+			if len(s) <= maxBytesPerField {
+				return
+			}
+			fields[k] = truncateText(s, maxBytesPerField-len(truncationMarker)) + truncationMarker
+			truncated = append(truncated, k)
+		}
+		for k, v := range fields {
+			budget(k, v)
+		}
+		for k, v := range entry.Data {
+			if _, ok := fields[k]; !ok {
+				budget(k, v)
+			}
+		}
+		if len(truncated) > 0 {
+			sort.Strings(truncated)
+			fields[TruncatedFieldsField] = truncated
+		}
+	})
+}
+
+// fieldText returns the text a field value is rendered as.
+func fieldText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// truncateText returns at most the given number of bytes of the text without splitting a UTF-8 sequence.
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n < 0 {
+		return ""
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,40 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldBudget(t *testing.T) {
+	entry := &logrus.Entry{
+		Message: "request",
+		Data: logrus.Fields{
+			"body":   strings.Repeat("x", 100),
+			"name":   "éééééé",
+			"small":  "ok",
+			"params": map[string]string{"query": strings.Repeat("q", 50)},
+		},
+	}
+	fields := logrus.Fields{}
+	FieldBudgetEnricher(10).Enrich(entry, fields)
+
+	if body := fields["body"]; body != "xxxxxxx…" {
+		t.Errorf("expected the body to be truncated, got %q", body)
+	}
+	if name := fields["name"]; name != "ééé…" {
+		t.Errorf("expected the name to be truncated on a character boundary, got %q", name)
+	}
+	if _, ok := fields["small"]; ok {
+		t.Errorf("expected the small field to be left alone")
+	}
+	if params, ok := fields["params"].(string); !ok || !strings.HasPrefix(params, `{"query`) {
+		t.Errorf("expected the params to be truncated in their JSON form, got %v", fields["params"])
+	}
+	b, _ := json.Marshal(fields[TruncatedFieldsField])
+	if string(b) != `["body","name","params"]` {
+		t.Errorf("expected the truncated fields to be listed, got %s", b)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.18
+// +build go1.18
+
+package cloudwatchhook
+
+import "runtime/debug"
+
+// buildSettings returns the settings used to build the binary, such as VCS revision and build flags.
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	settings := map[string]string{}
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	return settings
+}
@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package cloudwatchhook
+
+import "runtime/debug"
+
+// buildSettings returns nil since build settings are not recorded before Go 1.18.
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	return nil
+}
@@ -0,0 +1,117 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// burstSlot is a slot of the burst buffer. Its turn tells producers and the consumer whose turn it is to use the slot:
+// it equals the position of the next write to the slot while the slot is free and that position plus one once the
+// event has been written.
+type burstSlot struct {
+	turn  uint64
+	event queuedEvent
+}
+
+// burstBuffer is a bounded ring buffer which absorbs bursts of events while the batch queue is full. Any number of
+// goroutines may push events without taking a lock; only the batching worker pops them.
+type burstBuffer struct {
+	head   uint64
+	tail   uint64
+	mask   uint64
+	slots  []burstSlot
+	signal chan struct{}
+}
+
+// newBurstBuffer creates a buffer holding at least the given number of events, rounded up to a power of two.
+func newBurstBuffer(size int) *burstBuffer {
+	capacity := uint64(1)
+	for capacity < uint64(size) {
+		capacity <<= 1
+	}
+	b := &burstBuffer{
+		head:   0,
+		tail:   0,
+		mask:   capacity - 1,
+		slots:  make([]burstSlot, capacity),
+		signal: make(chan struct{}, 1),
+	}
+	for i := range b.slots {
+		b.slots[i].turn = uint64(i)
+	}
+	return b
+}
+
+// push adds the event to the buffer and wakes the consumer, returning false if the buffer is full.
+func (b *burstBuffer) push(event queuedEvent) bool {
+	for {
+		head := atomic.LoadUint64(&b.head)
+		slot := &b.slots[head&b.mask]
+		turn := atomic.LoadUint64(&slot.turn)
+		if turn < head {
+			// the slot still holds the event written a lap ago
+			return false
+		}
+		if turn == head && atomic.CompareAndSwapUint64(&b.head, head, head+1) {
+			slot.event = event
+			atomic.StoreUint64(&slot.turn, head+1)
+			select {
+			case b.signal <- struct{}{}:
+			default:
+			}
+			return true
+		}
+		// another producer claimed the slot first
+	}
+}
+
+// pop removes and returns the oldest event, or returns false if there is none. It must only be called by the
+// consumer.
+func (b *burstBuffer) pop() (queuedEvent, bool) {
+	tail := atomic.LoadUint64(&b.tail)
+	slot := &b.slots[tail&b.mask]
+	if atomic.LoadUint64(&slot.turn) != tail+1 {
+		return queuedEvent{}, false
+	}
+	event := slot.event
+	slot.event = queuedEvent{}
+	atomic.StoreUint64(&b.tail, tail+1)
+	atomic.StoreUint64(&slot.turn, tail+b.mask+1)
+	return event, true
+}
+
+// len returns the number of events in the buffer, including any still being written.
+func (b *burstBuffer) len() int {
+	return int(atomic.LoadUint64(&b.head) - atomic.LoadUint64(&b.tail))
+}
+
+// queueLength returns the number of events waiting to be batched, in the batch queue and the burst buffer.
+func (h *CloudWatchLogsHook) queueLength() int {
+	if h.burst == nil {
+		return len(h.ch)
+	}
+	return len(h.ch) + h.burst.len()
+}
+
+// queueEvent puts the event in the batch queue, or in the burst buffer while the queue is full. Once the burst buffer
+// overflows too, entries less severe than the backpressure level, if one is set, are dropped; any other event waits
+// for room in the queue, as it would without a burst buffer.
+func (h *CloudWatchLogsHook) queueEvent(queued queuedEvent, entry *logrus.Entry) {
+	select {
+	case h.ch <- queued:
+		return
+	default:
+	}
+	if h.burst.push(queued) {
+		atomic.AddInt64(&h.stats.burstBuffered, 1)
+		return
+	}
+	atomic.AddInt64(&h.stats.burstOverflows, 1)
+	if entry != nil && h.backpressure != nil && entry.Level > h.backpressure.level {
+		atomic.AddInt64(&h.stats.backpressureDropped, 1)
+		atomic.AddInt64(&h.stats.queuedBytes, -queuedSize(queued.event))
+		return
+	}
+	h.ch <- queued
+}
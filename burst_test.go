@@ -0,0 +1,73 @@
+package cloudwatchhook
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBurstBuffer(t *testing.T) {
+	b := newBurstBuffer(5)
+	if len(b.slots) != 8 {
+		t.Fatalf("expected the size to be rounded up to 8, got %d", len(b.slots))
+	}
+
+	// fill the buffer from several goroutines at once
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 2; i++ {
+				if !b.push(queuedEvent{seq: uint64(w*2 + i + 1)}) {
+					t.Errorf("expected room for event %d", w*2+i+1)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if b.push(queuedEvent{seq: 9}) {
+		t.Errorf("expected the buffer to be full")
+	}
+	if b.len() != 8 {
+		t.Errorf("expected 8 events, got %d", b.len())
+	}
+
+	// each event comes out exactly once, and the buffer can be reused once drained
+	seen := map[uint64]bool{}
+	for lap := 0; lap < 2; lap++ {
+		for p, ok := b.pop(); ok; p, ok = b.pop() {
+			if seen[p.seq] {
+				t.Errorf("event %d popped twice", p.seq)
+			}
+			seen[p.seq] = true
+		}
+		if lap == 0 && !b.push(queuedEvent{seq: 10}) {
+			t.Errorf("expected room once drained")
+		}
+	}
+	if len(seen) != 9 || b.len() != 0 {
+		t.Errorf("expected 9 distinct events and an empty buffer, got %d and %d", len(seen), b.len())
+	}
+}
+
+func TestBurstOverflow(t *testing.T) {
+	h := newTestHook(t, "group", "stream", WithBatchDuration(time.Hour), WithQueueCapacity(1), WithBurstBuffer(2),
+		WithBackpressureLevel(logrus.WarnLevel, 1, 0))
+	// the queue and the buffer are created once the hook starts
+	h.ch = make(chan queuedEvent, h.resolvedQueueCapacity())
+	h.burst = newBurstBuffer(h.burstSize)
+	entry := &logrus.Entry{Level: logrus.InfoLevel}
+	for i := 0; i < 4; i++ {
+		h.queueEvent(queuedEvent{seq: uint64(i)}, entry)
+	}
+	if len(h.ch) != 1 || h.burst.len() != 2 {
+		t.Errorf("expected the queue and then the buffer to fill, got %d and %d", len(h.ch), h.burst.len())
+	}
+	stats := h.Stats()
+	if stats.BurstBuffered != 2 || stats.BurstOverflows != 1 || stats.BackpressureDropped != 1 {
+		t.Errorf("expected the overflowing info entry to be dropped, got %+v", stats)
+	}
+}
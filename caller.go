@@ -0,0 +1,128 @@
+package cloudwatchhook
+
+import (
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CallerFileField is the name of the field holding the file of the caller.
+	CallerFileField = "file"
+
+	// CallerLineField is the name of the field holding the line number of the caller.
+	CallerLineField = "line"
+
+	// CallerFuncField is the name of the field holding the function of the caller.
+	CallerFuncField = "func"
+
+	// maxCallerDepth is the maximum number of frames searched when computing the caller.
+	maxCallerDepth = 25
+)
+
+// CallerPolicy determines how the caller recorded by logrus when ReportCaller is enabled on the logger appears in the
+// messages sent to Amazon CloudWatch. The output of the logger itself is not affected.
+type CallerPolicy int
+
+const (
+	// CallerKeep sends the caller as recorded by logrus. This is the default policy.
+	CallerKeep CallerPolicy = iota
+
+	// CallerTrim removes the first matching prefix, such as a GOPATH or module path, from the file and function.
+	CallerTrim
+
+	// CallerShort keeps only the base name of the file and the package name and function, such as hook.go and
+	// cloudwatchhook.(*CloudWatchLogsHook).Fire.
+	CallerShort
+
+	// CallerDrop leaves the caller out of the messages sent to Amazon CloudWatch.
+	CallerDrop
+)
+
+// String returns the name of the policy.
+func (p CallerPolicy) String() string {
+	switch p {
+	case CallerKeep:
+		return "keep"
+	case CallerTrim:
+		return "trim"
+	case CallerShort:
+		return "short"
+	case CallerDrop:
+		return "drop"
+	default:
+		return "unknown"
+	}
+}
+
+// apply returns the frame to report according to the policy, which is nil if the caller is dropped. The frame recorded
+// by logrus is shared with the output of the logger, so it is copied rather than changed.
+func (p CallerPolicy) apply(frame *runtime.Frame, prefixes []string) *runtime.Frame {
+	trimmed := *frame
+	switch p {
+	case CallerTrim:
+		trimmed.File = trimPrefixes(frame.File, prefixes)
+		trimmed.Function = trimPrefixes(frame.Function, prefixes)
+	case CallerShort:
+		trimmed.File = path.Base(frame.File)
+		trimmed.Function = frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	case CallerDrop:
+		return nil
+	default:
+		return frame
+	}
+	return &trimmed
+}
+
+// callerFrame returns the frame of the function which logged the entry. The frame recorded by logrus is used when
+// ReportCaller is enabled; otherwise the stack is searched for the first frame outside of logrus and this package.
+func callerFrame(entry *logrus.Entry) *runtime.Frame {
+	if entry.Caller != nil {
+		return entry.Caller
+	}
+	pcs := make([]uintptr, maxCallerDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame.Function) {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// isInternalFrame determines whether or not the function belongs to logrus, this package or the runtime.
+func isInternalFrame(function string) bool {
+	return strings.HasPrefix(function, "github.com/sirupsen/logrus.") ||
+		strings.HasPrefix(function, modulePath+".") ||
+		strings.HasPrefix(function, "runtime.")
+}
+
+// trimPrefixes removes the first matching prefix from the value.
+func trimPrefixes(value string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return strings.TrimPrefix(value, prefix)
+		}
+	}
+	return value
+}
+
+// CallerEnricher returns an enricher which adds the file, line and function of the caller to the fields. The first
+// matching prefix is trimmed from the file and function names.
+func CallerEnricher(prefixes ...string) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		frame := callerFrame(entry)
+		if frame == nil {
+			return
+		}
+		fields[CallerFileField] = trimPrefixes(frame.File, prefixes)
+		fields[CallerLineField] = frame.Line
+		fields[CallerFuncField] = trimPrefixes(frame.Function, prefixes)
+	})
+}
@@ -0,0 +1,118 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCallerPolicy(t *testing.T) {
+	frame := &runtime.Frame{
+		File:     "/home/build/go/src/github.com/my-org/my-app/server/handler.go",
+		Function: "github.com/my-org/my-app/server.(*Handler).ServeHTTP",
+		Line:     42,
+	}
+	tests := []struct {
+		policy   CallerPolicy
+		file     string
+		function string
+	}{
+		{CallerKeep, frame.File, frame.Function},
+		{CallerTrim, "server/handler.go", "server.(*Handler).ServeHTTP"},
+		{CallerShort, "handler.go", "server.(*Handler).ServeHTTP"},
+	}
+	prefixes := []string{"/home/build/go/src/github.com/my-org/my-app/", "github.com/my-org/my-app/"}
+	for _, test := range tests {
+		reported := test.policy.apply(frame, prefixes)
+		if reported.File != test.file || reported.Function != test.function || reported.Line != 42 {
+			t.Errorf("%s: unexpected caller %s %s:%d", test.policy, reported.Function, reported.File, reported.Line)
+		}
+	}
+	if frame.File != tests[0].file {
+		t.Errorf("expected the frame recorded by logrus to be left unchanged, got %s", frame.File)
+	}
+
+	// dropping the caller only affects the message sent to CloudWatch
+	h := newTestHook(t, "group", "stream", WithCallerPolicy(CallerDrop), WithDirectEncoding())
+	entry := &logrus.Entry{Message: "hello", Caller: frame}
+	line, err := h.format(entry)
+	if err != nil {
+		t.Fatalf("unable to format entry: %v", err)
+	}
+	if strings.Contains(line, "handler.go") || entry.Caller != frame {
+		t.Errorf("expected the caller to be dropped from the message only, got %s", line)
+	}
+
+	// the prefixes of the policy and of the caller fields are kept apart
+	h = newTestHook(t, "group", "stream", WithCaller(prefixes[0]), WithCallerPolicy(CallerKeep))
+	if len(h.callerTrimPrefixes) != 1 || len(h.callerPolicyTrims) != 0 {
+		t.Errorf("expected the caller policy to leave the prefixes of WithCaller alone, got %v", h.callerTrimPrefixes)
+	}
+}
+
+func TestCallerEnricher(t *testing.T) {
+	frame := &runtime.Frame{
+		File:     "/home/build/go/src/github.com/my-org/my-app/server/handler.go",
+		Function: "github.com/my-org/my-app/server.(*Handler).ServeHTTP",
+		Line:     42,
+	}
+	tests := []struct {
+		prefixes []string
+		file     string
+		function string
+	}{
+		{nil, frame.File, frame.Function},
+		{[]string{"/opt/"}, frame.File, frame.Function},
+		{[]string{"/opt/", "/home/build/go/src/github.com/my-org/my-app/", "github.com/my-org/my-app/"},
+			"server/handler.go", "server.(*Handler).ServeHTTP"},
+	}
+	for _, test := range tests {
+		fields := logrus.Fields{}
+		CallerEnricher(test.prefixes...).Enrich(&logrus.Entry{Caller: frame}, fields)
+		if fields[CallerFileField] != test.file || fields[CallerLineField] != 42 ||
+			fields[CallerFuncField] != test.function {
+			t.Errorf("%v: unexpected caller fields %v", test.prefixes, fields)
+		}
+	}
+
+	// without ReportCaller, the caller is found on the stack outside of logrus and this package
+	fields := logrus.Fields{}
+	CallerEnricher().Enrich(&logrus.Entry{}, fields)
+	function, _ := fields[CallerFuncField].(string)
+	if function == "" || isInternalFrame(function) || fields[CallerFileField] == "" || fields[CallerLineField] == 0 {
+		t.Errorf("expected the first caller outside of the hook, got %v", fields)
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	h, err := newHook(aws.Config{}, "group", "stream", WithDirectEncoding(),
+		WithCaller("/home/build/go/src/github.com/my-org/my-app/"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	entry := &logrus.Entry{
+		Message: "hello",
+		Data:    logrus.Fields{},
+		Caller: &runtime.Frame{
+			File:     "/home/build/go/src/github.com/my-org/my-app/server/handler.go",
+			Function: "github.com/my-org/my-app/server.(*Handler).ServeHTTP",
+			Line:     42,
+		},
+	}
+	line, err := encodeEntry(entry, h.optionEnrichers(), h.codec)
+	if err != nil {
+		t.Fatalf("unable to encode entry: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+		t.Fatalf("unable to decode %s: %v", line, err)
+	}
+	if decoded[CallerFileField] != "server/handler.go" || decoded[CallerLineField] != float64(42) ||
+		decoded[CallerFuncField] != "github.com/my-org/my-app/server.(*Handler).ServeHTTP" {
+		t.Errorf("expected the trimmed caller in the fields, got %s", line)
+	}
+}
@@ -0,0 +1,689 @@
+// Package chaos provides a fault-injecting, in-memory Amazon CloudWatch Logs client and a soak test harness for
+// validating the delivery guarantees of the hook under throttling, sequence token errors, latency spikes and partial
+// rejects.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const (
+	// maxEventSize is the maximum size of an event, including its overhead, accepted by the service.
+	maxEventSize = 262144
+
+	// eventOverhead is the number of bytes added to the size of each event by the service.
+	eventOverhead = 26
+
+	// maxBatchSpan is the longest time, in milliseconds, the events of a single batch may span.
+	maxBatchSpan = int64(24 * time.Hour / time.Millisecond)
+
+	// maxBatchBytes and maxBatchEvents are the largest total size, counting the overhead of each event, and number of
+	// events of a single batch.
+	maxBatchBytes  = 1048576
+	maxBatchEvents = 10000
+)
+
+// Faults configures how often the client injects each kind of fault into PutLogEvents calls. Rates are fractions of
+// calls between 0 and 1.
+type Faults struct {
+	// ThrottleRate is the fraction of calls which fail with a ThrottlingException.
+	ThrottleRate float64
+
+	// UnavailableRate is the fraction of calls which fail with a ServiceUnavailableException.
+	UnavailableRate float64
+
+	// TokenErrorRate is the fraction of calls which fail with an InvalidSequenceTokenException, as if another writer
+	// had written to the stream.
+	TokenErrorRate float64
+
+	// RejectRate is the fraction of calls in which the oldest event is rejected as too old while the rest of the batch
+	// is accepted.
+	RejectRate float64
+
+	// LatencyRate is the fraction of calls which are delayed by Latency before being processed.
+	LatencyRate float64
+
+	// Latency is the delay added to calls selected by LatencyRate.
+	Latency time.Duration
+
+	// DropRate is the fraction of calls which succeed without storing their events, as if they had been silently
+	// lost after being accepted.
+	DropRate float64
+}
+
+// stream holds the state of a fake log stream.
+type stream struct {
+	token   int
+	created time.Time
+	events  []types.InputLogEvent
+	tags    map[string]string
+}
+
+// Client is an in-memory implementation of the Amazon CloudWatch Logs API used by the hook which injects faults
+// according to its configuration. It is safe for concurrent use.
+type Client struct {
+	// RequireSequenceTokens makes the client reject PutLogEvents calls without the expected sequence token, as the
+	// service did before sequence tokens became optional.
+	RequireSequenceTokens bool
+
+	// Partition and Region are used in the ARNs of the log groups and streams. They default to aws and us-east-1.
+	Partition string
+	Region    string
+
+	mutex    sync.Mutex
+	faults   Faults
+	rand     *rand.Rand
+	groups   map[string]map[string]*stream
+	filters  map[string]string
+	tags     map[string]map[string]string
+	keys     map[string]string
+	calls    map[string]int
+	exports  []*types.ExportTask
+	rejected int
+}
+
+// NewClient creates a new fake client which injects the given faults.
+func NewClient(faults Faults) *Client {
+	return &Client{
+		faults:  faults,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		groups:  map[string]map[string]*stream{},
+		filters: map[string]string{},
+		tags:    map[string]map[string]string{},
+		keys:    map[string]string{},
+		calls:   map[string]int{},
+	}
+}
+
+// SetFaults replaces the faults injected by the client, for example to simulate an outage that later recovers.
+func (c *Client) SetFaults(faults Faults) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.faults = faults
+}
+
+// Events returns a copy of the events stored in the given stream.
+func (c *Client) Events(group, stream string) []types.InputLogEvent {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s, ok := c.groups[group][stream]
+	if !ok {
+		return nil
+	}
+	return append([]types.InputLogEvent{}, s.events...)
+}
+
+// Calls returns the number of calls made to the given operation, such as "PutLogEvents".
+func (c *Client) Calls(operation string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.calls[operation]
+}
+
+// Destination returns the destination ARN the given log group is subscribed to, if any.
+func (c *Client) Destination(group string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.filters[group]
+}
+
+// KMSKey returns the KMS key ID associated with the given log group, if any.
+func (c *Client) KMSKey(group string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.keys[group]
+}
+
+// StreamTags returns a copy of the tags applied to the given stream with TagResource.
+func (c *Client) StreamTags(group, stream string) map[string]string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s, ok := c.groups[group][stream]
+	if !ok {
+		return nil
+	}
+	tags := map[string]string{}
+	for k, v := range s.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// Rejected returns the number of events rejected as too old by injected partial rejects.
+func (c *Client) Rejected() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.rejected
+}
+
+// chance returns true with the given probability. The caller must hold the mutex.
+func (c *Client) chance(rate float64) bool {
+	return rate > 0 && c.rand.Float64() < rate
+}
+
+// CreateLogGroup creates an empty log group.
+func (c *Client) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["CreateLogGroup"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; ok {
+		return nil, &types.ResourceAlreadyExistsException{Message: aws.String("The specified log group already exists")}
+	}
+	c.groups[name] = map[string]*stream{}
+	c.tags[name] = map[string]string{}
+	if params.KmsKeyId != nil {
+		c.keys[name] = aws.ToString(params.KmsKeyId)
+	}
+	for k, v := range params.Tags {
+		c.tags[name][k] = v
+	}
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+// ListTagsLogGroup lists the tags of a log group.
+func (c *Client) ListTagsLogGroup(ctx context.Context, params *cloudwatchlogs.ListTagsLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsLogGroupOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["ListTagsLogGroup"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	tags := map[string]string{}
+	for k, v := range c.tags[name] {
+		tags[k] = v
+	}
+	return &cloudwatchlogs.ListTagsLogGroupOutput{Tags: tags}, nil
+}
+
+// TagLogGroup adds tags to a log group.
+func (c *Client) TagLogGroup(ctx context.Context, params *cloudwatchlogs.TagLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagLogGroupOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["TagLogGroup"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	for k, v := range params.Tags {
+		c.tags[name][k] = v
+	}
+	return &cloudwatchlogs.TagLogGroupOutput{}, nil
+}
+
+// AssociateKmsKey accepts any KMS key for an existing log group, replacing the key associated with it.
+func (c *Client) AssociateKmsKey(ctx context.Context, params *cloudwatchlogs.AssociateKmsKeyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.AssociateKmsKeyOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["AssociateKmsKey"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	c.keys[name] = aws.ToString(params.KmsKeyId)
+	return &cloudwatchlogs.AssociateKmsKeyOutput{}, nil
+}
+
+// DisassociateKmsKey removes the KMS key associated with an existing log group.
+func (c *Client) DisassociateKmsKey(ctx context.Context, params *cloudwatchlogs.DisassociateKmsKeyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DisassociateKmsKeyOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DisassociateKmsKey"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	delete(c.keys, name)
+	return &cloudwatchlogs.DisassociateKmsKeyOutput{}, nil
+}
+
+// CreateLogStream creates an empty log stream.
+func (c *Client) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["CreateLogStream"]++
+	group, ok := c.groups[aws.ToString(params.LogGroupName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	name := aws.ToString(params.LogStreamName)
+	if _, ok := group[name]; ok {
+		return nil, &types.ResourceAlreadyExistsException{
+			Message: aws.String("The specified log stream already exists"),
+		}
+	}
+	group[name] = &stream{created: time.Now()}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+// DeleteLogGroup deletes a log group along with its streams.
+func (c *Client) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DeleteLogGroup"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	delete(c.groups, name)
+	delete(c.tags, name)
+	delete(c.filters, name)
+	delete(c.keys, name)
+	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
+}
+
+// DeleteLogStream deletes a log stream along with its events.
+func (c *Client) DeleteLogStream(ctx context.Context, params *cloudwatchlogs.DeleteLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DeleteLogStream"]++
+	group, ok := c.groups[aws.ToString(params.LogGroupName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	name := aws.ToString(params.LogStreamName)
+	if _, ok := group[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log stream does not exist")}
+	}
+	delete(group, name)
+	return &cloudwatchlogs.DeleteLogStreamOutput{}, nil
+}
+
+// TagResource adds tags to the log stream with the given ARN, which is how the hook calls the TagResource operation
+// of newer versions of the SDK.
+func (c *Client) TagResource(ctx context.Context, resourceARN string, tags map[string]string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["TagResource"]++
+	for group, streams := range c.groups {
+		for name, s := range streams {
+			if c.arn("log-group:"+group+":log-stream:"+name) != resourceARN {
+				continue
+			}
+			if s.tags == nil {
+				s.tags = map[string]string{}
+			}
+			for k, v := range tags {
+				s.tags[k] = v
+			}
+			return nil
+		}
+	}
+	return &types.ResourceNotFoundException{Message: aws.String("The specified resource does not exist")}
+}
+
+// DescribeLogGroups lists the log groups matching the prefix.
+func (c *Client) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DescribeLogGroups"]++
+	output := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for name := range c.groups {
+		if strings.HasPrefix(name, aws.ToString(params.LogGroupNamePrefix)) {
+			group := types.LogGroup{
+				LogGroupName: aws.String(name),
+				Arn:          aws.String(c.arn("log-group:" + name + ":*")),
+			}
+			if key, ok := c.keys[name]; ok {
+				group.KmsKeyId = aws.String(key)
+			}
+			output.LogGroups = append(output.LogGroups, group)
+		}
+	}
+	return output, nil
+}
+
+// arn returns the ARN of a resource of the fake account. The caller must hold the mutex.
+func (c *Client) arn(resource string) string {
+	partition, region := c.Partition, c.Region
+	if partition == "" {
+		partition = "aws"
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return fmt.Sprintf("arn:%s:logs:%s:123456789012:%s", partition, region, resource)
+}
+
+// DescribeLogStreams lists the log streams matching the prefix.
+func (c *Client) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DescribeLogStreams"]++
+	groupName := aws.ToString(params.LogGroupName)
+	group, ok := c.groups[groupName]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	output := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	for name, s := range group {
+		if strings.HasPrefix(name, aws.ToString(params.LogStreamNamePrefix)) {
+			stream := types.LogStream{
+				LogStreamName:       aws.String(name),
+				UploadSequenceToken: c.token(s),
+				CreationTime:        aws.Int64(s.created.UnixNano() / int64(time.Millisecond)),
+				Arn:                 aws.String(c.arn("log-group:" + groupName + ":log-stream:" + name)),
+			}
+			for _, event := range s.events {
+				if aws.ToInt64(event.Timestamp) > aws.ToInt64(stream.LastEventTimestamp) {
+					stream.LastEventTimestamp = event.Timestamp
+				}
+			}
+			output.LogStreams = append(output.LogStreams, stream)
+		}
+	}
+	return output, nil
+}
+
+// CreateExportTask starts an export task for an existing log group. Tasks are pending when created, and each call to
+// DescribeExportTasks moves them on to running and then completed, without writing anything.
+func (c *Client) CreateExportTask(ctx context.Context, params *cloudwatchlogs.CreateExportTaskInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateExportTaskOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["CreateExportTask"]++
+	if _, ok := c.groups[aws.ToString(params.LogGroupName)]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	for _, task := range c.exports {
+		if task.Status.Code != types.ExportTaskStatusCodeCompleted {
+			return nil, &types.LimitExceededException{Message: aws.String("Resource limit exceeded")}
+		}
+	}
+	task := &types.ExportTask{
+		TaskId:            aws.String(strconv.Itoa(len(c.exports) + 1)),
+		LogGroupName:      params.LogGroupName,
+		Destination:       params.Destination,
+		DestinationPrefix: params.DestinationPrefix,
+		From:              params.From,
+		To:                params.To,
+		Status:            &types.ExportTaskStatus{Code: types.ExportTaskStatusCodePending},
+	}
+	c.exports = append(c.exports, task)
+	return &cloudwatchlogs.CreateExportTaskOutput{TaskId: task.TaskId}, nil
+}
+
+// DescribeExportTasks returns the export task with the given ID, or all export tasks, moving each one returned on to
+// its next status.
+func (c *Client) DescribeExportTasks(ctx context.Context, params *cloudwatchlogs.DescribeExportTasksInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeExportTasksOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DescribeExportTasks"]++
+	output := &cloudwatchlogs.DescribeExportTasksOutput{}
+	for _, task := range c.exports {
+		if params.TaskId != nil && aws.ToString(params.TaskId) != aws.ToString(task.TaskId) {
+			continue
+		}
+		switch task.Status.Code {
+		case types.ExportTaskStatusCodePending:
+			task.Status = &types.ExportTaskStatus{Code: types.ExportTaskStatusCodeRunning}
+		case types.ExportTaskStatusCodeRunning:
+			task.Status = &types.ExportTaskStatus{Code: types.ExportTaskStatusCodeCompleted}
+		}
+		copied := *task
+		output.ExportTasks = append(output.ExportTasks, copied)
+	}
+	return output, nil
+}
+
+// ExportTasks returns a copy of the export tasks created with CreateExportTask.
+func (c *Client) ExportTasks() []types.ExportTask {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	tasks := make([]types.ExportTask, len(c.exports))
+	for i, task := range c.exports {
+		tasks[i] = *task
+	}
+	return tasks
+}
+
+// FilterLogEvents returns the events of the given streams, or of all streams in the group, with timestamps in the
+// range from the start time to the end time. Filter patterns and pagination are not supported.
+func (c *Client) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["FilterLogEvents"]++
+	group, ok := c.groups[aws.ToString(params.LogGroupName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	names := params.LogStreamNames
+	if len(names) == 0 {
+		for name := range group {
+			names = append(names, name)
+		}
+	}
+	output := &cloudwatchlogs.FilterLogEventsOutput{}
+	for _, name := range names {
+		s, ok := group[name]
+		if !ok {
+			continue
+		}
+		for _, event := range s.events {
+			ts := aws.ToInt64(event.Timestamp)
+			if (params.StartTime != nil && ts < *params.StartTime) || (params.EndTime != nil && ts >= *params.EndTime) {
+				continue
+			}
+			output.Events = append(output.Events, types.FilteredLogEvent{
+				LogStreamName: aws.String(name),
+				Message:       aws.String(aws.ToString(event.Message)),
+				Timestamp:     aws.Int64(ts),
+			})
+		}
+	}
+	return output, nil
+}
+
+// token returns the current sequence token of the stream, or nil if nothing has been written yet.
+func (c *Client) token(s *stream) *string {
+	if s.token == 0 {
+		return nil
+	}
+	return aws.String(strconv.Itoa(s.token))
+}
+
+// RequestID returns the request ID of the nth PutLogEvents call, counting from 1, as returned in its result metadata
+// or, if it failed, in its error, with the extended request ID "<request ID>-extended".
+func RequestID(n int) string {
+	return fmt.Sprintf("chaos-%d", n)
+}
+
+// respond wraps an error returned by the service in a response error holding the request IDs of the call, as the SDK
+// does.
+func respond(requestID string, err error) error {
+	header := http.Header{}
+	header.Set("X-Amz-Id-2", requestID+"-extended")
+	status := http.StatusBadRequest
+	var unavailable *types.ServiceUnavailableException
+	if errors.As(err, &unavailable) {
+		status = http.StatusServiceUnavailable
+	}
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status, Header: header}},
+			Err:      err,
+		},
+		RequestID: requestID,
+	}
+}
+
+// PutLogEvents stores the events in the stream, injecting faults according to the configuration. As with the service,
+// the whole batch is rejected if any event is too large or the batch exceeds the size, count or span limits. Each call
+// is given a request ID returned by RequestID.
+func (c *Client) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	c.mutex.Lock()
+	c.calls["PutLogEvents"]++
+	requestID := RequestID(c.calls["PutLogEvents"])
+	delay := c.chance(c.faults.LatencyRate)
+	latency := c.faults.Latency
+	c.mutex.Unlock()
+	if delay {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	output, err := c.putLogEvents(params)
+	if err != nil {
+		return nil, respond(requestID, err)
+	}
+	awsmiddleware.SetRequestIDMetadata(&output.ResultMetadata, requestID)
+	return output, nil
+}
+
+// putLogEvents stores the events for PutLogEvents. The caller must hold the mutex.
+func (c *Client) putLogEvents(params *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	s, ok := c.groups[aws.ToString(params.LogGroupName)][aws.ToString(params.LogStreamName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log stream does not exist")}
+	}
+	switch {
+	case c.chance(c.faults.ThrottleRate):
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	case c.chance(c.faults.UnavailableRate):
+		return nil, &types.ServiceUnavailableException{Message: aws.String("The service is unavailable")}
+	case c.chance(c.faults.TokenErrorRate):
+		// another writer got there first
+		s.token++
+		return nil, &types.InvalidSequenceTokenException{
+			Message:               aws.String("The given sequenceToken is invalid"),
+			ExpectedSequenceToken: c.token(s),
+		}
+	}
+	if c.RequireSequenceTokens && aws.ToString(params.SequenceToken) != aws.ToString(c.token(s)) {
+		return nil, &types.InvalidSequenceTokenException{
+			Message:               aws.String("The given sequenceToken is invalid"),
+			ExpectedSequenceToken: c.token(s),
+		}
+	}
+
+	size := 0
+	for _, event := range params.LogEvents {
+		if len(aws.ToString(event.Message))+eventOverhead > maxEventSize {
+			return nil, &types.InvalidParameterException{
+				Message: aws.String("Log event too large"),
+			}
+		}
+		size += len(aws.ToString(event.Message)) + eventOverhead
+	}
+	if size > maxBatchBytes || len(params.LogEvents) > maxBatchEvents {
+		return nil, &types.InvalidParameterException{
+			Message: aws.String("The batch of log events in a single PutLogEvents request exceeds the maximum " +
+				"size or number of events"),
+		}
+	}
+
+	if n := len(params.LogEvents); n > 1 {
+		first, last := aws.ToInt64(params.LogEvents[0].Timestamp), aws.ToInt64(params.LogEvents[n-1].Timestamp)
+		if last-first >= maxBatchSpan {
+			return nil, &types.InvalidParameterException{
+				Message: aws.String("The batch of log events in a single PutLogEvents request cannot span more " +
+					"than 24 hours"),
+			}
+		}
+	}
+
+	output := &cloudwatchlogs.PutLogEventsOutput{}
+	events := params.LogEvents
+	if len(events) > 0 && c.chance(c.faults.RejectRate) {
+		output.RejectedLogEventsInfo = &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(1)}
+		events = events[1:]
+		c.rejected++
+	}
+	if c.chance(c.faults.DropRate) {
+		events = nil
+	}
+	for _, event := range events {
+		// copy the event since the hook reuses its memory
+		s.events = append(s.events, types.InputLogEvent{
+			Message:   aws.String(aws.ToString(event.Message)),
+			Timestamp: aws.Int64(aws.ToInt64(event.Timestamp)),
+		})
+	}
+	s.token++
+	output.NextSequenceToken = c.token(s)
+	return output, nil
+}
+
+// PutRetentionPolicy accepts any retention policy.
+func (c *Client) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["PutRetentionPolicy"]++
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+// DeleteRetentionPolicy accepts any request to remove the retention policy.
+func (c *Client) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["DeleteRetentionPolicy"]++
+	return &cloudwatchlogs.DeleteRetentionPolicyOutput{}, nil
+}
+
+// PutSubscriptionFilter subscribes an existing log group to a destination.
+func (c *Client) PutSubscriptionFilter(ctx context.Context, params *cloudwatchlogs.PutSubscriptionFilterInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutSubscriptionFilterOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls["PutSubscriptionFilter"]++
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := c.groups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist")}
+	}
+	c.filters[name] = aws.ToString(params.DestinationArn)
+	return &cloudwatchlogs.PutSubscriptionFilterOutput{}, nil
+}
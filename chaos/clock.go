@@ -0,0 +1,95 @@
+package chaos
+
+import (
+	"sync"
+	"time"
+
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// Clock is a fake clock which only moves when it is advanced, allowing the timing of the hook, such as when batches
+// are uploaded, to be tested deterministically. Pass it to the hook with the WithClock option. It is safe for
+// concurrent use.
+type Clock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers map[*timer]bool
+}
+
+// NewClock creates a fake clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:    now,
+		timers: map[*timer]bool{},
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer which fires once the clock has been advanced by the given duration.
+func (c *Clock) NewTimer(d time.Duration) cloudwatchhook.Timer {
+	t := &timer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Timers returns the number of timers waiting to fire, which can be used to wait for the hook to schedule its work
+// before advancing the clock.
+func (c *Clock) Timers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// Advance moves the clock forward by the given duration, firing any timers which become due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		if !t.deadline.After(c.now) {
+			delete(c.timers, t)
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+// timer is a timer scheduled by a fake clock.
+type timer struct {
+	clock    *Clock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+// C returns the channel on which the time is delivered when the timer fires.
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Reset reschedules the timer to fire once the clock has been advanced by the given duration. It returns true if the
+// timer had been active.
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	active := t.clock.timers[t]
+	t.deadline = t.clock.now.Add(d)
+	t.clock.timers[t] = true
+	return active
+}
+
+// Stop prevents the timer from firing. It returns true if the timer had been active.
+func (t *timer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return active
+}
@@ -0,0 +1,43 @@
+package chaos
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/sirupsen/logrus"
+)
+
+func TestClockControlsBatching(t *testing.T) {
+	client := NewClient(Faults{})
+	clock := NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(time.Minute),
+		cloudwatchhook.WithClock(clock))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	for i := 0; i < 3; i++ {
+		logger.Info("batched")
+	}
+
+	// nothing is uploaded until the clock reaches the batch duration, however long it really takes
+	time.Sleep(50 * time.Millisecond)
+	if n := client.Calls("PutLogEvents"); n != 0 {
+		t.Fatalf("expected no uploads before the clock is advanced, got %d", n)
+	}
+	for i := 0; i < 100 && len(client.Events("group", "stream")) < 3; i++ {
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(client.Events("group", "stream")); n != 3 {
+		t.Errorf("expected 3 events after advancing the clock, got %d", n)
+	}
+}
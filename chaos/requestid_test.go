@@ -0,0 +1,70 @@
+package chaos
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// letterSink keeps the dead letters it is sent.
+type letterSink struct {
+	mutex   sync.Mutex
+	letters []cloudwatchhook.DeadLetter
+}
+
+func (s *letterSink) Send(ctx context.Context, letter cloudwatchhook.DeadLetter) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func TestRequestIDs(t *testing.T) {
+	client := NewClient(Faults{RejectRate: 1})
+	sink := &letterSink{}
+	var receipts []cloudwatchhook.BatchReceipt
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithDeadLetterSink(sink),
+		cloudwatchhook.WithDeliveryCallback(func(receipt cloudwatchhook.BatchReceipt) {
+			receipts = append(receipts, receipt)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	hook.Write([]byte("too old"))
+	hook.Write([]byte("delivered"))
+	hook.Write([]byte(strings.Repeat("x", maxEventSize)))
+	if err := hook.Close(); err == nil {
+		t.Errorf("expected an error for the oversized event")
+	}
+
+	// the oversized event is isolated from the valid ones, whose batches are delivered with the oldest event rejected
+	calls := client.Calls("PutLogEvents")
+	if len(receipts) != 2 || len(sink.letters) != 3 {
+		t.Fatalf("expected 2 receipts and 3 dead letters, got %+v and %+v", receipts, sink.letters)
+	}
+	for i, receipt := range receipts {
+		if receipt.RequestID == "" || receipt.RequestID == RequestID(calls) {
+			t.Errorf("expected the receipt to carry the request ID of its call, got %+v", receipt)
+		}
+		if letter := sink.letters[i]; letter.RequestID != receipt.RequestID || letter.ExtendedRequestID != "" {
+			t.Errorf("expected the rejected event to carry the request ID %s of its batch, got %+v",
+				receipt.RequestID, letter)
+		}
+	}
+	if receipts[0].RequestID == receipts[1].RequestID {
+		t.Errorf("expected each receipt to carry its own request ID, got %s twice", receipts[0].RequestID)
+	}
+	if letter := sink.letters[2]; letter.RequestID != RequestID(calls) ||
+		letter.ExtendedRequestID != RequestID(calls)+"-extended" {
+		t.Errorf("expected the oversized event to carry the request IDs of the failed call, got %+v", letter)
+	}
+}
@@ -0,0 +1,161 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/sirupsen/logrus"
+)
+
+// soakMessagePattern extracts the identifier of a soak test event from a delivered message.
+var soakMessagePattern = regexp.MustCompile(`soak event (\d+-\d+)`)
+
+// SoakConfig configures a soak test run.
+type SoakConfig struct {
+	// Duration is how long events are written for.
+	Duration time.Duration
+
+	// Writers is the number of goroutines writing events concurrently.
+	Writers int
+
+	// Rate is the number of events written per second by each writer.
+	Rate int
+}
+
+// SoakReport summarizes the outcome of a soak test run.
+type SoakReport struct {
+	// Sent is the number of events written to the hook.
+	Sent int
+
+	// Delivered is the number of unique events stored by the fake client.
+	Delivered int
+
+	// DeadLettered is the number of unique events handed to the dead letter recorder.
+	DeadLettered int
+
+	// Rejected is the number of events rejected by the fake client as too old.
+	Rejected int
+
+	// Duplicates is the number of events delivered more than once.
+	Duplicates int
+
+	// Lost is the number of events which were neither delivered nor dead lettered.
+	Lost int
+
+	// Errors is the number of errors returned by the hook while writing events.
+	Errors int
+
+	// Elapsed is the total time taken, including closing the hook.
+	Elapsed time.Duration
+}
+
+// String returns a one line summary of the report.
+func (r SoakReport) String() string {
+	return fmt.Sprintf("sent=%d delivered=%d dead_lettered=%d rejected=%d duplicates=%d lost=%d errors=%d elapsed=%s",
+		r.Sent, r.Delivered, r.DeadLettered, r.Rejected, r.Duplicates, r.Lost, r.Errors, r.Elapsed)
+}
+
+// DeadLetterRecorder is a dead letter sink which keeps undeliverable events in memory so that a soak test can account
+// for them.
+type DeadLetterRecorder struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+// Send records the messages of the undeliverable events.
+func (r *DeadLetterRecorder) Send(ctx context.Context, letter cloudwatchhook.DeadLetter) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, event := range letter.Events {
+		r.messages = append(r.messages, aws.ToString(event.Message))
+	}
+	return nil
+}
+
+// Messages returns a copy of the recorded messages.
+func (r *DeadLetterRecorder) Messages() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.messages...)
+}
+
+// Soak writes events to the hook through the logger from several goroutines for the configured duration, closes the
+// hook and then compares what was sent with what the fake client stored and what was dead lettered. The hook must
+// have been created with the fake client for the given group and stream. The recorder may be nil if the hook has no
+// dead letter sink.
+func Soak(logger *logrus.Logger, hook *cloudwatchhook.CloudWatchLogsHook, client *Client,
+	recorder *DeadLetterRecorder, group, stream string, config SoakConfig) SoakReport {
+
+	if config.Writers < 1 {
+		config.Writers = 1
+	}
+	if config.Rate < 1 {
+		config.Rate = 1
+	}
+	start := time.Now()
+	deadline := start.Add(config.Duration)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	report := SoakReport{}
+	for w := 0; w < config.Writers; w++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			ticker := time.NewTicker(time.Second / time.Duration(config.Rate))
+			defer ticker.Stop()
+			sent, errors := 0, 0
+			for seq := 0; time.Now().Before(deadline); seq++ {
+				<-ticker.C
+				entry := logrus.NewEntry(logger)
+				entry.Time = time.Now()
+				entry.Level = logrus.InfoLevel
+				entry.Message = fmt.Sprintf("soak event %d-%d", writer, seq)
+				if err := hook.Fire(entry); err != nil {
+					errors++
+				}
+				sent++
+			}
+			mutex.Lock()
+			report.Sent += sent
+			report.Errors += errors
+			mutex.Unlock()
+		}(w)
+	}
+	wg.Wait()
+	if err := hook.Close(); err != nil {
+		report.Errors++
+	}
+	report.Elapsed = time.Since(start)
+
+	// account for every event sent
+	delivered := map[string]int{}
+	for _, event := range client.Events(group, stream) {
+		if match := soakMessagePattern.FindStringSubmatch(aws.ToString(event.Message)); match != nil {
+			delivered[match[1]]++
+		}
+	}
+	for _, count := range delivered {
+		report.Delivered++
+		report.Duplicates += count - 1
+	}
+	deadLettered := map[string]bool{}
+	if recorder != nil {
+		for _, msg := range recorder.Messages() {
+			if match := soakMessagePattern.FindStringSubmatch(msg); match != nil {
+				if _, ok := delivered[match[1]]; !ok {
+					deadLettered[match[1]] = true
+				}
+			}
+		}
+	}
+	report.DeadLettered = len(deadLettered)
+	report.Rejected = client.Rejected()
+	report.Lost = report.Sent - report.Delivered - report.DeadLettered
+	return report
+}
@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/sirupsen/logrus"
+)
+
+var soakDuration = flag.Duration("soak", 2*time.Second, "how long to run the soak test for")
+
+func TestSoak(t *testing.T) {
+	client := NewClient(Faults{
+		ThrottleRate:    0.05,
+		UnavailableRate: 0.02,
+		TokenErrorRate:  0.05,
+		RejectRate:      0.02,
+		LatencyRate:     0.05,
+		Latency:         50 * time.Millisecond,
+	})
+	client.RequireSequenceTokens = true
+	recorder := &DeadLetterRecorder{}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "soak-group", "soak-stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(100*time.Millisecond),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Millisecond, MaxRetries: 10}),
+		cloudwatchhook.WithDeadLetterSink(recorder),
+	)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	report := Soak(logger, hook, client, recorder, "soak-group", "soak-stream", SoakConfig{
+		Duration: *soakDuration,
+		Writers:  4,
+		Rate:     200,
+	})
+	t.Log(report)
+	if report.Sent == 0 {
+		t.Fatalf("no events were sent")
+	}
+	if report.Lost != 0 {
+		t.Errorf("%d events were lost", report.Lost)
+	}
+}
+
+func TestPoisonEvent(t *testing.T) {
+	client := NewClient(Faults{})
+	recorder := &DeadLetterRecorder{}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "poison-group", "poison-stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithDeadLetterSink(recorder),
+	)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	for i := 0; i < 9; i++ {
+		hook.Write([]byte("valid event"))
+	}
+	hook.Write([]byte(strings.Repeat("x", maxEventSize)))
+	if err := hook.Close(); err == nil {
+		t.Errorf("expected an error for the oversized event")
+	}
+
+	if n := len(client.Events("poison-group", "poison-stream")); n != 9 {
+		t.Errorf("expected the 9 valid events to be delivered, got %d", n)
+	}
+	if n := len(recorder.Messages()); n != 1 {
+		t.Errorf("expected only the oversized event to be dead lettered, got %d", n)
+	}
+}
@@ -0,0 +1,88 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Child creates a hook which inherits the configuration of this hook but writes to a different stream, named by adding
+// the suffix to the name of this hook's stream. The child shares the client, and therefore the credentials and
+// connections, and the log group of this hook, so it is a cheap way of separating the logs of each subcomponent of an
+// application. The options are applied on top of the inherited configuration and change how the entries of the child
+// are formatted and filtered; its events are queued, batched and archived by the hook at the root of the family, which
+// runs the only background workers of the family. Closing this hook also closes its children and sends their queued
+// events.
+func (h *CloudWatchLogsHook) Child(streamSuffix string, options ...CloudWatchLogsHookOption) (
+	*CloudWatchLogsHook, error) {
+
+	if streamSuffix == "" {
+		return nil, fmt.Errorf("Invalid stream suffix: must not be empty")
+	}
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return nil, fmt.Errorf("Unable to create child hook: hook is closed")
+	}
+
+	inherited := make([]CloudWatchLogsHookOption, 0, len(h.options)+len(options)+1)
+	inherited = append(inherited, h.options...)
+	inherited = append(inherited, withParent(h))
+	inherited = append(inherited, options...)
+	child, err := NewCloudWatchLogsHook(h.config, h.group, h.stream+streamSuffix, inherited...)
+	if err != nil {
+		return nil, err
+	}
+
+	h.childMutex.Lock()
+	defer h.childMutex.Unlock()
+	h.children = append(h.children, child)
+	return child, nil
+}
+
+// queueOwner returns the hook whose queue the events of this hook are sent through, which is the root of the family
+// for a child, including a child of a child.
+func (h *CloudWatchLogsHook) queueOwner() *CloudWatchLogsHook {
+	owner := h
+	for owner.parent != nil {
+		owner = owner.parent
+	}
+	return owner
+}
+
+// withParent marks the hook as a child of the given hook, from which it inherits the client and log group.
+func withParent(parent *CloudWatchLogsHook) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.parent = parent
+	}
+}
+
+// inheritGroupInfo copies the ARN of the log group from the parent, which shares the group with this hook.
+func (h *CloudWatchLogsHook) inheritGroupInfo() {
+	groupARN := h.parent.GroupARN()
+	h.resourceMutex.Lock()
+	defer h.resourceMutex.Unlock()
+	h.groupARN = groupARN
+	if h.streamARN == "" && groupARN != "" {
+		h.streamARN = groupARN + ":log-stream:" + h.stream
+	}
+}
+
+// closeChildren closes every child of the hook, giving up once the context is done, and returns their combined result
+// and the first error encountered. The children are kept so that the final ops summary still covers them; closing
+// them again returns an empty result.
+func (h *CloudWatchLogsHook) closeChildren(ctx context.Context) (CloseResult, error) {
+	h.childMutex.Lock()
+	children := append([]*CloudWatchLogsHook(nil), h.children...)
+	h.childMutex.Unlock()
+
+	var result CloseResult
+	var firstErr error
+	for _, child := range children {
+		childResult, err := child.CloseContext(ctx)
+		result.Flushed += childResult.Flushed
+		result.Abandoned += childResult.Abandoned
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return result, firstErr
+}
@@ -0,0 +1,163 @@
+package cloudwatchhook_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestChild(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "app", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	child, err := hook.Child("-db", cloudwatchhook.WithSchemaVersion("2"))
+	if err != nil {
+		t.Fatalf("unable to create child hook: %v", err)
+	}
+	if n := client.Calls("CreateLogGroup"); n != 1 {
+		t.Errorf("expected the log group to be created once, got %d calls", n)
+	}
+	config := child.Config()
+	if config.Stream != "app-db" || config.BatchDuration != time.Hour || config.SchemaVersion != "2" {
+		t.Errorf("unexpected child configuration: %+v", config)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(child)
+	logger.Info("query")
+
+	// closing the parent flushes the child
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(client.Events("group", "app-db")); n != 1 {
+		t.Errorf("expected 1 event in the child stream, got %d", n)
+	}
+	if _, err := hook.Child("-cache"); err == nil {
+		t.Errorf("expected an error creating a child of a closed hook")
+	}
+}
+
+func TestChildSharesParentQueue(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "app", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithRetargetPolicy(cloudwatchhook.RetargetToCurrent))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	child, err := hook.Child("-db")
+	if err != nil {
+		t.Fatalf("unable to create child hook: %v", err)
+	}
+	fmt.Fprint(child, "query")
+	fmt.Fprint(hook, "request")
+
+	// the events of the child stay pinned to its stream when the queued events of the parent follow it elsewhere
+	if err := hook.Update(cloudwatchhook.UpdateTarget("group", "app-v2")); err != nil {
+		t.Fatalf("unable to update the target: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(client.Events("group", "app-db")); n != 1 {
+		t.Errorf("expected 1 event in the child stream, got %d", n)
+	}
+	if n := len(client.Events("group", "app-v2")); n != 1 {
+		t.Errorf("expected 1 event in the new stream of the parent, got %d", n)
+	}
+	if parent, own := hook.Stats().Delivered, child.Stats().Delivered; parent != 2 || own != 0 {
+		t.Errorf("expected the parent to deliver the events of the family, got %d and %d", parent, own)
+	}
+}
+
+func TestChildrenSharingStreamStayOrdered(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "app", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour), cloudwatchhook.WithClock(clock))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	first, err := hook.Child("-db")
+	if err != nil {
+		t.Fatalf("unable to create child hook: %v", err)
+	}
+	second, err := hook.Child("-db")
+	if err != nil {
+		t.Fatalf("unable to create child hook: %v", err)
+	}
+
+	// both children write to the same stream, so their events are merged into one batch
+	written := []string{}
+	write := func(child *cloudwatchhook.CloudWatchLogsHook, msg string) {
+		fmt.Fprint(child, msg)
+		written = append(written, msg)
+		clock.Advance(time.Second)
+	}
+	write(first, "a1")
+	write(first, "a2")
+	write(second, "b1")
+	write(first, "a3")
+	write(second, "b2")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := client.Events("group", "app-db")
+	if len(events) != len(written) {
+		t.Fatalf("expected %d events, got %d", len(written), len(events))
+	}
+	for i, event := range events {
+		if msg := aws.ToString(event.Message); msg != written[i] {
+			t.Errorf("expected event %d to be %s, got %s", i, written[i], msg)
+		}
+		if i > 0 && aws.ToInt64(event.Timestamp) < aws.ToInt64(events[i-1].Timestamp) {
+			t.Errorf("expected event %d to be no older than the one before it", i)
+		}
+	}
+}
+
+func TestGrandchild(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "app", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	child, err := hook.Child("-db")
+	if err != nil {
+		t.Fatalf("unable to create child hook: %v", err)
+	}
+	grandchild, err := child.Child("-primary")
+	if err != nil {
+		t.Fatalf("unable to create grandchild hook: %v", err)
+	}
+
+	// the events of the grandchild are batched by the root rather than sent as they are written
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(grandchild, "query %d", i)
+	}
+	if calls := client.Calls("PutLogEvents"); calls != 0 {
+		t.Errorf("expected the events to be batched, got %d PutLogEvents calls", calls)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if events := client.Events("group", "app-db-primary"); len(events) != 3 {
+		t.Errorf("expected 3 events in the stream of the grandchild, got %d", len(events))
+	}
+	if calls := client.Calls("PutLogEvents"); calls != 1 {
+		t.Errorf("expected the events to be sent in a single batch, got %d PutLogEvents calls", calls)
+	}
+}
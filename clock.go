@@ -0,0 +1,56 @@
+package cloudwatchhook
+
+import (
+	"time"
+)
+
+// Clock is used to tell the time, including the timestamps of events, and to schedule the work of the hook, such as
+// uploading batches, emitting heartbeats and waiting between retries. Replacing it with a fake clock, such as the one
+// provided by the chaos package, allows the timing of the hook to be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event scheduled by a Clock, with the same semantics as time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// systemClock is the Clock backed by the time package which is used by default.
+type systemClock struct{}
+
+// Now returns the current time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a timer which fires after the given duration.
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer adapts time.Timer to the Timer interface.
+type systemTimer struct {
+	*time.Timer
+}
+
+// C returns the channel on which the time is delivered when the timer fires.
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// sleep waits for the duration on the clock of the hook. It returns false if the cancel channel, which may be nil, is
+// closed first.
+func (h *CloudWatchLogsHook) sleep(d time.Duration, cancel <-chan struct{}) bool {
+	timer := h.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-cancel:
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package cloudwatchhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestClockDrivesBackoffAndTimestamps(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := chaos.NewClient(chaos.Faults{ThrottleRate: 1})
+	clock := chaos.NewClock(start)
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Minute, MaxRetries: 3}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := hook.Write([]byte("throttled"))
+		written <- err
+	}()
+
+	// each retry waits for the clock rather than the system time
+	waitForCalls := func(want int) {
+		for i := 0; i < 100 && client.Calls("PutLogEvents") < want; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if n := client.Calls("PutLogEvents"); n != want {
+			t.Fatalf("expected %d calls, got %d", want, n)
+		}
+	}
+	waitForCalls(1)
+	client.SetFaults(chaos.Faults{})
+	clock.Advance(time.Minute)
+	waitForCalls(2)
+	if err := <-written; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// events are stamped with the time of the clock
+	events := client.Events("group", "stream")
+	if len(events) != 1 || aws.ToInt64(events[0].Timestamp) != start.UnixNano()/int64(time.Millisecond) {
+		t.Errorf("expected the event to be stamped with the time of the clock, got %v", events)
+	}
+}
@@ -0,0 +1,138 @@
+// Command cwhook-decrypt decrypts log events written with the WithBatchEncryption option of the hook. It reads the
+// envelopes either from a log stream or, one message per line, from standard input and prints each decrypted event
+// on its own line, preceded by its timestamp. Data keys are decrypted by calling the AWS CLI, so it must be installed
+// and have permission to use the KMS key.
+//
+// Usage:
+//
+//	cwhook-decrypt -group /app/payments -stream web-1
+//	aws logs get-log-events ... --query 'events[].message' --output text | tr '\t' '\n' | cwhook-decrypt
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// awsCLIKeys decrypts data keys by calling "aws kms decrypt".
+type awsCLIKeys struct{}
+
+// GenerateDataKey is not needed for decryption.
+func (awsCLIKeys) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	return nil, nil, fmt.Errorf("Generating data keys is not supported")
+}
+
+// Decrypt returns the plaintext of the encrypted data key.
+func (awsCLIKeys) Decrypt(ctx context.Context, encrypted []byte) ([]byte, error) {
+	blob, err := ioutil.TempFile("", "cwhook-decrypt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(blob.Name())
+	if _, err := blob.Write(encrypted); err != nil {
+		blob.Close()
+		return nil, err
+	}
+	blob.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "aws", "kms", "decrypt", "--ciphertext-blob", "fileb://"+blob.Name(),
+		"--query", "Plaintext", "--output", "text")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(out)))
+}
+
+// open decrypts and prints the events held in an envelope.
+func open(ctx context.Context, message string) error {
+	events, err := cloudwatchhook.OpenEnvelope(ctx, awsCLIKeys{}, message)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		fmt.Printf("%s %s\n", event.Timestamp.UTC().Format(time.RFC3339Nano), event.Message)
+	}
+	return nil
+}
+
+// openStream decrypts every envelope in the log stream.
+func openStream(ctx context.Context, group, stream string) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to load AWS default configuration: %v", err)
+	}
+	client := cloudwatchlogs.NewFromConfig(cfg)
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(group),
+		LogStreamName: aws.String(stream),
+		StartFromHead: aws.Bool(true),
+	}
+	for {
+		result, err := client.GetLogEvents(ctx, input)
+		if err != nil {
+			return fmt.Errorf("Failed to get log events: %v", err)
+		}
+		for _, event := range result.Events {
+			if err := open(ctx, aws.ToString(event.Message)); err != nil {
+				return err
+			}
+		}
+
+		// the forward token is returned unchanged once the end of the stream is reached
+		if aws.ToString(result.NextForwardToken) == aws.ToString(input.NextToken) {
+			return nil
+		}
+		input.NextToken = result.NextForwardToken
+	}
+}
+
+func main() {
+	group := flag.String("group", "", "log group to read the envelopes from")
+	stream := flag.String("stream", "", "log stream to read the envelopes from")
+	flag.Parse()
+	ctx := context.Background()
+
+	if *group != "" || *stream != "" {
+		if *group == "" || *stream == "" {
+			fmt.Fprintf(os.Stderr, "ERROR: Both -group and -stream must be given\n")
+			os.Exit(1)
+		}
+		if err := openStream(ctx, *group, *stream); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 512*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := open(ctx, scanner.Text()); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(2)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Failed to read standard input: %s\n", err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,316 @@
+// Command cwhook-doctor diagnoses why log events sent by the hook are not showing up in Amazon CloudWatch Logs. It
+// checks the AWS configuration and credentials, the log group and stream and the permissions needed to use them,
+// then sends test events through the hook, tails them back from the stream and measures how long they took to be
+// ingested and to become visible. It finishes with a diagnosis listing each problem found and how to fix it.
+//
+// Usage:
+//
+//	cwhook-doctor -group /app/payments -stream web-1
+//	cwhook-doctor -group /app/payments -stream doctor -create -events 10 -timeout 1m
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// doctor runs the checks and collects the problems found.
+type doctor struct {
+	ctx      context.Context
+	cfg      aws.Config
+	client   *cloudwatchlogs.Client
+	group    string
+	stream   string
+	create   bool
+	problems []string
+}
+
+// pass reports a successful check.
+func (d *doctor) pass(format string, args ...interface{}) {
+	fmt.Printf("[ OK ] %s\n", fmt.Sprintf(format, args...))
+}
+
+// warn reports a check which found something worth knowing about which does not stop delivery.
+func (d *doctor) warn(format string, args ...interface{}) {
+	fmt.Printf("[WARN] %s\n", fmt.Sprintf(format, args...))
+}
+
+// fail reports a failed check and records the advice for fixing it in the diagnosis.
+func (d *doctor) fail(advice string, format string, args ...interface{}) {
+	fmt.Printf("[FAIL] %s\n", fmt.Sprintf(format, args...))
+	d.problems = append(d.problems, advice)
+}
+
+// denied determines whether or not the error is because the credentials are not allowed to call the operation, in
+// which case it records the missing permission.
+func (d *doctor) denied(operation string, err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDeniedException" {
+		return false
+	}
+	d.fail(fmt.Sprintf("Allow the logs:%s action in the IAM policy of the credentials", operation),
+		"%s was denied: %v", operation, err)
+	return true
+}
+
+// checkCredentials checks that a region is configured and that credentials can be retrieved.
+func (d *doctor) checkCredentials() bool {
+	if d.cfg.Region == "" {
+		d.fail("Set the AWS_REGION environment variable or the region of the AWS profile", "No AWS region configured")
+		return false
+	}
+	d.pass("Region is %s", d.cfg.Region)
+	if d.cfg.Credentials == nil {
+		d.fail("Configure AWS credentials, for example with AWS_PROFILE or an instance role",
+			"No AWS credentials found")
+		return false
+	}
+	creds, err := d.cfg.Credentials.Retrieve(d.ctx)
+	if err != nil {
+		d.fail("Configure AWS credentials, for example with AWS_PROFILE or an instance role",
+			"Unable to retrieve AWS credentials: %v", err)
+		return false
+	}
+	if creds.CanExpire && time.Until(creds.Expires) < 5*time.Minute {
+		d.warn("Credentials from %s expire at %s", creds.Source, creds.Expires.Format(time.RFC3339))
+	} else {
+		d.pass("Credentials retrieved from %s", creds.Source)
+	}
+	return true
+}
+
+// checkGroup checks that the log group exists and reports its settings.
+func (d *doctor) checkGroup() bool {
+	result, err := d.client.DescribeLogGroups(d.ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(d.group),
+	})
+	if err != nil {
+		if !d.denied("DescribeLogGroups", err) {
+			d.fail("Check network access to the CloudWatch Logs endpoint of the region",
+				"Unable to describe log groups: %v", err)
+		}
+		return false
+	}
+	for _, group := range result.LogGroups {
+		if aws.ToString(group.LogGroupName) != d.group {
+			continue
+		}
+		d.pass("Log group %s exists (%d bytes stored)", d.group, aws.ToInt64(group.StoredBytes))
+		if group.RetentionInDays != nil {
+			d.pass("Events are retained for %d days", aws.ToInt32(group.RetentionInDays))
+		}
+		if group.KmsKeyId != nil {
+			d.warn("Log group is encrypted with %s; the credentials of readers need kms:Decrypt",
+				aws.ToString(group.KmsKeyId))
+		}
+		return true
+	}
+	if d.create {
+		d.warn("Log group %s does not exist and will be created", d.group)
+		return true
+	}
+	d.fail("Create the log group, for example with cwhook-provision, or run with -create",
+		"Log group %s does not exist", d.group)
+	return false
+}
+
+// checkStream checks that the log stream exists and when it last received events.
+func (d *doctor) checkStream() bool {
+	result, err := d.client.DescribeLogStreams(d.ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(d.group),
+		LogStreamNamePrefix: aws.String(d.stream),
+	})
+	if err != nil {
+		if !d.denied("DescribeLogStreams", err) {
+			d.fail("Check network access to the CloudWatch Logs endpoint of the region",
+				"Unable to describe log streams: %v", err)
+		}
+		return false
+	}
+	for _, stream := range result.LogStreams {
+		if aws.ToString(stream.LogStreamName) != d.stream {
+			continue
+		}
+		if stream.LastIngestionTime == nil {
+			d.pass("Log stream %s exists but has never received events", d.stream)
+		} else {
+			last := time.Unix(0, aws.ToInt64(stream.LastIngestionTime)*int64(time.Millisecond))
+			d.pass("Log stream %s last received events at %s", d.stream, last.Format(time.RFC3339))
+		}
+		return true
+	}
+	if d.create {
+		d.warn("Log stream %s does not exist and will be created", d.stream)
+		return true
+	}
+	d.fail("Create the log stream, for example with cwhook-provision, or run with -create",
+		"Log stream %s does not exist", d.stream)
+	return false
+}
+
+// send writes the test events through the hook and returns the time each was written, keyed by its message.
+func (d *doctor) send(marker string, count int) map[string]time.Time {
+	var mutex sync.Mutex
+	var receipts []cloudwatchhook.BatchReceipt
+	options := []cloudwatchhook.CloudWatchLogsHookOption{
+		cloudwatchhook.WithDeliveryCallback(func(receipt cloudwatchhook.BatchReceipt) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			receipts = append(receipts, receipt)
+		}),
+	}
+	if !d.create {
+		options = append(options, cloudwatchhook.WithNoCreate())
+	}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(d.cfg, d.group, d.stream, options...)
+	if err != nil {
+		d.fail("Check that the IAM policy of the credentials allows logs:DescribeLogGroups, logs:DescribeLogStreams "+
+			"and, with -create, logs:CreateLogGroup and logs:CreateLogStream", "Unable to create hook: %v", err)
+		return nil
+	}
+
+	sent := map[string]time.Time{}
+	for i := 1; i <= count; i++ {
+		message := fmt.Sprintf("cwhook-doctor %s %d/%d", marker, i, count)
+		sent[message] = time.Now()
+		if _, err := hook.Write([]byte(message)); err != nil {
+			hook.Close()
+			if !d.denied("PutLogEvents", err) {
+				d.fail("Fix the error writing to the stream", "Unable to send test event: %v", err)
+			}
+			return nil
+		}
+	}
+	if err := hook.Close(); err != nil {
+		if !d.denied("PutLogEvents", err) {
+			d.fail("Fix the error writing to the stream", "Unable to send test events: %v", err)
+		}
+		return nil
+	}
+
+	attempts, rejected := 0, 0
+	for _, receipt := range receipts {
+		attempts += receipt.Attempts
+		rejected += receipt.Rejected
+	}
+	d.pass("Sent %d test events in %d uploads", count, attempts)
+	if attempts > len(receipts) {
+		d.warn("%d uploads were retried; PutLogEvents may be throttled by the per-stream request quota",
+			attempts-len(receipts))
+	}
+	if rejected > 0 {
+		d.fail("Check that the system clock is correct; events more than 14 days old or 2 hours in the future are "+
+			"rejected", "%d test events were rejected because of their timestamp", rejected)
+	}
+	return sent
+}
+
+// tail reads the stream until every test event has been seen or the timeout passes, and reports the latencies.
+func (d *doctor) tail(sent map[string]time.Time, start time.Time, timeout time.Duration) {
+	var ingestion, visible time.Duration
+	seen := map[string]bool{}
+	deadline := time.Now().Add(timeout)
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(d.group),
+		LogStreamName: aws.String(d.stream),
+		StartTime:     aws.Int64(start.UnixNano() / int64(time.Millisecond)),
+		StartFromHead: aws.Bool(true),
+	}
+	for len(seen) < len(sent) && time.Now().Before(deadline) {
+		result, err := d.client.GetLogEvents(d.ctx, input)
+		if err != nil {
+			if !d.denied("GetLogEvents", err) {
+				d.fail("Check network access to the CloudWatch Logs endpoint of the region",
+					"Unable to read the test events back: %v", err)
+			}
+			return
+		}
+		now := time.Now()
+		for _, event := range result.Events {
+			message := aws.ToString(event.Message)
+			written, ok := sent[message]
+			if !ok || seen[message] {
+				continue
+			}
+			seen[message] = true
+			ingested := time.Unix(0, aws.ToInt64(event.IngestionTime)*int64(time.Millisecond))
+			if latency := ingested.Sub(written); latency > ingestion {
+				ingestion = latency
+			}
+			if latency := now.Sub(written); latency > visible {
+				visible = latency
+			}
+		}
+		if len(result.Events) > 0 {
+			input.NextToken = result.NextForwardToken
+		} else {
+			time.Sleep(time.Second)
+		}
+	}
+
+	if len(seen) < len(sent) {
+		d.fail("Increase -timeout, or check the subscription filters and metric filters of the group if events "+
+			"never appear", "Only %d of %d test events were readable after %s", len(seen), len(sent), timeout)
+		return
+	}
+	d.pass("All %d test events were read back", len(sent))
+	d.pass("Ingestion latency was at most %s and events were visible within %s", ingestion.Round(time.Millisecond),
+		visible.Round(time.Millisecond))
+}
+
+func main() {
+	group := flag.String("group", "", "log group to diagnose")
+	stream := flag.String("stream", "", "log stream to write the test events to")
+	events := flag.Int("events", 3, "number of test events to send")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long to wait for the test events to be readable")
+	create := flag.Bool("create", false, "let the hook create the log group and stream if they do not exist")
+	flag.Parse()
+	ctx := context.Background()
+
+	if *group == "" || *stream == "" {
+		fmt.Fprintf(os.Stderr, "ERROR: Both -group and -stream must be given\n")
+		os.Exit(1)
+	}
+	if *events < 1 {
+		fmt.Fprintf(os.Stderr, "ERROR: At least one test event must be sent\n")
+		os.Exit(1)
+	}
+
+	d := &doctor{ctx: ctx, group: *group, stream: *stream, create: *create}
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		d.fail("Fix the AWS configuration files or environment variables",
+			"Failed to load AWS default configuration: %v", err)
+	} else {
+		d.cfg = cfg
+		d.client = cloudwatchlogs.NewFromConfig(cfg)
+		if d.checkCredentials() && d.checkGroup() && d.checkStream() {
+			start := time.Now()
+			if sent := d.send(fmt.Sprintf("%x", start.UnixNano()), *events); sent != nil {
+				d.tail(sent, start, *timeout)
+			}
+		}
+	}
+
+	fmt.Println()
+	if len(d.problems) == 0 {
+		fmt.Println("Diagnosis: events sent by the hook are delivered and readable")
+		return
+	}
+	fmt.Println("Diagnosis:")
+	for _, problem := range d.problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,103 @@
+// Command cwhook-provision creates the log group and stream used by the hook ahead of time and applies the retention
+// period, KMS key and tags of the group, so that they can be provisioned during deployment and the application can
+// run with the WithNoCreate option. Each step checks that the credentials are allowed to make the change. With
+// -verify, a test event is written through a hook created with WithNoCreate, to check that the application will be
+// able to write to the stream.
+//
+// Usage:
+//
+//	cwhook-provision -group /app/payments -stream web-1 -retention 30 -tags team=payments,env=prod
+//	cwhook-provision -group /app/payments -stream web-1 -kms-key arn:aws:kms:... -verify
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// parseTags parses a comma separated list of key=value pairs.
+func parseTags(list string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, pair := range strings.Split(list, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("Invalid tag %q: expected key=value", pair)
+		}
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return tags, nil
+}
+
+func main() {
+	group := flag.String("group", "", "log group to provision")
+	stream := flag.String("stream", "", "log stream to provision")
+	retention := flag.Int("retention", 0, "number of days to retain log events (0 leaves the retention unchanged)")
+	kmsKey := flag.String("kms-key", "", "ARN of the KMS key used to encrypt the log group")
+	tagList := flag.String("tags", "", "comma separated key=value tags to apply to the log group")
+	verify := flag.Bool("verify", false, "write a test event to check that the stream can be written to")
+	flag.Parse()
+	ctx := context.Background()
+
+	if *group == "" || *stream == "" {
+		fmt.Fprintf(os.Stderr, "ERROR: Both -group and -stream must be given\n")
+		os.Exit(1)
+	}
+	tags, err := parseTags(*tagList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Failed to load AWS default configuration: %s\n", err)
+		os.Exit(2)
+	}
+	options := []cloudwatchhook.CloudWatchLogsHookOption{}
+	if *retention > 0 {
+		options = append(options, cloudwatchhook.WithGroupRetentionDays(int32(*retention)))
+	}
+	if *kmsKey != "" {
+		options = append(options, cloudwatchhook.WithGroupKmsKeyID(*kmsKey))
+	}
+	if len(tags) > 0 {
+		options = append(options, cloudwatchhook.WithGroupTags(tags))
+	}
+	report, err := cloudwatchhook.Provision(cfg, *group, *stream, options...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(2)
+	}
+	for _, action := range report.Actions {
+		fmt.Println(action)
+	}
+	if len(report.Actions) == 0 {
+		fmt.Println("Log group and stream are already provisioned")
+	}
+
+	if *verify {
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(cfg, *group, *stream, cloudwatchhook.WithNoCreate())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Unable to create hook: %s\n", err)
+			os.Exit(3)
+		}
+		if _, err := hook.Write([]byte("cwhook-provision: verifying write access")); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Unable to write test event: %s\n", err)
+			os.Exit(3)
+		}
+		if err := hook.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Unable to write test event: %s\n", err)
+			os.Exit(3)
+		}
+		fmt.Println("Verified write access to the log stream")
+	}
+}
@@ -0,0 +1,272 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ConfigSnapshot holds the resolved configuration of a hook. It is a copy, so changing it has no effect on the hook.
+type ConfigSnapshot struct {
+	Group             string            `json:"group"`
+	Stream            string            `json:"stream"`
+	NamingPolicies    int               `json:"naming_policies"`
+	RetentionDays     int32             `json:"retention_days"`
+	KmsKeyID          string            `json:"kms_key_id,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+	StreamTags        map[string]string `json:"stream_tags,omitempty"`
+	BatchDuration     time.Duration     `json:"batch_duration"`
+	BatchJitter       time.Duration     `json:"batch_jitter"`
+	AlignedFlush      bool              `json:"aligned_flush"`
+	TimestampBuckets  time.Duration     `json:"timestamp_buckets"`
+	AdaptiveBatching  bool              `json:"adaptive_batching"`
+	DebugLogger       bool              `json:"debug_logger"`
+	ErrorDedup        bool              `json:"error_deduplication"`
+	FallbackLogger    bool              `json:"fallback_logger"`
+	OpsStream         string            `json:"ops_stream,omitempty"`
+	SequenceTokens    bool              `json:"sequence_tokens"`
+	TokenRefresh      time.Duration     `json:"token_refresh"`
+	SharedStream      bool              `json:"shared_stream"`
+	Backoff           string            `json:"backoff,omitempty"`
+	CreationBackoff   string            `json:"creation_backoff,omitempty"`
+	WriteTimeout      time.Duration     `json:"write_timeout"`
+	DeadLetterSink    string            `json:"dead_letter_sink,omitempty"`
+	DeliveryCallback  bool              `json:"delivery_callback"`
+	VerificationRate  float64           `json:"verification_rate,omitempty"`
+	LagThreshold      time.Duration     `json:"lag_threshold"`
+	SQSRelay          bool              `json:"sqs_relay"`
+	Transport         string            `json:"transport,omitempty"`
+	DestinationARN    string            `json:"destination_arn,omitempty"`
+	GroupSelector     string            `json:"group_selector,omitempty"`
+	NoCreate          bool              `json:"no_create"`
+	Region            string            `json:"region,omitempty"`
+	Partition         string            `json:"partition,omitempty"`
+	BatchEncryption   bool              `json:"batch_encryption"`
+	FIPS              bool              `json:"fips"`
+	TierRules         int               `json:"tier_rules"`
+	SamplingTarget    int64             `json:"sampling_target,omitempty"`
+	PatternKey        bool              `json:"pattern_key"`
+	EventIDs          bool              `json:"event_ids"`
+	EventUIDs         bool              `json:"event_uids"`
+	SeverityMapping   bool              `json:"severity_mapping"`
+	PriorityQueue     bool              `json:"priority_queue"`
+	QueueCapacity     int               `json:"queue_capacity,omitempty"`
+	BurstBuffer       int               `json:"burst_buffer,omitempty"`
+	StartupEvent      bool              `json:"startup_event"`
+	HeartbeatInterval time.Duration     `json:"heartbeat_interval"`
+	Caller            bool              `json:"caller"`
+	CallerPolicy      string            `json:"caller_policy"`
+	ErrorStacks       bool              `json:"error_stacks"`
+	Kubernetes        bool              `json:"kubernetes"`
+	RoutingTag        string            `json:"routing_tag,omitempty"`
+	ValueMarshaler    bool              `json:"value_marshaler"`
+	FieldTypes        map[string]string `json:"field_types,omitempty"`
+	FieldBudget       int               `json:"field_budget,omitempty"`
+	BackpressureLevel string            `json:"backpressure_level,omitempty"`
+	SchemaVersion     string            `json:"schema_version,omitempty"`
+	TimestampFormat   string            `json:"timestamp_format,omitempty"`
+	TimestampLocation string            `json:"timestamp_location,omitempty"`
+	InstanceMetadata  bool              `json:"instance_metadata"`
+	MetadataTimeout   time.Duration     `json:"metadata_timeout"`
+	StripControlChars bool              `json:"strip_control_chars"`
+	StripANSI         bool              `json:"strip_ansi"`
+	LevelPrefix       bool              `json:"level_prefix"`
+	CompactJSON       bool              `json:"compact_json"`
+	EmptyMessages     string            `json:"empty_messages"`
+	APIOptions        int               `json:"api_options"`
+	AppID             string            `json:"app_id,omitempty"`
+	Codec             string            `json:"codec"`
+	MessageTemplate   string            `json:"message_template,omitempty"`
+	FilterPattern     string            `json:"filter_pattern,omitempty"`
+	RawMessages       bool              `json:"raw_messages"`
+	Enrichers         int               `json:"enrichers"`
+	Filters           int               `json:"filters"`
+	EventDecorator    bool              `json:"event_decorator"`
+}
+
+// MarshalJSON renders the snapshot as JSON with durations in their human readable form.
+func (c ConfigSnapshot) MarshalJSON() ([]byte, error) {
+	type snapshot ConfigSnapshot
+	return json.Marshal(struct {
+		snapshot
+		BatchDuration     string `json:"batch_duration"`
+		BatchJitter       string `json:"batch_jitter"`
+		HeartbeatInterval string `json:"heartbeat_interval"`
+		TokenRefresh      string `json:"token_refresh"`
+		MetadataTimeout   string `json:"metadata_timeout"`
+		LagThreshold      string `json:"lag_threshold"`
+		TimestampBuckets  string `json:"timestamp_buckets"`
+		WriteTimeout      string `json:"write_timeout"`
+	}{
+		snapshot:          snapshot(c),
+		BatchDuration:     c.BatchDuration.String(),
+		BatchJitter:       c.BatchJitter.String(),
+		HeartbeatInterval: c.HeartbeatInterval.String(),
+		TokenRefresh:      c.TokenRefresh.String(),
+		MetadataTimeout:   c.MetadataTimeout.String(),
+		LagThreshold:      c.LagThreshold.String(),
+		TimestampBuckets:  c.TimestampBuckets.String(),
+		WriteTimeout:      c.WriteTimeout.String(),
+	})
+}
+
+// Config returns a snapshot of the resolved configuration of the hook for inspection, debugging and inclusion in
+// diagnostics. The group and stream are those currently written to, which may have been changed by Update.
+func (h *CloudWatchLogsHook) Config() ConfigSnapshot {
+	h.intakeMutex.Lock()
+	target := h.currentTarget()
+	h.intakeMutex.Unlock()
+
+	// sequence tokens may have been turned back on by the hook sending the events, if the service still requires them
+	owner := h.queueOwner()
+	owner.mutex.Lock()
+	seqTokens := owner.seqTokens()
+	owner.mutex.Unlock()
+
+	config := ConfigSnapshot{
+		Group:             target.group,
+		Stream:            target.stream,
+		NamingPolicies:    len(h.namingPolicies),
+		RetentionDays:     h.retentionDays,
+		KmsKeyID:          h.kmsKeyID,
+		Tags:              make(map[string]string, len(h.tags)),
+		StreamTags:        make(map[string]string, len(h.streamTags)),
+		BatchDuration:     h.logFrequency,
+		BatchJitter:       h.batchJitter,
+		AlignedFlush:      h.alignedFlush,
+		TimestampBuckets:  h.timestampBuckets,
+		AdaptiveBatching:  h.adaptIntervals > 0,
+		DebugLogger:       h.debugLogger != nil,
+		ErrorDedup:        h.dedupErrors,
+		FallbackLogger:    h.fallbackLogger != nil,
+		OpsStream:         h.opsStream,
+		SequenceTokens:    seqTokens,
+		TokenRefresh:      h.tokenRefresh,
+		SharedStream:      h.sharedStream,
+		WriteTimeout:      h.writeTimeout,
+		SQSRelay:          h.relay != "",
+		DestinationARN:    h.destinationARN,
+		NoCreate:          h.noCreate,
+		Region:            h.resolvedRegion(),
+		Partition:         h.resolvedPartition(),
+		BatchEncryption:   h.dataKeys != nil,
+		FIPS:              fipsMode(),
+		TierRules:         len(h.tierRules),
+		DeliveryCallback:  h.deliveryCallback != nil,
+		VerificationRate:  h.verificationRate,
+		LagThreshold:      h.lagThreshold,
+		PatternKey:        h.patternKey,
+		EventIDs:          h.eventIDExtractor != nil,
+		EventUIDs:         h.eventUIDGenerator != nil,
+		SeverityMapping:   h.severityMapping != nil,
+		PriorityQueue:     h.priority,
+		QueueCapacity:     cap(h.queueOwner().ch),
+		BurstBuffer:       h.burstSize,
+		StartupEvent:      h.startupEvent,
+		HeartbeatInterval: h.heartbeatInterval,
+		Caller:            h.caller,
+		CallerPolicy:      h.callerPolicy.String(),
+		ErrorStacks:       h.errorStacks,
+		Kubernetes:        h.kubernetes,
+		RoutingTag:        h.routingTag,
+		ValueMarshaler:    h.valueMarshaler != nil,
+		FieldBudget:       h.fieldBudget,
+		SchemaVersion:     h.schemaVersion,
+		TimestampFormat:   h.timestampLayout,
+		InstanceMetadata:  !h.noInstanceMetadata,
+		MetadataTimeout:   h.metadataTimeout,
+		StripControlChars: h.stripControlChars,
+		StripANSI:         h.stripANSI,
+		LevelPrefix:       h.levelPrefix,
+		CompactJSON:       h.compactJSON,
+		EmptyMessages:     h.emptyPolicy.String(),
+		APIOptions:        len(h.apiOptions),
+		Codec:             fmt.Sprintf("%T", h.codec),
+		MessageTemplate:   h.messageTemplate,
+		FilterPattern:     h.filterPattern,
+		RawMessages:       h.rawMessages,
+		Enrichers:         len(h.enrichers) + len(h.optionEnrichers()),
+		Filters:           len(h.filters),
+		EventDecorator:    h.eventDecorator != nil,
+	}
+	for k, v := range h.tags {
+		config.Tags[k] = v
+	}
+	for k, v := range h.streamTags {
+		config.StreamTags[k] = v
+	}
+	if len(h.fieldTypes) > 0 {
+		config.FieldTypes = make(map[string]string, len(h.fieldTypes))
+		for name, t := range h.fieldTypes {
+			config.FieldTypes[name] = t.String()
+		}
+	}
+	if h.backpressure != nil {
+		config.BackpressureLevel = h.backpressure.level.String()
+	}
+	if h.timestampLocation != nil {
+		config.TimestampLocation = h.timestampLocation.String()
+	}
+	if h.appVersion != "" {
+		config.AppID = h.appName + "/" + h.appVersion
+	} else {
+		config.AppID = h.appName
+	}
+	if h.sampler != nil {
+		config.SamplingTarget = h.sampler.target
+	}
+	if h.groupSelector != nil {
+		config.GroupSelector = h.groupSelector.String()
+	}
+	if h.transport != nil {
+		config.Transport = fmt.Sprintf("%T", h.transport)
+	}
+	if h.backoff != nil {
+		config.Backoff = fmt.Sprintf("%T", h.backoff)
+	}
+	if h.creationBackoff != nil {
+		config.CreationBackoff = fmt.Sprintf("%T", h.creationBackoff)
+	}
+	if h.deadLetters != nil {
+		config.DeadLetterSink = fmt.Sprintf("%T", h.deadLetters)
+	}
+	return config
+}
+
+// hookSummary is the configuration and state of a hook rendered by MarshalJSON.
+type hookSummary struct {
+	Config ConfigSnapshot `json:"config"`
+	Stream StreamInfo     `json:"stream"`
+	Stats  Stats          `json:"stats"`
+	Closed bool           `json:"closed"`
+}
+
+// MarshalJSON renders the configuration, stream and statistics of the hook as JSON for diagnostics. Like Config, it
+// never includes the credentials of the hook or any other secrets, only whether features which use them are enabled.
+func (h *CloudWatchLogsHook) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hookSummary{
+		Config: h.Config(),
+		Stream: h.StreamInfo(),
+		Stats:  h.Stats(),
+		Closed: atomic.LoadInt32(&h.closed) != 0,
+	})
+}
+
+// String returns a one-line summary of the hook for logs and diagnostics, so that printing the hook does not dump its
+// internals, which include the credentials of its AWS configuration.
+func (h *CloudWatchLogsHook) String() string {
+	config := h.Config()
+	destination := "cloudwatch"
+	switch {
+	case config.SQSRelay:
+		destination = "sqs-relay"
+	case config.Transport != "":
+		destination = config.Transport
+	case config.DestinationARN != "":
+		destination = config.DestinationARN
+	}
+	return fmt.Sprintf("CloudWatchLogsHook{group=%s stream=%s region=%s destination=%s batch=%v delivered=%d "+
+		"closed=%t}", config.Group, config.Stream, config.Region, destination, config.BatchDuration,
+		h.Stats().Delivered, atomic.LoadInt32(&h.closed) != 0)
+}
@@ -0,0 +1,67 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// staticCredentials provides fixed credentials.
+type staticCredentials struct{}
+
+func (staticCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnSECRET"}, nil
+}
+
+func TestHookSummary(t *testing.T) {
+	config := aws.Config{Credentials: staticCredentials{}}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(config, "/app/web", "web-1",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})), cloudwatchhook.WithRegion("eu-west-1"),
+		cloudwatchhook.WithWriteTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hook.Close()
+
+	summary := fmt.Sprintf("%v", hook)
+	expected := "CloudWatchLogsHook{group=/app/web stream=web-1 region=eu-west-1 destination=cloudwatch batch=0s " +
+		"delivered=1 closed=true}"
+	if summary != expected {
+		t.Errorf("expected %s, got %s", expected, summary)
+	}
+
+	b, err := json.Marshal(hook)
+	if err != nil {
+		t.Fatalf("unable to marshal hook: %v", err)
+	}
+	var decoded struct {
+		Config struct {
+			Group        string `json:"group"`
+			WriteTimeout string `json:"write_timeout"`
+		} `json:"config"`
+		Stats struct {
+			Delivered int64 `json:"delivered"`
+		} `json:"stats"`
+		Closed bool `json:"closed"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unable to unmarshal hook: %v", err)
+	}
+	if decoded.Config.Group != "/app/web" || decoded.Config.WriteTimeout != "2s" || decoded.Stats.Delivered != 1 ||
+		!decoded.Closed {
+		t.Errorf("unexpected summary: %s", b)
+	}
+	if strings.Contains(string(b), "AKIDEXAMPLE") || strings.Contains(string(b), "SECRET") {
+		t.Errorf("expected the credentials to be left out, got %s", b)
+	}
+}
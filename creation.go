@@ -0,0 +1,68 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// defaultCreationBackoff is used to wait for newly created log groups and streams unless the WithCreationBackoff
+// option is specified.
+var defaultCreationBackoff Backoff = ExponentialBackoff{
+	Base:       100 * time.Millisecond,
+	Max:        2 * time.Second,
+	MaxRetries: 5,
+}
+
+// retryCreation calls fn until it reports that it is done, waiting between attempts according to the creation backoff
+// policy. A ResourceNotFoundException is retried too, since a log group which was just created may not be found by the
+// calls which follow. Any other error is returned straight away. Once the policy gives up, the result of the last
+// attempt is returned.
+func (h *CloudWatchLogsHook) retryCreation(fn func() (bool, error)) (bool, error) {
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		done, err := fn()
+		var notFoundErr *types.ResourceNotFoundException
+		if done || (err != nil && !errors.As(err, &notFoundErr)) {
+			return done, err
+		}
+		retry := h.creationBackoff != nil
+		if retry {
+			delay, retry = h.creationBackoff.Next(attempt, delay)
+		}
+		if !retry {
+			return done, err
+		}
+		ctx := h.lookupContext()
+		if !h.sleep(delay, ctx.Done()) {
+			return done, ctx.Err()
+		}
+	}
+}
+
+// retryThrottled calls fn until it succeeds, retrying throttled calls according to the creation backoff policy, which
+// also paces bulk operations such as PurgeOldStreams. Any other error is returned straight away, as is the last error
+// once the policy gives up.
+func (h *CloudWatchLogsHook) retryThrottled(ctx context.Context, fn func() error) error {
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		var apiErr smithy.APIError
+		if err == nil || !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ThrottlingException" {
+			return err
+		}
+		retry := h.creationBackoff != nil
+		if retry {
+			delay, retry = h.creationBackoff.Next(attempt, delay)
+		}
+		if !retry {
+			return err
+		}
+		if !h.sleep(delay, ctx.Done()) {
+			return ctx.Err()
+		}
+	}
+}
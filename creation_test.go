@@ -0,0 +1,83 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// laggingClient leaves the first log groups and streams out of the listings of the client it wraps, as the service
+// does while newly created resources propagate.
+type laggingClient struct {
+	*chaos.Client
+	mutex         sync.Mutex
+	hiddenGroups  int
+	hiddenStreams int
+}
+
+func (c *laggingClient) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.hiddenGroups > 0 {
+		c.hiddenGroups--
+		return &cloudwatchlogs.DescribeLogGroupsOutput{}, nil
+	}
+	return c.Client.DescribeLogGroups(ctx, params, optFns...)
+}
+
+func (c *laggingClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.hiddenStreams > 0 {
+		c.hiddenStreams--
+		return &cloudwatchlogs.DescribeLogStreamsOutput{}, nil
+	}
+	return c.Client.DescribeLogStreams(ctx, params, optFns...)
+}
+
+func TestCreationRace(t *testing.T) {
+	// another replica has just created the group and stream, which are not listed yet
+	client := &laggingClient{Client: chaos.NewClient(chaos.Faults{}), hiddenGroups: 2, hiddenStreams: 2}
+	ctx := context.Background()
+	client.Client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("/app/web")})
+	client.Client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String("/app/web"),
+		LogStreamName: aws.String("replica-1"),
+	})
+
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "replica-1",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithGroupRetentionDays(7),
+		cloudwatchhook.WithCreationBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Millisecond, MaxRetries: 3}))
+	if err != nil {
+		t.Fatalf("expected the hook to be created, got %v", err)
+	}
+	defer hook.Close()
+	if arn := hook.GroupARN(); arn == "" {
+		t.Errorf("expected the ARN of the group to be found once it was listed")
+	}
+	if calls := client.Calls("PutRetentionPolicy"); calls != 0 {
+		t.Errorf("expected the retention policy to be left to the replica which created the group, got %d calls", calls)
+	}
+
+	// without a policy, the hook carries on after a single attempt to find the stream it created
+	client.hiddenStreams = 10
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "replica-2",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithCreationBackoff(nil))
+	if err != nil {
+		t.Fatalf("expected the hook to be created, got %v", err)
+	}
+	defer hook.Close()
+	if client.hiddenStreams != 8 {
+		t.Errorf("expected the stream to be looked up twice, got %d lookups", 10-client.hiddenStreams)
+	}
+}
@@ -0,0 +1,63 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// CutOver points the hook at a different stream in its current log group, such as when moving to a new naming scheme
+// or isolating the logs of an incident. The new stream passes through the naming policies of the hook and is created,
+// or checked to exist with WithNoCreate, before the hook switches to it, and the switch itself is atomic: every event
+// is written to either the old stream or the new one.
+//
+// With drain, the events queued or collected into batches when CutOver is called are sent to the old stream first,
+// and the hook only switches once their delivery, and that of any batches already being sent or retried, has
+// completed, successfully or not, so nothing written before the cutover ends up in the new stream. Events written
+// while draining, and without drain any events still pending, are delivered according to the retarget policy. If the
+// context is done before the events are drained, the hook stays on the old stream and the error of the context is
+// returned. Draining has no effect if the hook does not batch events.
+func (h *CloudWatchLogsHook) CutOver(ctx context.Context, newStream string, drain bool) error {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return ErrClosed
+	}
+	h.intakeMutex.Lock()
+	old := h.currentTarget()
+	h.intakeMutex.Unlock()
+	target, err := h.prepareTarget(streamTarget{group: old.group, stream: newStream})
+	if err != nil {
+		return err
+	}
+
+	if drain {
+		if err := h.drain(ctx); err != nil {
+			return err
+		}
+	}
+
+	h.retarget(target.group, target.stream)
+	h.opsf("info", "lifecycle", "cut over from stream %s to %s", old.stream, target.stream)
+	return nil
+}
+
+// drain sends the events queued or collected into batches when it is called and waits for their delivery, and that of
+// any batches already being sent or retried, to complete, leaving the hook open. Events written while draining are
+// left for later batches. It returns immediately if the hook does not batch events.
+func (h *CloudWatchLogsHook) drain(ctx context.Context) error {
+	if h.drainCh == nil {
+		return nil
+	}
+	drained := make(chan struct{})
+	select {
+	case h.drainCh <- drained:
+	case <-h.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
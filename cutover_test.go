@@ -0,0 +1,124 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestCutOver(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "blue",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithBatchDuration(time.Minute))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	ctx := context.Background()
+	messages := func(stream string) []string {
+		var messages []string
+		for _, event := range client.Events("group", stream) {
+			messages = append(messages, aws.ToString(event.Message))
+		}
+		return messages
+	}
+
+	// the pending events are delivered to the old stream before the hook switches
+	if _, err := hook.Write([]byte("drained")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.CutOver(ctx, "green", true); err != nil {
+		t.Fatalf("unable to cut over: %v", err)
+	}
+	if got := messages("blue"); len(got) != 1 || got[0] != "drained" {
+		t.Errorf("expected the pending event to be drained to the old stream, got %v", got)
+	}
+
+	// without draining, the pending events are delivered according to the retarget policy
+	if _, err := hook.Write([]byte("pending")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.CutOver(ctx, "red", false); err != nil {
+		t.Fatalf("unable to cut over: %v", err)
+	}
+	if _, err := hook.Write([]byte("switched")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	if got := messages("green"); len(got) != 1 || got[0] != "pending" {
+		t.Errorf("expected the pending event in the stream it was written for, got %v", got)
+	}
+	if got := messages("red"); len(got) != 1 || got[0] != "switched" {
+		t.Errorf("expected the new event in the new stream, got %v", got)
+	}
+
+	if err := hook.CutOver(ctx, "blue", true); err != cloudwatchhook.ErrClosed {
+		t.Errorf("expected ErrClosed once the hook is closed, got %v", err)
+	}
+}
+
+func TestCutOverWaitsForBatchesInFlight(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{LatencyRate: 1, Latency: 200 * time.Millisecond})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "blue",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithBatchDuration(time.Minute))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	// the batch is already being sent, slowly, when the cutover is requested
+	if _, err := hook.Write([]byte("in flight")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 100 && client.Calls("PutLogEvents") == 0; i++ {
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if client.Calls("PutLogEvents") == 0 {
+		t.Fatal("expected the batch to be sent")
+	}
+	if err := hook.CutOver(context.Background(), "green", true); err != nil {
+		t.Fatalf("unable to cut over: %v", err)
+	}
+	if events := client.Events("group", "blue"); len(events) != 1 {
+		t.Errorf("expected the batch in flight to be delivered before the cutover, got %v", events)
+	}
+}
+
+func TestCutOverRetargetToCurrent(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "blue",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithBatchDuration(time.Minute),
+		cloudwatchhook.WithRetargetPolicy(cloudwatchhook.RetargetToCurrent))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+
+	// the pending event follows the hook to the new stream rather than going to the stream it was written for
+	if _, err := hook.Write([]byte("pending")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.CutOver(context.Background(), "green", false); err != nil {
+		t.Fatalf("unable to cut over: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	if events := client.Events("group", "blue"); len(events) != 0 {
+		t.Errorf("expected nothing in the old stream, got %v", events)
+	}
+	if events := client.Events("group", "green"); len(events) != 1 || aws.ToString(events[0].Message) != "pending" {
+		t.Errorf("expected the pending event in the new stream, got %v", events)
+	}
+}
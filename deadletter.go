@@ -0,0 +1,226 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// maxSQSMessageSize is the maximum size of a single Amazon SQS message body.
+const maxSQSMessageSize = 262144
+
+// DeadLetter holds log events that could not be delivered to Amazon CloudWatch after exhausting all retries.
+type DeadLetter struct {
+	Group  string
+	Stream string
+	Events []types.InputLogEvent
+	Err    error
+	Time   time.Time
+
+	// RequestID and ExtendedRequestID identify the PutLogEvents call which failed or, for events rejected individually,
+	// the call which delivered the rest of their batch, so that it can be referenced in support cases with AWS. They
+	// are empty if the events were never sent to Amazon CloudWatch or the service did not return them.
+	RequestID         string
+	ExtendedRequestID string
+}
+
+// DeadLetterSink is used to store log events that could not be delivered to Amazon CloudWatch so that they are never
+// silently lost.
+type DeadLetterSink interface {
+	// Send stores the undeliverable events. The events are only valid until Send returns, since the hook reuses the
+	// memory holding them; a sink which stores them asynchronously must copy them first. The context expires with the
+	// write timeout of a direct write and is cancelled once the deadline given to CloseContext passes, including while
+	// the events abandoned because of it are sent, so a sink which honors it never holds up closing the hook.
+	Send(ctx context.Context, letter DeadLetter) error
+}
+
+// deadLetterRecord is the JSON representation of a single undeliverable event.
+type deadLetterRecord struct {
+	Group     string `json:"group"`
+	Stream    string `json:"stream"`
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
+	FailedAt  string `json:"failed_at"`
+	RequestID string `json:"request_id,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// records converts the dead letter into one JSON line per event.
+func (l DeadLetter) records() ([][]byte, error) {
+	errMsg := ""
+	if l.Err != nil {
+		errMsg = l.Err.Error()
+	}
+	lines := make([][]byte, 0, len(l.Events))
+	for _, event := range l.Events {
+		line, err := json.Marshal(deadLetterRecord{
+			Group:     l.Group,
+			Stream:    l.Stream,
+			Timestamp: aws.ToInt64(event.Timestamp),
+			Message:   aws.ToString(event.Message),
+			Error:     errMsg,
+			FailedAt:  l.Time.UTC().Format(time.RFC3339Nano),
+			RequestID: l.RequestID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, append(line, '\n'))
+	}
+	return lines, nil
+}
+
+// truncateRecord shortens the message of the JSON line of a dead letter record to the longest prefix for which the line
+// fits within the given size, marking the record as truncated. The line is returned as is if it already fits.
+func truncateRecord(line []byte, size int) ([]byte, error) {
+	if len(line) <= size {
+		return line, nil
+	}
+	var record deadLetterRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, err
+	}
+	record.Truncated = true
+	message := record.Message
+	record.Message = ""
+	empty, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// escaping can make the encoded message several times longer than the message itself, so the prefix is found by
+	// measuring its encoded length rather than from the excess
+	limit := size - len(empty) - 1
+	n := sort.Search(len(message)+1, func(i int) bool {
+		encoded, _ := json.Marshal(truncateText(message, i))
+		return len(encoded)-2 > limit
+	}) - 1
+	record.Message = truncateText(message, n)
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+// FileDeadLetterSink appends undeliverable events to a local file as JSON lines.
+type FileDeadLetterSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewFileDeadLetterSink opens (or creates) the file at the given path for appending undeliverable events.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open dead letter file: %v", err)
+	}
+	return &FileDeadLetterSink{file: file}, nil
+}
+
+// Send appends the undeliverable events to the file.
+func (s *FileDeadLetterSink) Send(ctx context.Context, letter DeadLetter) error {
+	lines, err := letter.records()
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(bytes.Join(lines, nil))
+	return err
+}
+
+// Close closes the underlying file.
+func (s *FileDeadLetterSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+// S3PutObjectFunc uploads an object to Amazon S3. It is typically a thin wrapper around the PutObject method of an
+// Amazon S3 client so the hook does not need to depend on the S3 SDK.
+type S3PutObjectFunc func(ctx context.Context, bucket, key string, body io.Reader) error
+
+// S3DeadLetterSink stores each set of undeliverable events as a JSON lines object in an Amazon S3 bucket.
+type S3DeadLetterSink struct {
+	put    S3PutObjectFunc
+	bucket string
+	prefix string
+}
+
+// NewS3DeadLetterSink creates a sink which uploads undeliverable events to the given bucket under the given prefix.
+func NewS3DeadLetterSink(put S3PutObjectFunc, bucket, prefix string) *S3DeadLetterSink {
+	return &S3DeadLetterSink{
+		put:    put,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// Send uploads the undeliverable events as a single object keyed by group, stream and time of failure.
+func (s *S3DeadLetterSink) Send(ctx context.Context, letter DeadLetter) error {
+	lines, err := letter.records()
+	if err != nil {
+		return err
+	}
+	t := letter.Time.UTC()
+	key := path.Join(s.prefix, letter.Group, letter.Stream, t.Format("2006/01/02/15"),
+		fmt.Sprintf("%d.jsonl", t.UnixNano()))
+	return s.put(ctx, s.bucket, key, bytes.NewReader(bytes.Join(lines, nil)))
+}
+
+// SQSSendMessageFunc sends a message to an Amazon SQS queue. It is typically a thin wrapper around the SendMessage
+// method of an Amazon SQS client so the hook does not need to depend on the SQS SDK.
+type SQSSendMessageFunc func(ctx context.Context, queueURL, body string) error
+
+// SQSDeadLetterSink sends undeliverable events to an Amazon SQS queue as JSON lines, splitting them across as many
+// messages as needed to stay within the SQS message size limit. The message of an event too large to fit in an SQS
+// message on its own is truncated, and its record marked as such, so that the rest of the events are still sent.
+type SQSDeadLetterSink struct {
+	send     SQSSendMessageFunc
+	queueURL string
+}
+
+// NewSQSDeadLetterSink creates a sink which sends undeliverable events to the given queue.
+func NewSQSDeadLetterSink(send SQSSendMessageFunc, queueURL string) *SQSDeadLetterSink {
+	return &SQSDeadLetterSink{
+		send:     send,
+		queueURL: queueURL,
+	}
+}
+
+// Send sends the undeliverable events to the queue.
+func (s *SQSDeadLetterSink) Send(ctx context.Context, letter DeadLetter) error {
+	lines, err := letter.records()
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	for _, line := range lines {
+		if line, err = truncateRecord(line, maxSQSMessageSize); err != nil {
+			return err
+		}
+		if body.Len() > 0 && body.Len()+len(line) > maxSQSMessageSize {
+			if err := s.send(ctx, s.queueURL, body.String()); err != nil {
+				return err
+			}
+			body.Reset()
+		}
+		body.Write(line)
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+	return s.send(ctx, s.queueURL, body.String())
+}
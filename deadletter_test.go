@@ -0,0 +1,246 @@
+package cloudwatchhook_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// deadLetterRecord is the JSON line written by the dead letter sinks for each event.
+type deadLetterRecord struct {
+	Group     string `json:"group"`
+	Stream    string `json:"stream"`
+	Message   string `json:"message"`
+	Error     string `json:"error"`
+	Truncated bool   `json:"truncated"`
+}
+
+// undeliverable writes the messages to a hook whose every PutLogEvents call fails and closes it, so that the messages
+// are handed to the sink. Closing the hook still reports the failure to deliver them.
+func undeliverable(t *testing.T, sink cloudwatchhook.DeadLetterSink, messages ...string) {
+	t.Helper()
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{UnavailableRate: 1})),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Millisecond, MaxRetries: 1}),
+		cloudwatchhook.WithDeadLetterSink(sink),
+	)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	for _, msg := range messages {
+		if _, err := hook.Write([]byte(msg)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := hook.Close(); err == nil || strings.Contains(err.Error(), "dead letter sink failed") {
+		t.Fatalf("expected the undeliverable events to be dead lettered, got %v", err)
+	}
+}
+
+// decodeRecords decodes the JSON lines written by a dead letter sink.
+func decodeRecords(t *testing.T, r io.Reader) []deadLetterRecord {
+	t.Helper()
+	var records []deadLetterRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record deadLetterRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid dead letter record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deadletter")
+	if err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sink, err := cloudwatchhook.NewFileDeadLetterSink(filepath.Join(dir, "dead.jsonl"))
+	if err != nil {
+		t.Fatalf("unable to create sink: %v", err)
+	}
+	undeliverable(t, sink, "first", "second")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unable to close sink: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "dead.jsonl"))
+	if err != nil {
+		t.Fatalf("unable to open dead letter file: %v", err)
+	}
+	defer file.Close()
+	records := decodeRecords(t, file)
+	if len(records) != 2 || records[0].Message != "first" || records[1].Message != "second" {
+		t.Fatalf("expected both events to be dead lettered, got %+v", records)
+	}
+	for _, record := range records {
+		if record.Group != "group" || record.Stream != "stream" || !strings.Contains(record.Error, "unavailable") {
+			t.Errorf("unexpected dead letter record: %+v", record)
+		}
+	}
+}
+
+func TestS3DeadLetterSink(t *testing.T) {
+	var keys []string
+	var records []deadLetterRecord
+	sink := cloudwatchhook.NewS3DeadLetterSink(func(ctx context.Context, bucket, key string, body io.Reader) error {
+		if bucket != "bucket" {
+			t.Errorf("unexpected bucket %s", bucket)
+		}
+		keys = append(keys, key)
+		records = append(records, decodeRecords(t, body)...)
+		return nil
+	}, "bucket", "dead")
+	undeliverable(t, sink, "first", "second")
+
+	if len(keys) != 1 || !strings.HasPrefix(keys[0], "dead/group/stream/") || !strings.HasSuffix(keys[0], ".jsonl") {
+		t.Errorf("expected a single object keyed by group and stream, got %v", keys)
+	}
+	if len(records) != 2 || records[0].Message != "first" || records[1].Message != "second" {
+		t.Errorf("expected both events to be dead lettered, got %+v", records)
+	}
+}
+
+func TestSQSDeadLetterSink(t *testing.T) {
+	var bodies []string
+	sink := cloudwatchhook.NewSQSDeadLetterSink(func(ctx context.Context, queueURL, body string) error {
+		if queueURL != "https://sqs.us-east-1.amazonaws.com/123456789012/dead" {
+			t.Errorf("unexpected queue %s", queueURL)
+		}
+		bodies = append(bodies, body)
+		return nil
+	}, "https://sqs.us-east-1.amazonaws.com/123456789012/dead")
+
+	// the two large events cannot share a message within the SQS size limit
+	large := strings.Repeat("x", 200000)
+	undeliverable(t, sink, large, large, "small")
+
+	if len(bodies) != 2 {
+		t.Fatalf("expected the events to be split across 2 messages, got %d", len(bodies))
+	}
+	var records []deadLetterRecord
+	for _, body := range bodies {
+		if len(body) > 262144 {
+			t.Errorf("expected messages within the SQS size limit, got %d bytes", len(body))
+		}
+		records = append(records, decodeRecords(t, strings.NewReader(body))...)
+	}
+	if len(records) != 3 || records[2].Message != "small" {
+		t.Errorf("expected all 3 events to be dead lettered, got %d", len(records))
+	}
+}
+
+func TestSQSDeadLetterSinkOversizedEvent(t *testing.T) {
+	var bodies []string
+	sink := cloudwatchhook.NewSQSDeadLetterSink(func(ctx context.Context, queueURL, body string) error {
+		if len(body) > 262144 {
+			return errors.New("message too long")
+		}
+		bodies = append(bodies, body)
+		return nil
+	}, "queue")
+
+	// the record of an event close to the CloudWatch size limit does not fit in an SQS message on its own
+	oversized := strings.Repeat("x", 262000)
+	undeliverable(t, sink, oversized, "small")
+
+	var records []deadLetterRecord
+	for _, body := range bodies {
+		records = append(records, decodeRecords(t, strings.NewReader(body))...)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected both events to be dead lettered, got %d", len(records))
+	}
+	if !records[0].Truncated || len(records[0].Message) >= len(oversized) ||
+		!strings.HasPrefix(oversized, records[0].Message) {
+		t.Errorf("expected the message of the oversized event to be truncated, got %d bytes", len(records[0].Message))
+	}
+	if records[1].Truncated || records[1].Message != "small" {
+		t.Errorf("expected the small event to be left alone, got %+v", records[1])
+	}
+}
+
+func TestSQSDeadLetterSinkEscapedEvent(t *testing.T) {
+	var bodies []string
+	sink := cloudwatchhook.NewSQSDeadLetterSink(func(ctx context.Context, queueURL, body string) error {
+		if len(body) > 262144 {
+			return errors.New("message too long")
+		}
+		bodies = append(bodies, body)
+		return nil
+	}, "queue")
+
+	// each character is escaped to six in the record, so the record is several times the size of the message
+	html := strings.Repeat("<", 60000)
+	undeliverable(t, sink, html)
+
+	if len(bodies) != 1 {
+		t.Fatalf("expected a single message, got %d", len(bodies))
+	}
+	records := decodeRecords(t, strings.NewReader(bodies[0]))
+	if len(records) != 1 || !records[0].Truncated || records[0].Message == "" ||
+		!strings.HasPrefix(html, records[0].Message) {
+		t.Fatalf("expected the message of the escaped event to be truncated, got %d records", len(records))
+	}
+	if len(bodies[0]) < 262144-6 {
+		t.Errorf("expected the message to be cut no shorter than needed, got a %d byte body", len(bodies[0]))
+	}
+}
+
+// contextSink records the context given to each call of Send.
+type contextSink struct {
+	mutex    sync.Mutex
+	contexts []context.Context
+}
+
+func (s *contextSink) Send(ctx context.Context, letter cloudwatchhook.DeadLetter) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.contexts = append(s.contexts, ctx)
+	return nil
+}
+
+func TestDeadLetterSinkCancelledOnClose(t *testing.T) {
+	sink := &contextSink{}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{UnavailableRate: 1})),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: 10 * time.Millisecond, MaxRetries: 1000}),
+		cloudwatchhook.WithDeadLetterSink(sink))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("undeliverable")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the events abandoned at the deadline are handed to the sink along with a cancelled context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := hook.CloseContext(ctx); err != cloudwatchhook.ErrAbandoned {
+		t.Fatalf("expected the event to be abandoned, got %v", err)
+	}
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if len(sink.contexts) != 1 || sink.contexts[0].Err() == nil {
+		t.Errorf("expected the sink to be given the cancelled context of the hook, got %d calls", len(sink.contexts))
+	}
+}
@@ -0,0 +1,14 @@
+package cloudwatchhook
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// decorate calls the event decorator, restoring the message and timestamp afterwards since batching and ordering the
+// events depends on them.
+func (h *CloudWatchLogsHook) decorate(entry *logrus.Entry, event *types.InputLogEvent) {
+	message, timestamp := event.Message, event.Timestamp
+	h.eventDecorator(entry, event)
+	event.Message, event.Timestamp = message, timestamp
+}
@@ -0,0 +1,45 @@
+package cloudwatchhook_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEventDecorator(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	var decorated []string
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithEventDecorator(func(entry *logrus.Entry, event *types.InputLogEvent) {
+			decorated = append(decorated, entry.Message)
+			event.Message = aws.String("replaced")
+		}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	log.AddHook(hook)
+	log.Info("decorated")
+	if _, err := hook.Write([]byte("written")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+
+	if len(decorated) != 1 || decorated[0] != "decorated" {
+		t.Errorf("expected only the entry to be decorated, got %q", decorated)
+	}
+	events := client.Events("group", "stream")
+	if len(events) != 2 || !strings.Contains(aws.ToString(events[0].Message), "decorated") {
+		t.Errorf("expected the message of the decorated event to be kept, got %v", events)
+	}
+}
@@ -0,0 +1,81 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestDefaultTagsLimits(t *testing.T) {
+	// the tags of the standard count towards the limits along with those given explicitly
+	groupTags := func(n int) map[string]string {
+		tags := map[string]string{}
+		for i := 0; i < n; i++ {
+			tags["tag-"+strconv.Itoa(i)] = "value"
+		}
+		return tags
+	}
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		key      string
+		value    string
+		expected string
+	}{
+		{"at the tag count limit", groupTags(49), "owner", "payments", ""},
+		{"over the tag count limit", groupTags(50), "owner", "payments", "at most 50 tags"},
+		{"at the key length limit", nil, strings.Repeat("k", 128), "payments", ""},
+		{"over the key length limit", nil, strings.Repeat("k", 129), "payments", "between 1 and 128"},
+		{"at the value length limit", nil, "owner", strings.Repeat("v", 256), ""},
+		{"over the value length limit", nil, "owner", strings.Repeat("v", 257), "at most 256"},
+	}
+	for _, test := range tests {
+		value := test.value
+		standard := cloudwatchhook.TagStandard{
+			Tags: map[string]string{test.key: ""},
+			Provider: func(key string) (string, error) {
+				return value, nil
+			},
+		}
+		client := chaos.NewClient(chaos.Faults{})
+		options := []cloudwatchhook.CloudWatchLogsHookOption{
+			cloudwatchhook.WithClient(client),
+			cloudwatchhook.WithDefaultTags(standard),
+		}
+		if test.tags != nil {
+			options = append(options, cloudwatchhook.WithGroupTags(test.tags))
+		}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream", options...)
+		if test.expected != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("%s: expected an error containing %q, got %v", test.name, test.expected, err)
+			}
+			if n := client.Calls("CreateLogGroup"); n != 0 {
+				t.Errorf("%s: expected the group not to be created, got %d calls", test.name, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		hook.Close()
+
+		// the group is created with every tag at the limit
+		output, err := client.ListTagsLogGroup(context.Background(),
+			&cloudwatchlogs.ListTagsLogGroupInput{LogGroupName: aws.String("group")})
+		if err != nil {
+			t.Fatalf("%s: unable to list tags: %v", test.name, err)
+		}
+		if len(output.Tags) != len(test.tags)+1 || output.Tags[test.key] != test.value {
+			t.Errorf("%s: expected the group to be created with %d tags, got %d", test.name, len(test.tags)+1,
+				len(output.Tags))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+)
+
+// DeferredHook is a hook which does not interact with AWS, not even to create its client, until it is first used.
+// It is created by NewDeferred.
+type DeferredHook struct {
+	hook *CloudWatchLogsHook
+	once sync.Once
+	err  error
+}
+
+// NewDeferred creates a hook for sending log messages to Amazon CloudWatch Logs which defers every interaction with
+// AWS, including creating the client and the log group and stream, until the first entry is fired or written. The
+// configuration is validated immediately, but credentials and network access are only needed once the hook is used,
+// so unit tests and command line help never require them. Any error starting the hook is returned by the first and
+// every subsequent use.
+func NewDeferred(config aws.Config, group, stream string, options ...CloudWatchLogsHookOption) (*DeferredHook, error) {
+	hook, err := newHook(config, group, stream, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &DeferredHook{hook: hook}, nil
+}
+
+// Hook starts the hook, if it has not been started already, and returns it.
+func (d *DeferredHook) Hook() (*CloudWatchLogsHook, error) {
+	d.once.Do(func() {
+		if err := d.hook.start(); err != nil {
+			// stop any workers started before the failure
+			d.hook.Close()
+			d.err = fmt.Errorf("Unable to start hook: %v", err)
+		}
+	})
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.hook, nil
+}
+
+// Levels returns the logging levels supported by the hook without starting it.
+func (d *DeferredHook) Levels() []logrus.Level {
+	return d.hook.Levels()
+}
+
+// Fire starts the hook, if necessary, and sends the entry to Amazon CloudWatch.
+func (d *DeferredHook) Fire(entry *logrus.Entry) error {
+	hook, err := d.Hook()
+	if err != nil {
+		return err
+	}
+	return hook.Fire(entry)
+}
+
+// Write starts the hook, if necessary, and sends the message to Amazon CloudWatch.
+func (d *DeferredHook) Write(msg []byte) (int, error) {
+	hook, err := d.Hook()
+	if err != nil {
+		return 0, err
+	}
+	return hook.Write(msg)
+}
+
+// Config returns a snapshot of the resolved configuration of the hook without starting it.
+func (d *DeferredHook) Config() ConfigSnapshot {
+	return d.hook.Config()
+}
+
+// Close flushes and closes the hook if it was started. A hook which was never used is closed without interacting with
+// AWS and cannot be started afterwards.
+func (d *DeferredHook) Close() error {
+	_, err := d.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext flushes and closes the hook if it was started, giving up once the context is done, like the
+// CloseContext method of CloudWatchLogsHook.
+func (d *DeferredHook) CloseContext(ctx context.Context) (CloseResult, error) {
+	started := true
+	d.once.Do(func() {
+		started = false
+		d.err = ErrClosed
+	})
+	if !started || d.err != nil {
+		return CloseResult{}, nil
+	}
+	return d.hook.CloseContext(ctx)
+}
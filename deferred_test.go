@@ -0,0 +1,81 @@
+package cloudwatchhook_test
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewDeferred(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewDeferred(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	if n := client.Calls("DescribeLogGroups") + client.Calls("CreateLogGroup"); n != 0 {
+		t.Fatalf("expected no calls before the hook is used, got %d", n)
+	}
+
+	logger.Info("first use")
+	if n := client.Calls("CreateLogGroup"); n != 1 {
+		t.Errorf("expected the log group to be created on first use, got %d calls", n)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(client.Events("group", "stream")); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+}
+
+func TestNewDeferredUnused(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewDeferred(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := client.Calls("DescribeLogGroups"); n != 0 {
+		t.Errorf("expected no calls for an unused hook, got %d", n)
+	}
+	if _, err := hook.Write([]byte("late")); err != cloudwatchhook.ErrClosed {
+		t.Errorf("expected ErrClosed writing to a closed hook, got %v", err)
+	}
+
+	// the configuration is still validated up front
+	if _, err := cloudwatchhook.NewDeferred(aws.Config{}, "", "stream"); err == nil {
+		t.Errorf("expected an invalid group name to be rejected")
+	}
+}
+
+func TestNewDeferredConfig(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewDeferred(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithCaller(), cloudwatchhook.WithEnricher(cloudwatchhook.PatternKeyEnricher()))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	// the enrichers are counted the same way before and after the hook is first used
+	if n := hook.Config().Enrichers; n != 2 {
+		t.Errorf("expected 2 enrichers before first use, got %d", n)
+	}
+	if _, err := hook.Write([]byte("first use")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := hook.Config().Enrichers; n != 2 {
+		t.Errorf("expected 2 enrichers after first use, got %d", n)
+	}
+}
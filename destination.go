@@ -0,0 +1,82 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+)
+
+// DestinationFilterName is the name of the subscription filter used to forward the log group to the destination given
+// by the WithDestinationARN option.
+const DestinationFilterName = "logrus-cloudwatch-hook-destination"
+
+// SubscriptionFilterAPI is the part of the Amazon CloudWatch Logs API used to forward a log group to a destination.
+// It is only required of the client when the WithDestinationARN option is used.
+type SubscriptionFilterAPI interface {
+	PutSubscriptionFilter(ctx context.Context, params *cloudwatchlogs.PutSubscriptionFilterInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutSubscriptionFilterOutput, error)
+}
+
+// validateDestinationARN is used to make sure the ARN refers to an Amazon CloudWatch Logs destination.
+func validateDestinationARN(destinationARN string) error {
+	parsed, err := arn.Parse(destinationARN)
+	if err != nil {
+		return fmt.Errorf("Invalid destination ARN %q: %v", destinationARN, err)
+	}
+	if parsed.Service != "logs" || !strings.HasPrefix(parsed.Resource, "destination:") {
+		return fmt.Errorf("Invalid destination ARN %q: not an Amazon CloudWatch Logs destination", destinationARN)
+	}
+	return nil
+}
+
+// subscribeDestination makes sure the log group and stream exist and forwards the log group to the destination. In a
+// vended log setup, the group and stream are provisioned with the delivery rather than by the hook, so they are never
+// created here.
+func (h *CloudWatchLogsHook) subscribeDestination() error {
+	group, err := h.findLogGroup(h.lookupContext(), h.group)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("Log group %s does not exist; it must be created before publishing to destination %s",
+			h.group, h.destinationARN)
+	}
+	stream, err := h.findLogStream()
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return fmt.Errorf("Log stream %s does not exist; it must be created before publishing to destination %s",
+			h.stream, h.destinationARN)
+	}
+
+	client, ok := h.client.(SubscriptionFilterAPI)
+	if !ok {
+		return fmt.Errorf("Unable to subscribe log group %s to destination %s: client does not support subscription "+
+			"filters", h.group, h.destinationARN)
+	}
+
+	// access to a cross-account destination is granted by the access policy of the destination in the receiving
+	// account rather than by a role, so no role ARN is given
+	_, err = client.PutSubscriptionFilter(h.lookupContext(), &cloudwatchlogs.PutSubscriptionFilterInput{
+		LogGroupName:   aws.String(h.group),
+		FilterName:     aws.String(DestinationFilterName),
+		FilterPattern:  aws.String(""),
+		DestinationArn: aws.String(h.destinationARN),
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException" {
+		return fmt.Errorf("Unable to subscribe log group %s to destination %s; make sure the access policy of the "+
+			"destination allows this account: %v", h.group, h.destinationARN, err)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to subscribe log group %s to destination %s: %v", h.group, h.destinationARN, err)
+	}
+	return nil
+}
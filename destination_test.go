@@ -0,0 +1,49 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithDestinationARN(t *testing.T) {
+	const destination = "arn:aws:logs:us-east-1:123456789012:destination:partner"
+	client := chaos.NewClient(chaos.Faults{})
+	options := []cloudwatchhook.CloudWatchLogsHookOption{
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithDestinationARN(destination),
+	}
+	if _, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "vended", "stream", options...); err == nil {
+		t.Fatalf("expected an error when the log group does not exist")
+	}
+	if client.Calls("CreateLogGroup") != 0 {
+		t.Errorf("expected the log group not to be created")
+	}
+
+	client.CreateLogGroup(context.TODO(), &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("vended")})
+	client.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String("vended"),
+		LogStreamName: aws.String("stream"),
+	})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "vended", "stream", options...)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if got := client.Destination("vended"); got != destination {
+		t.Errorf("expected the log group to be subscribed to %s, got %q", destination, got)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	logger.Info("delivered")
+	if n := len(client.Events("vended", "stream")); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+}
@@ -0,0 +1,78 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EntryCodec encodes entries as JSON objects built directly from the time, level, message, caller and fields of the
+// entry, without calling any logrus formatter. It matches the default output of logrus.JSONFormatter, so fields named
+// time, level, msg, func or file are renamed with a fields. prefix and errors are rendered as their message.
+type EntryCodec struct{}
+
+// entryKeys are the keys used by EntryCodec for the properties of an entry.
+var entryKeys = []string{"time", "level", "msg", "func", "file"}
+
+// Encode serializes the entry as a single line of JSON.
+func (EntryCodec) Encode(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+5)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	for _, key := range entryKeys {
+		if v, ok := data[key]; ok {
+			delete(data, key)
+			data["fields."+key] = v
+		}
+	}
+	if !entry.Time.IsZero() {
+		data["time"] = entry.Time.Format(time.RFC3339)
+	}
+	data["level"] = entry.Level.String()
+	data["msg"] = entry.Message
+	if entry.Caller != nil {
+		data["func"] = entry.Caller.Function
+		data["file"] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+
+	buf := entry.Buffer
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// discardFormatter is installed on loggers by AttachExclusive in place of their formatter. It formats nothing, since
+// the output of the logger is discarded, while the hook still encodes entries using the formatter it replaced.
+type discardFormatter struct {
+	logrus.Formatter
+}
+
+// Format returns nothing for the logger to write.
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// AttachExclusive adds the hook to the logger and makes it the only destination of the logger's entries: the output
+// of the logger is discarded and its formatter is no longer run for that output, so each entry is only formatted once,
+// by the hook. The hook keeps encoding entries with the formatter the logger had, unless it has its own codec.
+func (h *CloudWatchLogsHook) AttachExclusive(logger *logrus.Logger) {
+	logger.AddHook(h)
+	logger.SetOutput(ioutil.Discard)
+	if _, ok := logger.Formatter.(discardFormatter); !ok {
+		logger.SetFormatter(discardFormatter{Formatter: logger.Formatter})
+	}
+}
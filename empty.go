@@ -0,0 +1,58 @@
+package cloudwatchhook
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// EmptyMessagePolicy determines how empty and whitespace-only messages, which Amazon CloudWatch rejects along with the
+// rest of their batch, are handled.
+type EmptyMessagePolicy int
+
+const (
+	// EmptyMessageDrop drops empty and whitespace-only messages. This is the default policy.
+	EmptyMessageDrop EmptyMessagePolicy = iota
+
+	// EmptyMessagePad pads empty messages to a single space so that they are accepted. Whitespace-only messages are
+	// sent as is.
+	EmptyMessagePad
+
+	// EmptyMessagePlaceholder replaces empty and whitespace-only messages with a placeholder.
+	EmptyMessagePlaceholder
+)
+
+// String returns the name of the policy.
+func (p EmptyMessagePolicy) String() string {
+	switch p {
+	case EmptyMessageDrop:
+		return "drop"
+	case EmptyMessagePad:
+		return "pad"
+	case EmptyMessagePlaceholder:
+		return "placeholder"
+	default:
+		return "unknown"
+	}
+}
+
+// applyEmptyPolicy applies the empty message policy to the message, returning the message to send and whether or not
+// it should be sent at all.
+func (h *CloudWatchLogsHook) applyEmptyPolicy(msg string) (string, bool) {
+	if strings.TrimSpace(msg) != "" {
+		return msg, true
+	}
+	switch h.emptyPolicy {
+	case EmptyMessagePad:
+		if msg != "" {
+			return msg, true
+		}
+		msg = " "
+	case EmptyMessagePlaceholder:
+		msg = h.emptyPlaceholder
+	default:
+		atomic.AddInt64(&h.stats.emptyDropped, 1)
+		return "", false
+	}
+	atomic.AddInt64(&h.stats.emptyReplaced, 1)
+	return msg, true
+}
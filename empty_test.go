@@ -0,0 +1,34 @@
+package cloudwatchhook
+
+import "testing"
+
+func TestApplyEmptyPolicy(t *testing.T) {
+	tests := []struct {
+		policy   EmptyMessagePolicy
+		msg      string
+		expected string
+		send     bool
+	}{
+		{EmptyMessageDrop, "message", "message", true},
+		{EmptyMessageDrop, "", "", false},
+		{EmptyMessageDrop, " \n", "", false},
+		{EmptyMessagePad, "", " ", true},
+		{EmptyMessagePad, "\n", "\n", true},
+		{EmptyMessagePlaceholder, "\t", "<empty>", true},
+	}
+	for _, test := range tests {
+		h := newTestHook(t, "group", "stream", WithEmptyMessagePolicy(test.policy, "<empty>"))
+		msg, send := h.applyEmptyPolicy(test.msg)
+		if msg != test.expected || send != test.send {
+			t.Errorf("%s policy for %q = (%q, %v), want (%q, %v)", test.policy, test.msg, msg, send, test.expected,
+				test.send)
+		}
+	}
+
+	h := newTestHook(t, "group", "stream")
+	h.applyEmptyPolicy("")
+	h.applyEmptyPolicy("  ")
+	if stats := h.Stats(); stats.EmptyDropped != 2 {
+		t.Errorf("expected 2 dropped messages, got %d", stats.EmptyDropped)
+	}
+}
@@ -0,0 +1,37 @@
+package cloudwatchhook
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func TestFIPSEndpoints(t *testing.T) {
+	resolver := fipsEndpointResolver(cloudwatchlogs.NewDefaultEndpointResolver())
+	tests := map[string]string{
+		"fips-us-gov-west-1": "https://logs.us-gov-west-1.amazonaws.com",
+		"us-gov-east-1-fips": "https://logs.us-gov-east-1.amazonaws.com",
+		"fips-us-east-1":     "https://logs-fips.us-east-1.amazonaws.com",
+		"us-west-2-fips":     "https://logs-fips.us-west-2.amazonaws.com",
+		"us-gov-west-1":      "https://logs.us-gov-west-1.amazonaws.com",
+		"eu-west-1":          "https://logs.eu-west-1.amazonaws.com",
+	}
+	for region, expected := range tests {
+		endpoint, err := resolver.ResolveEndpoint(region, cloudwatchlogs.EndpointResolverOptions{})
+		if err != nil {
+			t.Errorf("%s: unable to resolve endpoint: %v", region, err)
+			continue
+		}
+		if endpoint.URL != expected {
+			t.Errorf("%s: expected endpoint %s, got %s", region, expected, endpoint.URL)
+		}
+		if base, _ := fipsBaseRegion(region); endpoint.SigningRegion != base {
+			t.Errorf("%s: expected signing region %s, got %s", region, base, endpoint.SigningRegion)
+		}
+	}
+
+	if _, err := newHook(aws.Config{}, "group", "stream", WithRegion("fips-eu-west-1")); err == nil {
+		t.Errorf("expected an error for a pseudo region without FIPS endpoints")
+	}
+}
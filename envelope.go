@@ -0,0 +1,230 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	// envelopeVersion is the version of the envelope format written by the hook.
+	envelopeVersion = 1
+
+	// envelopeAlgorithm is the algorithm used to encrypt the events in an envelope.
+	envelopeAlgorithm = "AES-256-GCM"
+
+	// dataKeySize is the size of the data keys, which are AES-256 keys.
+	dataKeySize = 32
+
+	// gcmNonceSize and gcmTagSize are the sizes of the random nonce of AES-GCM and of the authentication tag appended
+	// to its ciphertext.
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+// DataKeyProvider is used to obtain the data keys which encrypt batches of events. It is typically a thin wrapper
+// around the GenerateDataKey and Decrypt operations of an AWS KMS client bound to a single key, so the hook does not
+// need to depend on the KMS SDK.
+type DataKeyProvider interface {
+	// GenerateDataKey returns a new 256-bit data key, both in plaintext and encrypted under the master key.
+	GenerateDataKey(ctx context.Context) (plaintext, encrypted []byte, err error)
+
+	// Decrypt returns the plaintext of an encrypted data key.
+	Decrypt(ctx context.Context, encrypted []byte) ([]byte, error)
+}
+
+// Envelope is the manifest of a batch of events encrypted by the WithBatchEncryption option. It is sent to Amazon
+// CloudWatch as the message of a single event, in JSON form, in place of the events it holds.
+type Envelope struct {
+	Version    int    `json:"envelope"`
+	Algorithm  string `json:"alg"`
+	DataKey    string `json:"key"`
+	Nonce      string `json:"nonce"`
+	Events     int    `json:"events"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// sealEvents encrypts the events into as few envelope events as possible using a new data key. Each envelope event has
+// the timestamp of the oldest event it holds. The events must be in chronological order. Envelopes are sized on the
+// length of their manifest once the events are encoded, encrypted and base64 encoded, so that each fits within the
+// maximum size of an event; events which cannot fit in an envelope on their own are returned as oversized rather than
+// sealed.
+func sealEvents(ctx context.Context, provider DataKeyProvider, events []types.InputLogEvent) (
+	sealed, oversized []types.InputLogEvent, err error) {
+
+	plainKey, encryptedKey, err := provider.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to generate data key: %v", err)
+	}
+	if len(plainKey) != dataKeySize {
+		return nil, nil, fmt.Errorf("Invalid data key: expected %d bytes, got %d", dataKeySize, len(plainKey))
+	}
+	dataKey := base64.StdEncoding.EncodeToString(encryptedKey)
+
+	// envelopeSize returns the length of the manifest of an envelope holding the given number of events, encoded into
+	// the given number of bytes of plaintext
+	nonceSize := base64.StdEncoding.EncodedLen(gcmNonceSize)
+	envelopeSize := func(count, plaintextSize int) (int, error) {
+		manifest, err := json.Marshal(Envelope{
+			Version:    envelopeVersion,
+			Algorithm:  envelopeAlgorithm,
+			DataKey:    dataKey,
+			Nonce:      "",
+			Events:     count,
+			Ciphertext: "",
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(manifest) + nonceSize + base64.StdEncoding.EncodedLen(plaintextSize+gcmTagSize), nil
+	}
+
+	// the plaintext of an envelope is the JSON array of its events, built up as the events are added
+	var chunk []types.InputLogEvent
+	plaintext := []byte{'['}
+	seal := func() error {
+		nonce, ciphertext, err := sealGCM(plainKey, append(plaintext, ']'))
+		if err != nil {
+			return err
+		}
+		manifest, err := json.Marshal(Envelope{
+			Version:    envelopeVersion,
+			Algorithm:  envelopeAlgorithm,
+			DataKey:    dataKey,
+			Nonce:      base64.StdEncoding.EncodeToString(nonce),
+			Events:     len(chunk),
+			Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		})
+		if err != nil {
+			return err
+		}
+		sealed = append(sealed, types.InputLogEvent{
+			Message:   aws.String(string(manifest)),
+			Timestamp: chunk[0].Timestamp,
+		})
+		chunk, plaintext = nil, plaintext[:1]
+		return nil
+	}
+
+	for _, event := range events {
+		encoded, err := json.Marshal(relayEvent{
+			Timestamp: aws.ToInt64(event.Timestamp),
+			Message:   aws.ToString(event.Message),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if size, err := envelopeSize(1, len(encoded)+2); err != nil {
+			return nil, nil, err
+		} else if size > maxMessageSize {
+			oversized = append(oversized, event)
+			continue
+		}
+		if len(chunk) > 0 {
+			// the encoded event is added after a comma, and the array is closed when the envelope is sealed
+			size, err := envelopeSize(len(chunk)+1, len(plaintext)+1+len(encoded)+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if size > maxMessageSize {
+				if err := seal(); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		if len(chunk) > 0 {
+			plaintext = append(plaintext, ',')
+		}
+		plaintext = append(plaintext, encoded...)
+		chunk = append(chunk, event)
+	}
+	if len(chunk) > 0 {
+		if err := seal(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return sealed, oversized, nil
+}
+
+// OpenEnvelope decrypts the events held in the message of an event written with the WithBatchEncryption option.
+func OpenEnvelope(ctx context.Context, provider DataKeyProvider, message string) (events []Event, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			events, err = nil, fmt.Errorf("Unable to decrypt envelope: %v", r)
+		}
+	}()
+
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(message), &envelope); err != nil || envelope.Version == 0 {
+		return nil, fmt.Errorf("Message is not an encrypted envelope")
+	}
+	if envelope.Version != envelopeVersion || envelope.Algorithm != envelopeAlgorithm {
+		return nil, fmt.Errorf("Unsupported envelope version %d using %s", envelope.Version, envelope.Algorithm)
+	}
+	encryptedKey, err := base64.StdEncoding.DecodeString(envelope.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid envelope data key: %v", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid envelope nonce: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid envelope ciphertext: %v", err)
+	}
+
+	plainKey, err := provider.Decrypt(ctx, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt data key: %v", err)
+	}
+	if len(plainKey) != dataKeySize {
+		return nil, fmt.Errorf("Invalid data key: expected %d bytes, got %d", dataKeySize, len(plainKey))
+	}
+	plaintext, err := openGCM(plainKey, nonce, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunk []relayEvent
+	if err := json.Unmarshal(plaintext, &chunk); err != nil {
+		return nil, fmt.Errorf("Unable to decode envelope events: %v", err)
+	}
+	events = make([]Event, len(chunk))
+	for i, e := range chunk {
+		events[i] = Event{
+			Message:   e.Message,
+			Timestamp: time.Unix(0, e.Timestamp*int64(time.Millisecond)),
+		}
+	}
+	return events, nil
+}
+
+// seal encrypts the events if batch encryption is enabled. Events too large to fit in an envelope on their own are
+// handed to the dead letter sink and reported by the error returned along with the envelopes of the other events.
+// Crypto modules which reject an operation by panicking, as some FIPS validated modules do, fail the batch rather than
+// the application. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) seal(events []types.InputLogEvent) (sealed []types.InputLogEvent, err error) {
+	if h.dataKeys == nil {
+		return events, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			sealed, err = nil, fmt.Errorf("Unable to encrypt batch: %v", r)
+		}
+	}()
+	sealed, oversized, err := sealEvents(h.putContext(), h.dataKeys, events)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encrypt batch: %v", err)
+	}
+	if len(oversized) > 0 {
+		err = h.deadLetter(oversized, fmt.Errorf("Unable to encrypt %d events: too large to fit in an envelope",
+			len(oversized)))
+	}
+	return sealed, err
+}
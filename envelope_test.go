@@ -0,0 +1,146 @@
+//go:build !cloudwatchhook_nocrypto
+// +build !cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// testDataKeys is a DataKeyProvider which "encrypts" a fixed data key by prefixing it.
+type testDataKeys struct{}
+
+var testDataKey = bytes.Repeat([]byte{7}, 32)
+
+func (testDataKeys) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	return testDataKey, append([]byte("wrapped:"), testDataKey...), nil
+}
+
+func (testDataKeys) Decrypt(ctx context.Context, encrypted []byte) ([]byte, error) {
+	return bytes.TrimPrefix(encrypted, []byte("wrapped:")), nil
+}
+
+func TestSealAndOpenEnvelope(t *testing.T) {
+	large := strings.Repeat("x", 100000)
+	events := []types.InputLogEvent{
+		{Message: aws.String("secret " + large), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("second " + large), Timestamp: aws.Int64(2000)},
+		{Message: aws.String("third"), Timestamp: aws.Int64(3000)},
+	}
+	sealed, oversized, err := sealEvents(context.TODO(), testDataKeys{}, events)
+	if err != nil || len(oversized) != 0 {
+		t.Fatalf("unexpected error: %v (%d oversized)", err, len(oversized))
+	}
+	if len(sealed) != 2 {
+		t.Fatalf("expected 2 envelopes, got %d", len(sealed))
+	}
+	if aws.ToInt64(sealed[1].Timestamp) != 2000 {
+		t.Errorf("expected the second envelope to have the timestamp of its oldest event")
+	}
+
+	var opened []Event
+	for _, event := range sealed {
+		msg := aws.ToString(event.Message)
+		if strings.Contains(msg, "secret") || len(msg)+26 > 262144 {
+			t.Fatalf("envelope leaks plaintext or is too large (%d bytes)", len(msg))
+		}
+		envelopeEvents, err := OpenEnvelope(context.TODO(), testDataKeys{}, msg)
+		if err != nil {
+			t.Fatalf("unable to open envelope: %v", err)
+		}
+		opened = append(opened, envelopeEvents...)
+	}
+	if len(opened) != 3 || opened[2].Message != "third" || opened[2].Timestamp.UnixNano() != 3000*1000000 {
+		t.Errorf("unexpected opened events: %d events", len(opened))
+	}
+
+	if _, err := OpenEnvelope(context.TODO(), testDataKeys{}, "plain message"); err == nil {
+		t.Errorf("expected an error opening a plain message")
+	}
+}
+
+func TestSealEventsLimits(t *testing.T) {
+	events := func(n int, msg string) []types.InputLogEvent {
+		events := make([]types.InputLogEvent, n)
+		for i := range events {
+			events[i] = types.InputLogEvent{Message: aws.String(msg), Timestamp: aws.Int64(int64(1000 + i))}
+		}
+		return events
+	}
+	tests := map[string][]types.InputLogEvent{
+		// base64 grows the ciphertext by a third, so a full batch no longer fits in a single call
+		"full batch": events(1026, strings.Repeat("x", 974)),
+		// JSON escapes each < into six bytes
+		"escaped": events(1000, strings.Repeat("<", 1000)),
+	}
+	for name, batch := range tests {
+		sealed, oversized, err := sealEvents(context.TODO(), testDataKeys{}, batch)
+		if err != nil || len(oversized) != 0 {
+			t.Fatalf("%s: unexpected error: %v (%d oversized)", name, err, len(oversized))
+		}
+		opened := 0
+		for _, slice := range limitSlices(sealed) {
+			size := 0
+			for _, event := range slice {
+				msg := aws.ToString(event.Message)
+				if len(msg)+EventOverhead > MaxEventSize {
+					t.Errorf("%s: envelope of %d bytes exceeds the event size limit", name, len(msg))
+				}
+				size += len(msg) + EventOverhead
+				envelopeEvents, err := OpenEnvelope(context.TODO(), testDataKeys{}, msg)
+				if err != nil {
+					t.Fatalf("%s: unable to open envelope: %v", name, err)
+				}
+				opened += len(envelopeEvents)
+			}
+			if size > MaxBatchBytes || len(slice) > MaxBatchEvents {
+				t.Errorf("%s: batch of %d bytes and %d events exceeds the limits", name, size, len(slice))
+			}
+		}
+		if opened != len(batch) {
+			t.Errorf("%s: expected %d events in the envelopes, got %d", name, len(batch), opened)
+		}
+	}
+
+	// an event which cannot fit in an envelope on its own is returned rather than sealed
+	large := events(1, strings.Repeat("x", 250000))
+	sealed, oversized, err := sealEvents(context.TODO(), testDataKeys{}, append(large, events(1, "small")...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sealed) != 1 || len(oversized) != 1 || aws.ToString(oversized[0].Message) != *large[0].Message {
+		t.Errorf("expected the large event to be returned as oversized, got %d sealed and %d oversized", len(sealed),
+			len(oversized))
+	}
+}
+
+// contextDataKeys is a DataKeyProvider which records the context given to GenerateDataKey.
+type contextDataKeys struct {
+	testDataKeys
+	ctx context.Context
+}
+
+func (k *contextDataKeys) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	k.ctx = ctx
+	return k.testDataKeys.GenerateDataKey(ctx)
+}
+
+func TestSealCancelledOnClose(t *testing.T) {
+	keys := &contextDataKeys{}
+	h := newTestHook(t, "group", "stream", WithBatchEncryption(keys))
+
+	// a data key requested once the deadline for closing the hook has passed is given the cancelled context
+	h.cancelSend()
+	if _, err := h.seal([]types.InputLogEvent{{Message: aws.String("secret"), Timestamp: aws.Int64(1000)}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys.ctx == nil || keys.ctx.Err() == nil {
+		t.Errorf("expected the data key provider to be given the cancelled send context of the hook")
+	}
+}
@@ -0,0 +1,84 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// errorRepeatInterval is how often an error which keeps recurring is reported again, with the number of times it was
+// repeated, when errors are deduplicated.
+const errorRepeatInterval = time.Minute
+
+// errorDeduper collapses identical consecutive errors from sending batches, so that a failure which persists is
+// reported when it is first seen, periodically while it is repeated and once it is resolved, rather than on every
+// batch. It is safe for concurrent use.
+type errorDeduper struct {
+	mutex    sync.Mutex
+	key      string
+	err      error
+	first    time.Time
+	reported time.Time
+	total    int64
+	repeats  int64
+}
+
+// errorKey identifies errors which are considered identical. The errors of the service are compared by their code and
+// message, since their text also holds the ID of the request.
+func errorKey(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() + ": " + apiErr.ErrorMessage()
+	}
+	return err.Error()
+}
+
+// reportErr records the outcome of sending a batch when errors are deduplicated, reporting the changes to the debug
+// logger and the ops stream. It returns the error if it should be returned by the next write, which is only the case
+// when it was first seen; nil marks a successful batch. Without deduplication, the error is returned as is.
+func (h *CloudWatchLogsHook) reportErr(err error) error {
+	d := h.errDedup
+	if d == nil {
+		return err
+	}
+	now := h.clock.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err == nil {
+		if d.err != nil {
+			h.errorf("error resolved after %d occurrences since %s: %v", d.total, d.first.Format(time.RFC3339),
+				d.err)
+			d.key, d.err, d.total, d.repeats = "", nil, 0, 0
+		}
+		return nil
+	}
+
+	key := errorKey(err)
+	if d.err != nil && key == d.key {
+		d.total++
+		d.repeats++
+		atomic.AddInt64(&h.stats.errorsSuppressed, 1)
+		if now.Sub(d.reported) >= errorRepeatInterval {
+			h.errorf("error repeated %d times since %s: %v", d.repeats, d.first.Format(time.RFC3339), err)
+			d.reported, d.repeats = now, 0
+		}
+		return nil
+	}
+	if d.err != nil && d.repeats > 0 {
+		h.errorf("error repeated %d times since %s: %v", d.repeats, d.first.Format(time.RFC3339), d.err)
+	}
+	h.errorf("error first seen: %v", err)
+	d.key, d.err, d.first, d.reported, d.total, d.repeats = key, err, now, now, 1, 0
+	return err
+}
+
+// errorf writes an error report to the debug logger and the ops stream, if they are configured.
+func (h *CloudWatchLogsHook) errorf(format string, args ...interface{}) {
+	if h.debugLogger != nil {
+		h.debugLogger.Printf("cloudwatchhook: "+format, args...)
+	}
+	h.opsf("error", "errors", format, args...)
+}
@@ -0,0 +1,58 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestErrorDeduplication(t *testing.T) {
+	var buf bytes.Buffer
+	h := newTestHook(t, "group", "stream", WithBatchDuration(time.Hour), WithErrorDeduplication(),
+		WithDebugLogger(log.New(&buf, "", 0)))
+	unavailable := func(id string) error {
+		// the text of errors from the service differs by request ID
+		return &types.ServiceUnavailableException{Message: &id}
+	}
+
+	if err := h.reportErr(unavailable("first")); err == nil {
+		t.Errorf("expected the first error to be returned")
+	}
+	for i := 0; i < 3; i++ {
+		if err := h.reportErr(unavailable("first")); err != nil {
+			t.Errorf("expected the repeated error to be suppressed, got %v", err)
+		}
+	}
+	if n := h.Stats().ErrorsSuppressed; n != 3 {
+		t.Errorf("expected 3 suppressed errors, got %d", n)
+	}
+
+	// a repeated error is reported again after the interval
+	h.errDedup.reported = h.errDedup.reported.Add(-errorRepeatInterval)
+	h.reportErr(unavailable("first"))
+	if !strings.Contains(buf.String(), "error repeated 4 times") {
+		t.Errorf("expected the repeats to be reported, got %q", buf.String())
+	}
+
+	// a different error is reported at once, and success resolves it
+	other := errors.New("other")
+	if err := h.reportErr(other); err != other {
+		t.Errorf("expected a different error to be returned, got %v", err)
+	}
+	if err := h.reportErr(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[2], "error first seen: other") ||
+		!strings.Contains(lines[3], "error resolved after 1 occurrences") {
+		t.Errorf("expected first seen, repeated, first seen and resolved reports, got %q", lines)
+	}
+	if err := h.reportErr(nil); err != nil || strings.Count(buf.String(), "\n") != 4 {
+		t.Errorf("expected nothing to be reported without an error")
+	}
+}
@@ -0,0 +1,96 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxErrorChain is the maximum number of wrapped errors expanded for a single field.
+const maxErrorChain = 32
+
+// ErrorDetails is the structured representation of an error field produced by the WithErrorStacks option.
+type ErrorDetails struct {
+	Message string       `json:"message"`
+	Type    string       `json:"type"`
+	Chain   []ErrorCause `json:"chain,omitempty"`
+	Stack   []string     `json:"stack,omitempty"`
+}
+
+// ErrorCause is a single error in the chain of wrapped errors.
+type ErrorCause struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// expandError walks the chain of wrapped errors, supporting both Go 1.13 wrapping and github.com/pkg/errors causes,
+// and records the stack trace of the innermost error which has one.
+func expandError(err error) ErrorDetails {
+	details := ErrorDetails{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+	}
+	for cause, i := unwrapError(err), 0; cause != nil && i < maxErrorChain; cause, i = unwrapError(cause), i+1 {
+		details.Chain = append(details.Chain, ErrorCause{
+			Message: cause.Error(),
+			Type:    fmt.Sprintf("%T", cause),
+		})
+	}
+	for e, i := err, 0; e != nil && i < maxErrorChain; e, i = unwrapError(e), i+1 {
+		if stack := errorStack(e); stack != nil {
+			details.Stack = stack
+		}
+	}
+	return details
+}
+
+// unwrapError returns the error wrapped by err using either Unwrap or the Cause method of github.com/pkg/errors.
+func unwrapError(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		if cause := causer.Cause(); cause != err {
+			return cause
+		}
+	}
+	return nil
+}
+
+// errorStack returns the stack trace recorded by an error from github.com/pkg/errors, or any error with a compatible
+// StackTrace method, without depending on that package.
+func errorStack(err error) []string {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	trace := method.Call(nil)[0]
+	if trace.Kind() != reflect.Slice || trace.Len() == 0 {
+		return nil
+	}
+	stack := make([]string, 0, trace.Len())
+	for i := 0; i < trace.Len(); i++ {
+		// frames format as "function\n\tfile:line" with %+v
+		frame := fmt.Sprintf("%+v", trace.Index(i).Interface())
+		stack = append(stack, strings.Replace(frame, "\n\t", " ", 1))
+	}
+	return stack
+}
+
+// ErrorStackEnricher returns an enricher which replaces any error fields with their structured details, holding the
+// message, type, chain of wrapped errors and stack trace.
+func ErrorStackEnricher() Enricher {
+	return EnricherFunc(addErrorStacks)
+}
+
+// addErrorStacks replaces any error fields with their structured details.
+func addErrorStacks(entry *logrus.Entry, fields logrus.Fields) {
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok && err != nil {
+			fields[k] = expandError(err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// stackError mimics an error from github.com/pkg/errors which records a stack trace.
+type stackError struct {
+	msg   string
+	cause error
+}
+
+type stackFrame string
+
+func (f stackFrame) Format(s fmt.State, verb rune) {
+	fmt.Fprint(s, "main.run\n\t/app/main.go:"+string(f))
+}
+
+func (e *stackError) Error() string            { return e.msg + ": " + e.cause.Error() }
+func (e *stackError) Cause() error             { return e.cause }
+func (e *stackError) StackTrace() []stackFrame { return []stackFrame{"10", "20"} }
+
+func TestExpandError(t *testing.T) {
+	root := errors.New("not found")
+	err := fmt.Errorf("load failed: %w", &stackError{msg: "query", cause: root})
+
+	details := expandError(err)
+	if details.Message != err.Error() {
+		t.Errorf("unexpected message %q", details.Message)
+	}
+	if len(details.Chain) != 2 || details.Chain[1].Message != "not found" {
+		t.Errorf("unexpected chain %+v", details.Chain)
+	}
+	if len(details.Stack) != 2 || details.Stack[0] != "main.run /app/main.go:10" {
+		t.Errorf("unexpected stack %+v", details.Stack)
+	}
+}
@@ -0,0 +1,54 @@
+package cloudwatchhook
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	mathrand "math/rand"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventUIDField is the name of the field holding the unique ID added by the WithEventID option.
+const EventUIDField = "event_uid"
+
+// crockford is the Crockford base32 alphabet used to encode ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// randRead fills the random bits of ULIDs, replaced in tests.
+var randRead = rand.Read
+
+// NewULID returns a new Universally Unique Lexicographically Sortable Identifier: 26 characters encoding the current
+// time in milliseconds followed by 80 random bits, so that IDs sort by the time they were generated. Should the
+// system's secure random number generator fail, the random bits are taken from math/rand instead, since an ID which
+// is unique but predictable still serves to remove duplicates.
+func NewULID() string {
+	var id [16]byte
+	now := time.Now()
+	binary.BigEndian.PutUint64(id[:8], uint64(now.UnixNano()/int64(time.Millisecond))<<16)
+	if _, err := randRead(id[6:]); err != nil {
+		mathrand.New(mathrand.NewSource(now.UnixNano())).Read(id[6:])
+	}
+
+	// encode the 128 bits 5 at a time, after the 3 bits left over at the top
+	var out [26]byte
+	out[0] = crockford[id[0]>>5]
+	acc, bits, n := uint32(id[0]&0x1f), uint(5), 1
+	for _, b := range id[1:] {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[n] = crockford[(acc>>bits)&0x1f]
+			n++
+		}
+	}
+	return string(out[:])
+}
+
+// UniqueIDEnricher returns an enricher which adds an ID returned by the generator to the fields of each entry.
+func UniqueIDEnricher(generator func() string) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		fields[EventUIDField] = generator()
+	})
+}
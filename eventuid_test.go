@@ -0,0 +1,35 @@
+package cloudwatchhook
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestUniqueIDEnricher(t *testing.T) {
+	first := NewULID()
+	if len(first) != 26 || strings.Trim(first, crockford) != "" {
+		t.Fatalf("expected a 26 character ULID, got %q", first)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if second := NewULID(); second <= first {
+		t.Errorf("expected ULIDs to sort by time, got %s after %s", second, first)
+	}
+
+	// the random bits still vary should the secure random number generator fail
+	randRead = func([]byte) (int, error) { return 0, errors.New("entropy exhausted") }
+	defer func() { randRead = rand.Read }()
+	if third, fourth := NewULID(), NewULID(); len(third) != 26 || third[10:] == fourth[10:] {
+		t.Errorf("expected ULIDs with random bits, got %s and %s", third, fourth)
+	}
+
+	fields := logrus.Fields{}
+	UniqueIDEnricher(func() string { return "ticket-42" }).Enrich(&logrus.Entry{}, fields)
+	if fields[EventUIDField] != "ticket-42" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
@@ -0,0 +1,273 @@
+// Package demo runs scenarios which exercise the hook end to end, from logging entries with logrus to reading the
+// events back from Amazon CloudWatch Logs: batching, rotating to a new stream and recovering from injected failures.
+// The scenarios run against the real service, a local emulator such as LocalStack, or the fake client of the chaos
+// package, so they double as integration tests which can be run before adopting the hook.
+package demo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/sirupsen/logrus"
+)
+
+// Client is the part of the Amazon CloudWatch Logs API used by the demo: the calls made by the hook, plus
+// FilterLogEvents to read the events back.
+type Client interface {
+	cloudwatchhook.CloudWatchLogsAPI
+	cloudwatchhook.FilterLogEventsAPI
+}
+
+// Config configures a run of the demo.
+type Config struct {
+	// Client is used to call Amazon CloudWatch Logs. If it is nil, a client is created from AWS which sends its
+	// requests to Endpoint, if set, such as http://localhost:4566 for LocalStack.
+	Client   Client
+	AWS      aws.Config
+	Endpoint string
+
+	// Group is the log group written to. Each scenario writes to its own streams, named after the scenario and the
+	// time the run started, so that runs do not see each other's events.
+	Group string
+
+	// Events is the number of entries logged by each scenario.
+	Events int
+
+	// Timeout is how long a scenario waits for its events to be readable.
+	Timeout time.Duration
+
+	// Out receives the progress of the run. Nothing is written if it is nil.
+	Out io.Writer
+}
+
+// DefaultConfig returns the configuration used by the demo command, without a client or AWS configuration.
+func DefaultConfig() Config {
+	return Config{
+		Client:   nil,
+		AWS:      aws.Config{},
+		Endpoint: "",
+		Group:    "/demo/logrus-cloudwatch-hook",
+		Events:   100,
+		Timeout:  30 * time.Second,
+		Out:      nil,
+	}
+}
+
+// Scenario is a named step of the demo. It returns an error if the hook did not behave as expected.
+type Scenario struct {
+	Name string
+	Run  func(ctx context.Context, cfg Config, run string) error
+}
+
+// Scenarios are the scenarios run by Run, in order.
+var Scenarios = []Scenario{
+	{Name: "batching", Run: Batching},
+	{Name: "rotation", Run: Rotation},
+	{Name: "failure-injection", Run: FailureInjection},
+}
+
+// Run runs each of the scenarios and returns the first error, after reporting the outcome of every scenario to the
+// output of the configuration.
+func Run(ctx context.Context, cfg Config) error {
+	if cfg.Client == nil {
+		cfg.Client = NewClient(cfg.AWS, cfg.Endpoint)
+	}
+	run := time.Now().UTC().Format("20060102T150405")
+	var firstErr error
+	for _, scenario := range Scenarios {
+		start := time.Now()
+		err := scenario.Run(ctx, cfg, run)
+		if err != nil {
+			cfg.printf("[FAIL] %s: %v\n", scenario.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("Scenario %s failed: %v", scenario.Name, err)
+			}
+			continue
+		}
+		cfg.printf("[ OK ] %s (%v)\n", scenario.Name, time.Since(start).Round(time.Millisecond))
+	}
+	return firstErr
+}
+
+// NewClient creates a client from the AWS configuration which sends its requests to the given endpoint, or to the
+// endpoint of the region if it is empty.
+func NewClient(cfg aws.Config, endpoint string) Client {
+	if endpoint != "" {
+		cfg.EndpointResolver = aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: endpoint, SigningRegion: region}, nil
+		})
+	}
+	return cloudwatchlogs.NewFromConfig(cfg)
+}
+
+// Batching logs the entries through a hook which batches them and checks that they were all delivered in fewer
+// calls than entries.
+func Batching(ctx context.Context, cfg Config, run string) error {
+	client := &instrumentedClient{Client: cfg.Client}
+	stream := "batching-" + run
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(cfg.AWS, cfg.Group, stream,
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(200*time.Millisecond))
+	if err != nil {
+		return err
+	}
+	logEntries(hook, "batching", 0, cfg.Events)
+	if err := hook.Close(); err != nil {
+		return err
+	}
+	calls := atomic.LoadInt64(&client.calls)
+	if calls >= int64(cfg.Events) {
+		return fmt.Errorf("Expected fewer than %d calls to PutLogEvents, got %d", cfg.Events, calls)
+	}
+	cfg.printf("       %d entries sent in %d calls, p99 latency %v\n", cfg.Events, calls, hook.Stats().LatencyP99)
+	return waitForEvents(ctx, cfg, stream, cfg.Events)
+}
+
+// Rotation logs half of the entries, points the hook at a new stream and logs the rest, then checks that each stream
+// received its half.
+func Rotation(ctx context.Context, cfg Config, run string) error {
+	first, second := "rotation-"+run+"-1", "rotation-"+run+"-2"
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(cfg.AWS, cfg.Group, first,
+		cloudwatchhook.WithClient(cfg.Client), cloudwatchhook.WithBatchDuration(200*time.Millisecond))
+	if err != nil {
+		return err
+	}
+	half := cfg.Events / 2
+	logEntries(hook, "rotation", 0, half)
+	if err := hook.Update(cloudwatchhook.UpdateTarget(cfg.Group, second)); err != nil {
+		hook.Close()
+		return err
+	}
+	logEntries(hook, "rotation", half, cfg.Events)
+	if err := hook.Close(); err != nil {
+		return err
+	}
+	if err := waitForEvents(ctx, cfg, first, half); err != nil {
+		return err
+	}
+	return waitForEvents(ctx, cfg, second, cfg.Events-half)
+}
+
+// FailureInjection throttles every third call to PutLogEvents and checks that the backoff policy of the hook still
+// delivers every entry without dead lettering any.
+func FailureInjection(ctx context.Context, cfg Config, run string) error {
+	client := &instrumentedClient{Client: cfg.Client, throttleEvery: 3}
+	letters := &deadLetterCounter{}
+	stream := "failure-injection-" + run
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(cfg.AWS, cfg.Group, stream,
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithDeadLetterSink(letters),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: 10 * time.Millisecond, MaxRetries: 3}))
+	if err != nil {
+		return err
+	}
+	logEntries(hook, "failure-injection", 0, cfg.Events)
+	if err := hook.Close(); err != nil {
+		return err
+	}
+	throttled := atomic.LoadInt64(&client.throttled)
+	if throttled == 0 {
+		return fmt.Errorf("Expected calls to PutLogEvents to be throttled")
+	}
+	if dead := atomic.LoadInt64(&letters.events); dead > 0 {
+		return fmt.Errorf("Expected no dead letters, got %d events", dead)
+	}
+	cfg.printf("       %d of %d calls throttled and retried\n", throttled, atomic.LoadInt64(&client.calls))
+	return waitForEvents(ctx, cfg, stream, cfg.Events)
+}
+
+// logEntries logs the entries numbered from start up to end through a logger which only writes to the hook.
+func logEntries(hook *cloudwatchhook.CloudWatchLogsHook, scenario string, start, end int) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(hook)
+	for i := start; i < end; i++ {
+		logger.WithFields(logrus.Fields{"scenario": scenario, "seq": i}).Info("demo entry")
+	}
+}
+
+// waitForEvents reads the events of the stream until there are as many as expected or the timeout passes.
+func waitForEvents(ctx context.Context, cfg Config, stream string, expected int) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	for {
+		count, err := countEvents(ctx, cfg.Client, cfg.Group, stream)
+		if err != nil {
+			return fmt.Errorf("Unable to read the events of log stream %s: %v", stream, err)
+		}
+		if count >= expected {
+			return nil
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return fmt.Errorf("Found %d of %d events in log stream %s", count, expected, stream)
+		}
+	}
+}
+
+// countEvents returns the number of events in the stream.
+func countEvents(ctx context.Context, client Client, group, stream string) (int, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   aws.String(group),
+		LogStreamNames: []string{stream},
+	}
+	count := 0
+	for {
+		result, err := client.FilterLogEvents(ctx, input)
+		if err != nil {
+			return 0, err
+		}
+		count += len(result.Events)
+		if result.NextToken == nil {
+			return count, nil
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+// printf writes the progress of the run to the output, if any.
+func (cfg Config) printf(format string, args ...interface{}) {
+	if cfg.Out != nil {
+		fmt.Fprintf(cfg.Out, format, args...)
+	}
+}
+
+// instrumentedClient counts the calls to PutLogEvents made through it and, if throttleEvery is set, fails every nth
+// call with a ThrottlingException without passing it on.
+type instrumentedClient struct {
+	Client
+	throttleEvery int64
+	calls         int64
+	throttled     int64
+}
+
+// PutLogEvents counts the call and passes it on unless it is throttled.
+func (c *instrumentedClient) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	calls := atomic.AddInt64(&c.calls, 1)
+	if c.throttleEvery > 0 && calls%c.throttleEvery == 0 {
+		atomic.AddInt64(&c.throttled, 1)
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded (injected)"}
+	}
+	return c.Client.PutLogEvents(ctx, params, optFns...)
+}
+
+// deadLetterCounter is a dead letter sink which counts the events it receives.
+type deadLetterCounter struct {
+	events int64
+}
+
+// Send counts the events of the letter.
+func (c *deadLetterCounter) Send(ctx context.Context, letter cloudwatchhook.DeadLetter) error {
+	atomic.AddInt64(&c.events, int64(len(letter.Events)))
+	return nil
+}
@@ -0,0 +1,43 @@
+package demo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/examples/demo"
+)
+
+func TestRun(t *testing.T) {
+	cfg := demo.DefaultConfig()
+	cfg.Client = chaos.NewClient(chaos.Faults{})
+	cfg.Timeout = time.Second
+	if err := demo.Run(context.Background(), cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRunLocalStack runs the demo against the endpoint in DEMO_ENDPOINT, such as the LocalStack container started by
+// examples/localstack/docker-compose.yml.
+func TestRunLocalStack(t *testing.T) {
+	endpoint := os.Getenv("DEMO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DEMO_ENDPOINT is not set")
+	}
+	cfg := demo.DefaultConfig()
+	cfg.AWS = aws.Config{Region: "us-east-1", Credentials: localCredentials{}}
+	cfg.Endpoint = endpoint
+	if err := demo.Run(context.Background(), cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+// localCredentials are the dummy credentials accepted by LocalStack.
+type localCredentials struct{}
+
+func (localCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test", Source: "demo"}, nil
+}
@@ -0,0 +1,45 @@
+// Command localstack runs the demo scenarios against Amazon CloudWatch Logs, or against the endpoint in DEMO_ENDPOINT,
+// such as the LocalStack container started by the docker-compose.yml file in this directory:
+//
+//	docker-compose up --abort-on-container-exit
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/examples/demo"
+)
+
+func main() {
+	cfg := demo.DefaultConfig()
+	cfg.Endpoint = os.Getenv("DEMO_ENDPOINT")
+	cfg.Out = os.Stdout
+	if group := os.Getenv("DEMO_LOG_GROUP"); group != "" {
+		cfg.Group = group
+	}
+	if events := os.Getenv("DEMO_EVENTS"); events != "" {
+		count, err := strconv.Atoi(events)
+		if err != nil || count < 2 {
+			fmt.Fprintf(os.Stderr, "ERROR: DEMO_EVENTS must be an integer of at least 2")
+			os.Exit(1)
+		}
+		cfg.Events = count
+	}
+
+	var err error
+	cfg.AWS, err = config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Failed to load AWS default configuration: %s", err)
+		os.Exit(2)
+	}
+
+	if err := demo.Run(context.Background(), cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,93 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// exportPollInterval is how often the status of an export task is checked while waiting for it to complete.
+const exportPollInterval = 5 * time.Second
+
+// ExportTasksAPI is the part of the Amazon CloudWatch Logs API used to export a log group to Amazon S3. It is only
+// required of the client by ExportToS3.
+type ExportTasksAPI interface {
+	CreateExportTask(ctx context.Context, params *cloudwatchlogs.CreateExportTaskInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateExportTaskOutput, error)
+	DescribeExportTasks(ctx context.Context, params *cloudwatchlogs.DescribeExportTasksInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeExportTasksOutput, error)
+}
+
+// ExportToS3 exports the events of the log group with timestamps from the start time up to, but not including, the end
+// time to the S3 bucket under the given prefix, and waits for the export task to complete. The ID of the task is
+// returned, even if waiting for it fails, since the task keeps running if the context is cancelled. Amazon CloudWatch
+// only runs one export task per account at a time, and the bucket policy must allow CloudWatch Logs to write to it.
+func (h *CloudWatchLogsHook) ExportToS3(ctx context.Context, bucket, prefix string,
+	from, to time.Time) (string, error) {
+
+	client, ok := h.client.(ExportTasksAPI)
+	if !ok {
+		return "", fmt.Errorf("Unable to export log group: client does not support export tasks")
+	}
+	if bucket == "" {
+		return "", fmt.Errorf("Unable to export log group: no bucket given")
+	}
+	if !to.After(from) {
+		return "", fmt.Errorf("Unable to export log group: the end time %v is not after the start time %v", to, from)
+	}
+	h.intakeMutex.Lock()
+	group := h.currentTarget().group
+	h.intakeMutex.Unlock()
+
+	input := &cloudwatchlogs.CreateExportTaskInput{
+		Destination:  aws.String(bucket),
+		LogGroupName: aws.String(group),
+		From:         aws.Int64(from.UnixNano() / int64(time.Millisecond)),
+		To:           aws.Int64(to.UnixNano() / int64(time.Millisecond)),
+	}
+	if prefix != "" {
+		input.DestinationPrefix = aws.String(prefix)
+	}
+	output, err := client.CreateExportTask(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("Unable to export log group %s: %v", group, err)
+	}
+	taskID := aws.ToString(output.TaskId)
+	return taskID, h.awaitExport(ctx, client, group, taskID)
+}
+
+// awaitExport polls the export task until it completes, fails or the context is done.
+func (h *CloudWatchLogsHook) awaitExport(ctx context.Context, client ExportTasksAPI, group, taskID string) error {
+	timer := h.clock.NewTimer(exportPollInterval)
+	defer timer.Stop()
+	for {
+		output, err := client.DescribeExportTasks(ctx, &cloudwatchlogs.DescribeExportTasksInput{
+			TaskId: aws.String(taskID),
+		})
+		if err != nil {
+			return fmt.Errorf("Unable to check export task %s: %v", taskID, err)
+		}
+		if len(output.ExportTasks) == 0 || output.ExportTasks[0].Status == nil {
+			return fmt.Errorf("Unable to check export task %s: the task was not found", taskID)
+		}
+		status := output.ExportTasks[0].Status
+		switch status.Code {
+		case types.ExportTaskStatusCodeCompleted:
+			return nil
+		case types.ExportTaskStatusCodeCancelled, types.ExportTaskStatusCodeFailed:
+			return fmt.Errorf("Export task %s for log group %s ended with status %s: %s", taskID, group,
+				status.Code, aws.ToString(status.Message))
+		}
+
+		select {
+		case <-timer.C():
+			timer.Reset(exportPollInterval)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
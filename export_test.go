@@ -0,0 +1,58 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestExportToS3(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	type result struct {
+		taskID string
+		err    error
+	}
+	done := make(chan result, 1)
+	from := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	go func() {
+		taskID, err := hook.ExportToS3(context.Background(), "archive", "logs/group", from, from.AddDate(0, 1, 0))
+		done <- result{taskID, err}
+	}()
+
+	// the task is still running when first checked, so the export waits for the next check
+	var r result
+	for r.taskID == "" && r.err == nil {
+		select {
+		case r = <-done:
+		case <-time.After(10 * time.Millisecond):
+			clock.Advance(5 * time.Second)
+		}
+	}
+	if r.err != nil {
+		t.Fatalf("unable to export: %v", r.err)
+	}
+	tasks := client.ExportTasks()
+	if len(tasks) != 1 || aws.ToString(tasks[0].TaskId) != r.taskID ||
+		aws.ToString(tasks[0].DestinationPrefix) != "logs/group" {
+		t.Errorf("expected a single export task for the group, got %+v", tasks)
+	}
+	if calls := client.Calls("DescribeExportTasks"); calls != 2 {
+		t.Errorf("expected the task to be checked until it completed, got %d checks", calls)
+	}
+
+	if _, err := hook.ExportToS3(context.Background(), "archive", "", from, from); err == nil {
+		t.Errorf("expected an error exporting an empty time range")
+	}
+}
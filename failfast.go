@@ -0,0 +1,96 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+var (
+	// ErrAccessDenied is the reason of a WriteError when the credentials of the hook are not allowed to send events.
+	ErrAccessDenied = errors.New("Access denied")
+
+	// ErrLogGroupNotFound is the reason of a WriteError when the log group or stream of the hook does not exist.
+	ErrLogGroupNotFound = errors.New("Log group or stream not found")
+
+	// ErrWriteTimeout is the reason of a WriteError when a write does not complete within the timeout set by
+	// WithWriteTimeout.
+	ErrWriteTimeout = errors.New("Write timed out")
+)
+
+// WriteError is returned by writes which are sent directly to Amazon CloudWatch and fail for one of the common
+// configuration problems. It matches its Reason with errors.Is, such as errors.Is(err, ErrAccessDenied), while the
+// error returned by the AWS SDK is still available with errors.As.
+type WriteError struct {
+	Reason error
+	Group  string
+	Stream string
+	Err    error
+}
+
+// Error describes the failure along with the most likely fix.
+func (e *WriteError) Error() string {
+	var hint string
+	switch e.Reason {
+	case ErrAccessDenied:
+		hint = "; make sure the IAM policy allows logs:PutLogEvents"
+	case ErrLogGroupNotFound:
+		hint = "; make sure it has not been deleted, or allow the hook to create it"
+	}
+	return fmt.Sprintf("%v writing to log stream %s in log group %s%s: %v", e.Reason, e.Stream, e.Group, hint, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is the reason of the error.
+func (e *WriteError) Is(target error) bool {
+	return target == e.Reason
+}
+
+// writeError wraps the error from a direct write in a WriteError if it has one of the known reasons, or returns it
+// unchanged otherwise. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) writeError(target streamTarget, err error) error {
+	var reason error
+	var notFoundErr *types.ResourceNotFoundException
+	var apiErr smithy.APIError
+	switch {
+	case h.writeCtx != nil && h.writeCtx.Err() == context.DeadlineExceeded:
+		reason = ErrWriteTimeout
+	case errors.As(err, &notFoundErr):
+		reason = ErrLogGroupNotFound
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException":
+		reason = ErrAccessDenied
+	default:
+		return err
+	}
+	return &WriteError{
+		Reason: reason,
+		Group:  target.group,
+		Stream: target.stream,
+		Err:    err,
+	}
+}
+
+// lookupContext returns the context for the calls which look up or create the log group and stream, which expires
+// with the write timeout while the hook is being created or during a direct write. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) lookupContext() context.Context {
+	if h.writeCtx != nil {
+		return h.writeCtx
+	}
+	return context.TODO()
+}
+
+// putContext returns the context for calls which send events, which expires with the write timeout during a direct
+// write. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) putContext() context.Context {
+	if h.writeCtx != nil {
+		return h.writeCtx
+	}
+	return h.sendContext()
+}
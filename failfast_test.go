@@ -0,0 +1,125 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// deniedClient fails every PutLogEvents call as if the credentials lacked the logs:PutLogEvents permission.
+type deniedClient struct {
+	*chaos.Client
+}
+
+func (c *deniedClient) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "User is not authorized"}
+}
+
+// hangingClient never answers DescribeLogGroups, as if the endpoint could not be reached.
+type hangingClient struct {
+	*chaos.Client
+}
+
+func (c *hangingClient) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// failingSink fails to store every dead letter.
+type failingSink struct{}
+
+func (failingSink) Send(ctx context.Context, letter cloudwatchhook.DeadLetter) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestWriteTimeout(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{LatencyRate: 1, Latency: time.Minute})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithWriteTimeout(50*time.Millisecond),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Minute, MaxRetries: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+
+	start := time.Now()
+	_, err = hook.Write([]byte("hello"))
+	if !errors.Is(err, cloudwatchhook.ErrWriteTimeout) {
+		t.Fatalf("expected a write timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the write to fail quickly, took %v", elapsed)
+	}
+}
+
+func TestWriteTimeoutAtStartup(t *testing.T) {
+	start := time.Now()
+	_, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(&hangingClient{chaos.NewClient(chaos.Faults{})}),
+		cloudwatchhook.WithWriteTimeout(50*time.Millisecond))
+	if !errors.Is(err, cloudwatchhook.ErrWriteTimeout) {
+		t.Fatalf("expected a write timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the hook to fail quickly, took %v", elapsed)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(&deniedClient{client}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = hook.Write([]byte("hello"))
+	var writeErr *cloudwatchhook.WriteError
+	if !errors.Is(err, cloudwatchhook.ErrAccessDenied) || !errors.As(err, &writeErr) || writeErr.Group != "group" {
+		t.Errorf("expected access to be denied to the group, got %v", err)
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("expected the error of the service to be wrapped, got %v", err)
+	}
+	hook.Close()
+
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+	if _, err := client.DeleteLogGroup(context.Background(),
+		&cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String("group")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = hook.Write([]byte("hello"))
+	var notFoundErr *types.ResourceNotFoundException
+	if !errors.Is(err, cloudwatchhook.ErrLogGroupNotFound) || !errors.As(err, &notFoundErr) {
+		t.Errorf("expected the log group to not be found, got %v", err)
+	}
+
+	// the error of the service is still wrapped when the dead letter sink fails too
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(&deniedClient{client}), cloudwatchhook.WithDeadLetterSink(failingSink{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+	_, err = hook.Write([]byte("hello"))
+	if !errors.Is(err, cloudwatchhook.ErrAccessDenied) || !errors.As(err, &apiErr) {
+		t.Errorf("expected access to be denied despite the dead letter sink failing, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// formatError handles an entry which the hook was unable to encode, such as one holding a value which cannot be
+// serialized. The error is counted and reported to the fallback logger, or to the debug logger if there is none,
+// rather than returned to Logrus, which would print it to stderr, unless WithReturnFormatErrors was given.
+func (h *CloudWatchLogsHook) formatError(entry *logrus.Entry, err error) error {
+	atomic.AddInt64(&h.stats.formatErrors, 1)
+	if h.returnFmtErrors {
+		return fmt.Errorf("Unable to parse entry: %v", err)
+	}
+	if h.fallbackLogger != nil {
+		h.fallbackLogger.Printf("cloudwatchhook: unable to encode %s entry %q: %v", entry.Level, entry.Message, err)
+		return nil
+	}
+	h.debugf("unable to encode %s entry %q: %v", entry.Level, entry.Message, err)
+	return nil
+}
@@ -0,0 +1,53 @@
+package cloudwatchhook_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSafeFallbackLogger(t *testing.T) {
+	var buf bytes.Buffer
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithSafeFallbackLogger(log.New(&buf, "", 0)))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	entry := logger.WithField("channel", make(chan int))
+	entry.Level, entry.Message = logrus.InfoLevel, "unencodable"
+	if err := hook.Fire(entry); err != nil {
+		t.Errorf("expected the error to be kept from Logrus, got %v", err)
+	}
+	if !strings.Contains(buf.String(), `unable to encode info entry "unencodable"`) {
+		t.Errorf("expected the error to be written to the fallback logger, got %q", buf.String())
+	}
+	if n := hook.Stats().FormatErrors; n != 1 {
+		t.Errorf("expected 1 format error, got %d", n)
+	}
+	if n := len(client.Events("group", "stream")); n != 0 {
+		t.Errorf("expected no events, got %d", n)
+	}
+
+	returning, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithReturnFormatErrors())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer returning.Close()
+	if err := returning.Fire(entry); err == nil {
+		t.Errorf("expected the error to be returned")
+	}
+}
@@ -0,0 +1,164 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UncoercedFieldsField is the name of the field holding the text of the values which the WithFieldTypes option could
+// not coerce to the type of their field, keyed by the name of the field.
+const UncoercedFieldsField = "uncoerced_fields"
+
+// FieldType is the type a field is coerced to by the WithFieldTypes option, so that CloudWatch Logs Insights sees the
+// same type in every event.
+type FieldType int
+
+const (
+	// FieldString renders the value as a string, as the field budget measures it.
+	FieldString FieldType = iota
+
+	// FieldNumber keeps numbers and parses strings holding a number. Booleans become 1 or 0.
+	FieldNumber
+
+	// FieldBool keeps booleans and parses strings such as "true", "f" or "1". Numbers are true unless they are zero.
+	FieldBool
+)
+
+// String returns the name of the type.
+func (t FieldType) String() string {
+	switch t {
+	case FieldString:
+		return "string"
+	case FieldNumber:
+		return "number"
+	case FieldBool:
+		return "bool"
+	default:
+		return "unknown"
+	}
+}
+
+// FieldTypeEnricher returns an enricher which coerces the fields named in the map to their type. A value which cannot
+// be coerced, such as "n/a" for a number, is replaced with null and its text is kept in the uncoerced_fields field
+// instead, so that it does not break aggregations. Missing and nil fields are left alone.
+func FieldTypeEnricher(types map[string]FieldType) Enricher {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		var uncoerced map[string]string
+		for _, name := range names {
+			v, ok := fields[name]
+			if !ok {
+				v, ok = entry.Data[name]
+			}
+			if !ok || v == nil {
+				continue
+			}
+			if coerced, ok := types[name].coerce(v); ok {
+				fields[name] = coerced
+				continue
+			}
+			if uncoerced == nil {
+				uncoerced = map[string]string{}
+			}
+			uncoerced[name] = fieldText(v)
+			fields[name] = nil
+		}
+		if uncoerced != nil {
+			fields[UncoercedFieldsField] = uncoerced
+		}
+	})
+}
+
+// coerce converts the value to the type, returning false if it cannot be converted.
+func (t FieldType) coerce(v interface{}) (interface{}, bool) {
+	switch t {
+	case FieldString:
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		return fieldText(v), true
+	case FieldNumber:
+		return coerceNumber(v)
+	case FieldBool:
+		return coerceBool(v)
+	}
+	return nil, false
+}
+
+// coerceNumber converts the value to a number. Integers are kept exact; NaN and infinities cannot be encoded as JSON.
+func coerceNumber(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case json.Number:
+		return parseNumber(string(v))
+	case string:
+		return parseNumber(v)
+	case []byte:
+		return parseNumber(string(v))
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		f := value.Float()
+		return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return nil, false
+}
+
+// parseNumber parses the text of a number.
+func parseNumber(s string) (interface{}, bool) {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return f, true
+}
+
+// coerceBool converts the value to a boolean.
+func coerceBool(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	case []byte:
+		b, err := strconv.ParseBool(strings.TrimSpace(string(v)))
+		return b, err == nil
+	}
+	n, ok := coerceNumber(v)
+	if !ok {
+		return nil, false
+	}
+	switch n := n.(type) {
+	case int64:
+		return n != 0, true
+	case uint64:
+		return n != 0, true
+	case float64:
+		return n != 0, true
+	}
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldTypes(t *testing.T) {
+	entry := &logrus.Entry{
+		Message: "request",
+		Data: logrus.Fields{
+			"latency_ms": "12.5",
+			"status":     "200",
+			"bytes":      uint16(512),
+			"elapsed":    time.Second,
+			"cached":     "TRUE",
+			"retried":    0,
+			"user_id":    42,
+			"err":        errors.New("timeout"),
+			"score":      "n/a",
+			"missing":    nil,
+		},
+	}
+	types := map[string]FieldType{
+		"latency_ms": FieldNumber,
+		"status":     FieldNumber,
+		"bytes":      FieldNumber,
+		"elapsed":    FieldNumber,
+		"cached":     FieldBool,
+		"retried":    FieldBool,
+		"user_id":    FieldString,
+		"err":        FieldString,
+		"score":      FieldNumber,
+		"missing":    FieldNumber,
+		"absent":     FieldNumber,
+	}
+	fields := logrus.Fields{"status": "201"}
+	FieldTypeEnricher(types).Enrich(entry, fields)
+
+	b, _ := json.Marshal(fields)
+	expected := `{"bytes":512,"cached":true,"elapsed":1000000000,"err":"timeout","latency_ms":12.5,"retried":false,` +
+		`"score":null,"status":201,"uncoerced_fields":{"score":"n/a"},"user_id":"42"}`
+	if string(b) != expected {
+		t.Errorf("unexpected fields:\n%s\nexpected:\n%s", b, expected)
+	}
+}
@@ -0,0 +1,477 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filterPattern is a parsed Amazon CloudWatch filter pattern, used by WithClientSideFilterPattern to drop the events
+// which do not match it before they are sent.
+type filterPattern interface {
+	match(msg string) bool
+}
+
+// parseFilterPattern parses the subset of the filter pattern syntax supported by the hook: terms, which match
+// unstructured messages, and JSON patterns enclosed in braces. Space-delimited patterns and regular expressions are
+// not supported.
+func parseFilterPattern(pattern string) (filterPattern, error) {
+	pattern = strings.TrimSpace(pattern)
+	switch {
+	case strings.HasPrefix(pattern, "{"):
+		if !strings.HasSuffix(pattern, "}") {
+			return nil, fmt.Errorf("JSON pattern must end with }")
+		}
+		return parseJSONPattern(pattern[1 : len(pattern)-1])
+	case strings.HasPrefix(pattern, "["):
+		return nil, fmt.Errorf("space-delimited patterns are not supported")
+	default:
+		return parseTermPattern(pattern)
+	}
+}
+
+// termPattern matches unstructured messages by the terms they hold. Terms are case-sensitive. Required terms match
+// wherever they appear in the message while excluded terms only match whole words, so the hook may keep an event
+// which the service would drop, but never drops one which it would keep.
+type termPattern struct {
+	all  []string
+	any  []string
+	none []string
+}
+
+// parseTermPattern parses terms separated by spaces, where a term holding spaces must be quoted. Every term must
+// appear, unless the terms are prefixed with ?, in which case any of them must; terms prefixed with - must not appear.
+func parseTermPattern(pattern string) (filterPattern, error) {
+	var p termPattern
+	for pattern != "" {
+		prefix := pattern[0]
+		if prefix == '?' || prefix == '-' {
+			pattern = pattern[1:]
+		}
+		var term string
+		if strings.HasPrefix(pattern, `"`) {
+			end := strings.Index(pattern[1:], `"`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted term")
+			}
+			term, pattern = pattern[1:end+1], pattern[end+2:]
+		} else {
+			end := strings.IndexAny(pattern, " \t")
+			if end < 0 {
+				end = len(pattern)
+			}
+			term, pattern = pattern[:end], pattern[end:]
+		}
+		if term == "" {
+			return nil, fmt.Errorf("empty term")
+		}
+		if strings.HasPrefix(term, "%") {
+			return nil, fmt.Errorf("regular expressions are not supported")
+		}
+		switch prefix {
+		case '?':
+			p.any = append(p.any, term)
+		case '-':
+			p.none = append(p.none, term)
+		default:
+			p.all = append(p.all, term)
+		}
+		pattern = strings.TrimLeft(pattern, " \t")
+	}
+	if len(p.all) > 0 && len(p.any) > 0 {
+		return nil, fmt.Errorf("terms prefixed with ? cannot be mixed with required terms")
+	}
+	return p, nil
+}
+
+func (p termPattern) match(msg string) bool {
+	for _, term := range p.all {
+		if !strings.Contains(msg, term) {
+			return false
+		}
+	}
+	for _, term := range p.none {
+		if containsWord(msg, term) {
+			return false
+		}
+	}
+	if len(p.any) == 0 {
+		return true
+	}
+	for _, term := range p.any {
+		if strings.Contains(msg, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether the term appears in the message on word boundaries, so that the term Exit is found in
+// "Exit now" but not in "Exiting".
+func containsWord(msg, term string) bool {
+	for offset := 0; ; {
+		i := strings.Index(msg[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before, _ := utf8.DecodeLastRuneInString(msg[:start])
+		after, _ := utf8.DecodeRuneInString(msg[end:])
+		first, _ := utf8.DecodeRuneInString(term)
+		last, _ := utf8.DecodeLastRuneInString(term)
+		if (start == 0 || !isWordRune(first) || !isWordRune(before)) &&
+			(end == len(msg) || !isWordRune(last) || !isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isWordRune reports whether the rune is part of a word.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// jsonPattern matches messages which are JSON objects by the values of their properties. Messages which are not JSON
+// objects never match.
+type jsonPattern struct {
+	expr jsonExpr
+}
+
+func (p jsonPattern) match(msg string) bool {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &doc); err != nil {
+		return false
+	}
+	return p.expr.eval(doc)
+}
+
+// jsonExpr is a condition of a JSON pattern.
+type jsonExpr interface {
+	eval(doc interface{}) bool
+}
+
+// jsonLogical joins two conditions with && or ||.
+type jsonLogical struct {
+	and         bool
+	left, right jsonExpr
+}
+
+func (e jsonLogical) eval(doc interface{}) bool {
+	if e.and {
+		return e.left.eval(doc) && e.right.eval(doc)
+	}
+	return e.left.eval(doc) || e.right.eval(doc)
+}
+
+// jsonComparison compares the value of a property with a string, which may hold * wildcards, or with a number. The
+// property must exist and hold a value of the same type for the comparison to match.
+type jsonComparison struct {
+	selector jsonSelector
+	op       string
+	text     string
+	number   float64
+	numeric  bool
+}
+
+func (e jsonComparison) eval(doc interface{}) bool {
+	value, ok := e.selector.lookup(doc)
+	if !ok {
+		return false
+	}
+	if e.numeric {
+		n, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		switch e.op {
+		case "=":
+			return n == e.number
+		case "!=":
+			return n != e.number
+		case "<":
+			return n < e.number
+		case "<=":
+			return n <= e.number
+		case ">":
+			return n > e.number
+		default:
+			return n >= e.number
+		}
+	}
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	return wildcardMatch(e.text, s) == (e.op == "=")
+}
+
+// wildcardMatch reports whether the string matches the pattern, where * matches any sequence of characters.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// jsonCheck tests whether a property is null, true or false, or does not exist.
+type jsonCheck struct {
+	selector jsonSelector
+	check    string
+}
+
+func (e jsonCheck) eval(doc interface{}) bool {
+	value, ok := e.selector.lookup(doc)
+	switch e.check {
+	case "NOT EXISTS":
+		return !ok
+	case "NULL":
+		return ok && value == nil
+	default:
+		return ok && value == (e.check == "TRUE")
+	}
+}
+
+// jsonSelector is the path to a property, made of object keys and array indexes.
+type jsonSelector []interface{}
+
+// parseJSONSelector parses a selector such as $.user.roles[0].
+func parseJSONSelector(s string) (jsonSelector, error) {
+	if !strings.HasPrefix(s, "$") || len(s) == 1 {
+		return nil, fmt.Errorf("invalid selector %q: must start with $ followed by a property", s)
+	}
+	var selector jsonSelector
+	for rest := s[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid selector %q: empty property name", s)
+			}
+			selector, rest = append(selector, rest[1:end+1]), rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid selector %q: missing ]", s)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid selector %q: array index must be a number", s)
+			}
+			selector, rest = append(selector, index), rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid selector %q: unexpected %q", s, rest[0])
+		}
+	}
+	return selector, nil
+}
+
+// lookup returns the value of the property, if it exists.
+func (s jsonSelector) lookup(doc interface{}) (interface{}, bool) {
+	for _, part := range s {
+		switch key := part.(type) {
+		case string:
+			object, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = object[key]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := doc.([]interface{})
+			if !ok || key >= len(array) {
+				return nil, false
+			}
+			doc = array[key]
+		}
+	}
+	return doc, true
+}
+
+// jsonParser parses the conditions of a JSON pattern, where && binds more tightly than ||.
+type jsonParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseJSONPattern parses the conditions between the braces of a JSON pattern.
+func parseJSONPattern(body string) (filterPattern, error) {
+	tokens, err := tokenizeJSONPattern(body)
+	if err != nil {
+		return nil, err
+	}
+	p := &jsonParser{tokens: tokens, pos: 0}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return jsonPattern{expr: expr}, nil
+}
+
+// tokenizeJSONPattern splits the conditions of a JSON pattern into selectors, operators, parentheses, keywords and
+// values. Quoted strings keep their quotes so they can be told apart from other values.
+func tokenizeJSONPattern(body string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(body[i:], "&&") || strings.HasPrefix(body[i:], "||") ||
+			strings.HasPrefix(body[i:], "!=") || strings.HasPrefix(body[i:], "<=") || strings.HasPrefix(body[i:], ">="):
+			tokens = append(tokens, body[i:i+2])
+			i += 2
+		case c == '=' || c == '<' || c == '>':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.Index(body[i+1:], `"`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted value")
+			}
+			tokens = append(tokens, body[i:i+end+2])
+			i += end + 2
+		default:
+			end := strings.IndexAny(body[i:], " \t()&|!=<>\"")
+			if end < 0 {
+				end = len(body) - i
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("unexpected %q", c)
+			}
+			tokens = append(tokens, body[i:i+end])
+			i += end
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("JSON pattern must hold a condition")
+	}
+	return tokens, nil
+}
+
+// next returns the next token, or an empty string at the end of the pattern.
+func (p *jsonParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+// peek returns the next token without consuming it.
+func (p *jsonParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *jsonParser) parseOr() (jsonExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.peek() == "||" {
+		p.next()
+		var right jsonExpr
+		if right, err = p.parseAnd(); err == nil {
+			left = jsonLogical{and: false, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *jsonParser) parseAnd() (jsonExpr, error) {
+	left, err := p.parseCondition()
+	for err == nil && p.peek() == "&&" {
+		p.next()
+		var right jsonExpr
+		if right, err = p.parseCondition(); err == nil {
+			left = jsonLogical{and: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseCondition parses a parenthesized group, a comparison or a check such as $.error IS NULL.
+func (p *jsonParser) parseCondition() (jsonExpr, error) {
+	token := p.next()
+	if token == "(" {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return expr, nil
+	}
+	selector, err := parseJSONSelector(token)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	switch op {
+	case "IS":
+		check := p.next()
+		if check != "NULL" && check != "TRUE" && check != "FALSE" {
+			return nil, fmt.Errorf("IS must be followed by NULL, TRUE or FALSE")
+		}
+		return jsonCheck{selector: selector, check: check}, nil
+	case "NOT":
+		if p.next() != "EXISTS" {
+			return nil, fmt.Errorf("NOT must be followed by EXISTS")
+		}
+		return jsonCheck{selector: selector, check: "NOT EXISTS"}, nil
+	case "=", "!=", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("expected an operator after %s, got %q", token, op)
+	}
+
+	value := p.next()
+	if value == "" || strings.ContainsAny(value, "()") || value == "&&" || value == "||" {
+		return nil, fmt.Errorf("expected a value after %s %s", token, op)
+	}
+	if strings.HasPrefix(value, `"`) {
+		value = value[1 : len(value)-1]
+	} else if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return jsonComparison{selector: selector, op: op, text: "", number: n, numeric: true}, nil
+	}
+	if op != "=" && op != "!=" {
+		return nil, fmt.Errorf("%s %s requires a number", token, op)
+	}
+	return jsonComparison{selector: selector, op: op, text: value, number: 0, numeric: false}, nil
+}
+
+// filtered determines whether or not the message is dropped by the client-side filter pattern, counting it if so.
+func (h *CloudWatchLogsHook) filtered(msg string) bool {
+	if h.patternFilter == nil || h.patternFilter.match(msg) {
+		return false
+	}
+	atomic.AddInt64(&h.stats.filteredOut, 1)
+	return true
+}
@@ -0,0 +1,134 @@
+package cloudwatchhook
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFilterPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		msg     string
+		match   bool
+	}{
+		{"", "anything", true},
+		{"ERROR", "level=ERROR msg=failed", true},
+		{"ERROR", "level=error msg=failed", false},
+		{`ERROR "connection refused"`, "ERROR dial: connection refused", true},
+		{`ERROR "connection refused"`, "ERROR dial: timeout", false},
+		{"ERROR -Exiting", "ERROR Exiting now", false},
+		{"ERROR -Exit", "ERROR Exiting now", true},
+		{"ERROR -Exit", "ERROR Exit now", false},
+		{"ERROR -Exit", "ERROR on Exit", false},
+		{"ERROR -Exit", "ERROR Exit_code=1", true},
+		{"ERROR -/health", "ERROR GET /health", false},
+		{"ERROR -/health", "ERROR GET /healthz", true},
+		{"?ERROR ?WARN", "WARN disk almost full", true},
+		{"?ERROR ?WARN", "INFO started", false},
+		{`{ $.level = "error" }`, `{"level":"error"}`, true},
+		{`{ $.level = "error" }`, `{"level":"info"}`, false},
+		{`{ $.level = "error" }`, "level=error", false},
+		{`{ $.path = /api/* }`, `{"path":"/api/v1/users"}`, true},
+		{`{ $.path != "/health*" }`, `{"path":"/healthz"}`, false},
+		{`{ $.latency > 500 && $.status = 200 }`, `{"latency":750,"status":200}`, true},
+		{`{ $.latency > 500 && $.status = 200 }`, `{"latency":250,"status":200}`, false},
+		{`{ ($.status >= 500 || $.retry IS TRUE) && $.user.roles[0] = "admin" }`,
+			`{"status":200,"retry":true,"user":{"roles":["admin"]}}`, true},
+		{`{ $.error IS NULL }`, `{"error":null}`, true},
+		{`{ $.error NOT EXISTS }`, `{"error":null}`, false},
+		{`{ $.error NOT EXISTS }`, `{"msg":"ok"}`, true},
+	}
+	for _, test := range tests {
+		p, err := parseFilterPattern(test.pattern)
+		if err != nil {
+			t.Errorf("parseFilterPattern(%q) failed: %v", test.pattern, err)
+			continue
+		}
+		if actual := p.match(test.msg); actual != test.match {
+			t.Errorf("pattern %q matching %q = %v, want %v", test.pattern, test.msg, actual, test.match)
+		}
+	}
+
+	for _, pattern := range []string{
+		"[ip, user, status]",
+		"%ERROR|WARN%",
+		"ERROR ?WARN",
+		`"unterminated`,
+		`{ $.level = "error"`,
+		`{ level = "error" }`,
+		`{ $.latency > slow }`,
+		`{ $.level = "error" && }`,
+		`{ ($.level = "error" }`,
+		`{ $.roles[x] = "admin" }`,
+	} {
+		if _, err := parseFilterPattern(pattern); err == nil {
+			t.Errorf("expected pattern %q to be rejected", pattern)
+		}
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"a*c*e", "abcde", true},
+		{"a*a", "a", false},
+		{"*", "", true},
+	}
+	for _, test := range tests {
+		if actual := wildcardMatch(test.pattern, test.s); actual != test.match {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", test.pattern, test.s, actual, test.match)
+		}
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	h := newTestHook(t, "group", "stream", WithClientSideFilterPattern("?ERROR ?WARN"))
+	for _, msg := range []string{"ERROR failed", "INFO started", "DEBUG polling"} {
+		h.filtered(msg)
+	}
+	if n := h.Stats().FilteredOut; n != 2 {
+		t.Errorf("expected 2 messages to be filtered out, got %d", n)
+	}
+}
+
+func TestClientSideFilterPattern(t *testing.T) {
+	transport := &testTransport{}
+	h, err := NewCloudWatchLogsHook(aws.Config{}, "group", "stream", WithTransport(transport),
+		WithClientSideFilterPattern("ERROR -Exit"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(h)
+	logger.Error("ERROR Exiting")
+	logger.Error("ERROR Exit now")
+	logger.Info("started")
+	if _, err := h.Write([]byte("ERROR disk full")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := h.Write([]byte("INFO polling")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transport.batches) != 2 || transport.batches[1][0].Message != "ERROR disk full" {
+		t.Errorf("expected only the matching messages to be sent, got %v", transport.batches)
+	}
+	if n := h.Stats().FilteredOut; n != 3 {
+		t.Errorf("expected 3 messages to be filtered out, got %d", n)
+	}
+
+	if _, err := NewCloudWatchLogsHook(aws.Config{}, "group", "stream", WithTransport(transport),
+		WithClientSideFilterPattern("%ERROR%")); err == nil {
+		t.Errorf("expected an unsupported pattern to be rejected")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build boringcrypto || goexperiment.boringcrypto
+// +build boringcrypto goexperiment.boringcrypto
+
+package cloudwatchhook
+
+// fipsMode reports true since the binary was built with the BoringCrypto module.
+func fipsMode() bool {
+	return true
+}
@@ -0,0 +1,9 @@
+//go:build !go1.24 && !boringcrypto && !goexperiment.boringcrypto
+// +build !go1.24,!boringcrypto,!goexperiment.boringcrypto
+
+package cloudwatchhook
+
+// fipsMode reports false since FIPS 140-3 mode is only available from Go 1.24 or with the BoringCrypto module.
+func fipsMode() bool {
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build go1.24 && !boringcrypto && !goexperiment.boringcrypto
+// +build go1.24,!boringcrypto,!goexperiment.boringcrypto
+
+package cloudwatchhook
+
+import "crypto/fips140"
+
+// fipsMode reports whether the cryptography libraries are operating in FIPS 140-3 mode.
+func fipsMode() bool {
+	return fips140.Enabled()
+}
@@ -0,0 +1,57 @@
+//go:build !cloudwatchhook_nocrypto
+// +build !cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestFIPSCrypto exercises every use of cryptography by the hook. Run it with a FIPS toolchain to check that the hook
+// works with it, for example:
+//
+//	GODEBUG=fips140=only go test -run FIPS .
+//	GOEXPERIMENT=boringcrypto go test -run FIPS .
+func TestFIPSCrypto(t *testing.T) {
+	t.Logf("FIPS mode: %v", fipsMode())
+
+	h := newTestHook(t, "group", "stream", WithBatchEncryption(testDataKeys{}))
+	sealed, err := h.seal([]types.InputLogEvent{{Message: aws.String("secret"), Timestamp: aws.Int64(1000)}})
+	if err != nil {
+		t.Fatalf("unable to encrypt batch: %v", err)
+	}
+	opened, err := OpenEnvelope(context.TODO(), testDataKeys{}, aws.ToString(sealed[0].Message))
+	if err != nil || len(opened) != 1 || opened[0].Message != "secret" {
+		t.Fatalf("unable to decrypt batch: %v", err)
+	}
+
+	if a, b := NewULID(), NewULID(); a == b {
+		t.Errorf("expected unique IDs, got %s twice", a)
+	}
+	now := time.Now()
+	if a, b := archiveKey("", "group", "stream", now), archiveKey("", "group", "stream", now); a == b {
+		t.Errorf("expected unique archive keys, got %s twice", a)
+	}
+}
+
+func TestSealRejectsShortDataKeys(t *testing.T) {
+	h := newTestHook(t, "group", "stream", WithBatchEncryption(shortDataKeys{}))
+	events := []types.InputLogEvent{{Message: aws.String("secret"), Timestamp: aws.Int64(1000)}}
+	if _, err := h.seal(events); err == nil {
+		t.Errorf("expected a 128-bit data key to be rejected")
+	}
+}
+
+// shortDataKeys is a DataKeyProvider which returns 128-bit data keys.
+type shortDataKeys struct {
+	testDataKeys
+}
+
+func (shortDataKeys) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	return testDataKey[:16], testDataKey[:16], nil
+}
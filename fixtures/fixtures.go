@@ -0,0 +1,122 @@
+// Package fixtures records the interactions of the hook with Amazon CloudWatch Logs to golden files and replays them,
+// so that the behavior of the hook in situations which are hard to reproduce, such as throttling and sequence token
+// errors, can be tested without calling AWS. The fixtures shipped in the testdata directory cover the most common of
+// these situations and can be used as examples for new ones.
+package fixtures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// Fixture is a sequence of interactions with Amazon CloudWatch Logs.
+type Fixture struct {
+	// Description says what the fixture covers.
+	Description string `json:"description"`
+
+	// Interactions holds the calls made, in the order they were made.
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single call to Amazon CloudWatch Logs along with its outcome.
+type Interaction struct {
+	// Operation is the name of the API operation called, such as PutLogEvents.
+	Operation string `json:"operation"`
+
+	// Request holds the input of the call. When replaying, only the fields present are compared, so that fields such
+	// as event timestamps which differ between runs can be left out.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// Response holds the output of a successful call.
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Error describes the error returned by a failed call.
+	Error *Error `json:"error,omitempty"`
+}
+
+// Error describes an error returned by Amazon CloudWatch Logs.
+type Error struct {
+	Code                  string `json:"code"`
+	Message               string `json:"message,omitempty"`
+	ExpectedSequenceToken string `json:"expected_sequence_token,omitempty"`
+}
+
+// Load reads a fixture from the JSON file at the given path.
+func Load(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read fixture: %v", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("Unable to parse fixture %s: %v", path, err)
+	}
+	return &fixture, nil
+}
+
+// Save writes the fixture as indented JSON to the file at the given path.
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Unable to encode fixture: %v", err)
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("Unable to write fixture: %v", err)
+	}
+	return nil
+}
+
+// newError describes an error returned by a call.
+func newError(err error) *Error {
+	recorded := &Error{Code: "UnknownError", Message: err.Error()}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		recorded.Code = apiErr.ErrorCode()
+		recorded.Message = apiErr.ErrorMessage()
+	}
+	var tokenErr *types.InvalidSequenceTokenException
+	var acceptedErr *types.DataAlreadyAcceptedException
+	if errors.As(err, &tokenErr) {
+		recorded.ExpectedSequenceToken = aws.ToString(tokenErr.ExpectedSequenceToken)
+	} else if errors.As(err, &acceptedErr) {
+		recorded.ExpectedSequenceToken = aws.ToString(acceptedErr.ExpectedSequenceToken)
+	}
+	return recorded
+}
+
+// Err returns the error the SDK would have returned, using the typed exceptions of the Amazon CloudWatch Logs client
+// where there is one for the code.
+func (e *Error) Err() error {
+	message := aws.String(e.Message)
+	var token *string
+	if e.ExpectedSequenceToken != "" {
+		token = aws.String(e.ExpectedSequenceToken)
+	}
+	switch e.Code {
+	case "InvalidSequenceTokenException":
+		return &types.InvalidSequenceTokenException{Message: message, ExpectedSequenceToken: token}
+	case "DataAlreadyAcceptedException":
+		return &types.DataAlreadyAcceptedException{Message: message, ExpectedSequenceToken: token}
+	case "ResourceAlreadyExistsException":
+		return &types.ResourceAlreadyExistsException{Message: message}
+	case "ResourceNotFoundException":
+		return &types.ResourceNotFoundException{Message: message}
+	case "InvalidParameterException":
+		return &types.InvalidParameterException{Message: message}
+	case "ServiceUnavailableException":
+		return &types.ServiceUnavailableException{Message: message}
+	case "LimitExceededException":
+		return &types.LimitExceededException{Message: message}
+	case "OperationAbortedException":
+		return &types.OperationAbortedException{Message: message}
+	case "UnrecognizedClientException":
+		return &types.UnrecognizedClientException{Message: message}
+	}
+	return &smithy.GenericAPIError{Code: e.Code, Message: e.Message}
+}
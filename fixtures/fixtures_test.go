@@ -0,0 +1,76 @@
+package fixtures_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/fixtures"
+)
+
+func TestReplay(t *testing.T) {
+	for _, name := range []string{"existing_resources", "create_resources", "throttling", "invalid_sequence_token"} {
+		t.Run(name, func(t *testing.T) {
+			fixture, err := fixtures.Load(filepath.Join("testdata", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := fixtures.NewReplayer(fixture)
+			hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/payments", "web-1",
+				cloudwatchhook.WithClient(client), cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{
+					MaxRetries: 3,
+				}))
+			if err != nil {
+				t.Fatalf("unable to create hook: %v", err)
+			}
+			if _, err := hook.Write([]byte("replayed")); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err := hook.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err := client.Err(); err != nil {
+				t.Errorf("hook did not follow the fixture: %v", err)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	recorder := fixtures.NewRecorder(chaos.NewClient(chaos.Faults{}), "recorded from the chaos client")
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(recorder))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	hook.Write([]byte("recorded"))
+	hook.Close()
+
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	if err := recorder.Fixture().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := fixtures.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fixtures.NewReplayer(fixture)
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	hook.Write([]byte("recorded"))
+	hook.Close()
+	if err := client.Err(); err != nil {
+		t.Errorf("replay did not match the recording: %v", err)
+	}
+
+	// a different group does not match the recording
+	client = fixtures.NewReplayer(fixture)
+	cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "other", "stream", cloudwatchhook.WithClient(client))
+	if client.Err() == nil {
+		t.Errorf("expected a mismatch")
+	}
+}
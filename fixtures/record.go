@@ -0,0 +1,134 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+)
+
+// Recorder is an implementation of the Amazon CloudWatch Logs API used by the hook which passes each call on to
+// another client, usually a real one, and records it. It is safe for concurrent use as long as the client is.
+type Recorder struct {
+	client  cloudwatchhook.CloudWatchLogsAPI
+	mutex   sync.Mutex
+	fixture Fixture
+}
+
+// NewRecorder creates a new client which records the calls made to the given client in a fixture with the given
+// description.
+func NewRecorder(client cloudwatchhook.CloudWatchLogsAPI, description string) *Recorder {
+	return &Recorder{
+		client:  client,
+		fixture: Fixture{Description: description},
+	}
+}
+
+// Fixture returns the interactions recorded so far. Use its Save method to write it to a golden file.
+func (r *Recorder) Fixture() *Fixture {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	fixture := r.fixture
+	fixture.Interactions = append([]Interaction(nil), r.fixture.Interactions...)
+	return &fixture
+}
+
+// record adds a call to the fixture.
+func (r *Recorder) record(operation string, params interface{}, output interface{}, err error) {
+	interaction := Interaction{Operation: operation}
+	if input, ok := params.(*cloudwatchlogs.PutLogEventsInput); ok {
+		params = withoutTimestamps(input)
+	}
+	interaction.Request, _ = json.Marshal(params)
+	if err != nil {
+		interaction.Error = newError(err)
+	} else {
+		interaction.Response, _ = json.Marshal(output)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+}
+
+// withoutTimestamps copies the input, leaving out the timestamps of the events, which differ between runs, so that they
+// are not compared when replaying.
+func withoutTimestamps(input *cloudwatchlogs.PutLogEventsInput) interface{} {
+	type event struct {
+		Message *string
+	}
+	events := make([]event, len(input.LogEvents))
+	for i, logEvent := range input.LogEvents {
+		events[i] = event{Message: logEvent.Message}
+	}
+	return struct {
+		LogGroupName  *string
+		LogStreamName *string
+		SequenceToken *string
+		LogEvents     []event
+	}{input.LogGroupName, input.LogStreamName, input.SequenceToken, events}
+}
+
+// CreateLogGroup records a CreateLogGroup call.
+func (r *Recorder) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+
+	output, err := r.client.CreateLogGroup(ctx, params, optFns...)
+	r.record("CreateLogGroup", params, output, err)
+	return output, err
+}
+
+// CreateLogStream records a CreateLogStream call.
+func (r *Recorder) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+
+	output, err := r.client.CreateLogStream(ctx, params, optFns...)
+	r.record("CreateLogStream", params, output, err)
+	return output, err
+}
+
+// DescribeLogGroups records a DescribeLogGroups call.
+func (r *Recorder) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+
+	output, err := r.client.DescribeLogGroups(ctx, params, optFns...)
+	r.record("DescribeLogGroups", params, output, err)
+	return output, err
+}
+
+// DescribeLogStreams records a DescribeLogStreams call.
+func (r *Recorder) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	output, err := r.client.DescribeLogStreams(ctx, params, optFns...)
+	r.record("DescribeLogStreams", params, output, err)
+	return output, err
+}
+
+// PutLogEvents records a PutLogEvents call.
+func (r *Recorder) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	output, err := r.client.PutLogEvents(ctx, params, optFns...)
+	r.record("PutLogEvents", params, output, err)
+	return output, err
+}
+
+// PutRetentionPolicy records a PutRetentionPolicy call.
+func (r *Recorder) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+
+	output, err := r.client.PutRetentionPolicy(ctx, params, optFns...)
+	r.record("PutRetentionPolicy", params, output, err)
+	return output, err
+}
+
+// DeleteRetentionPolicy records a DeleteRetentionPolicy call.
+func (r *Recorder) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+
+	output, err := r.client.DeleteRetentionPolicy(ctx, params, optFns...)
+	r.record("DeleteRetentionPolicy", params, output, err)
+	return output, err
+}
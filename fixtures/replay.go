@@ -0,0 +1,208 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// Replayer is an implementation of the Amazon CloudWatch Logs API used by the hook which replays the interactions of a
+// fixture in order. A call which does not match the next interaction fails, and the mismatch is reported by Err. It is
+// safe for concurrent use.
+type Replayer struct {
+	mutex   sync.Mutex
+	fixture *Fixture
+	next    int
+	err     error
+}
+
+// NewReplayer creates a new client which replays the fixture.
+func NewReplayer(fixture *Fixture) *Replayer {
+	return &Replayer{fixture: fixture}
+}
+
+// Err returns the first call which did not match the fixture or, if every call matched, an error if any interactions
+// were not replayed.
+func (r *Replayer) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if remaining := len(r.fixture.Interactions) - r.next; remaining > 0 {
+		return fmt.Errorf("%d interactions were not replayed, starting with %s", remaining,
+			r.fixture.Interactions[r.next].Operation)
+	}
+	return nil
+}
+
+// replay matches the call against the next interaction and decodes its response into the output.
+func (r *Replayer) replay(operation string, params interface{}, output interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.next >= len(r.fixture.Interactions) {
+		return r.mismatch(fmt.Errorf("Unexpected %s call after the last interaction", operation))
+	}
+	interaction := r.fixture.Interactions[r.next]
+	r.next++
+	if interaction.Operation != operation {
+		return r.mismatch(fmt.Errorf("Unexpected %s call, expected %s (interaction %d)", operation,
+			interaction.Operation, r.next))
+	}
+	if err := matchRequest(interaction.Request, params); err != nil {
+		return r.mismatch(fmt.Errorf("Unexpected %s request (interaction %d): %v", operation, r.next, err))
+	}
+	if interaction.Error != nil {
+		return interaction.Error.Err()
+	}
+	if len(interaction.Response) > 0 {
+		if err := json.Unmarshal(interaction.Response, output); err != nil {
+			return r.mismatch(fmt.Errorf("Unable to decode %s response (interaction %d): %v", operation, r.next, err))
+		}
+	}
+	return nil
+}
+
+// mismatch records the first call which did not match the fixture and returns the error. The caller must hold the
+// mutex.
+func (r *Replayer) mismatch(err error) error {
+	if r.err == nil {
+		r.err = err
+	}
+	return err
+}
+
+// matchRequest compares the fields present in the recorded request with those of the actual request.
+func matchRequest(recorded json.RawMessage, params interface{}) error {
+	if len(recorded) == 0 {
+		return nil
+	}
+	var expected, actual map[string]interface{}
+	if err := json.Unmarshal(recorded, &expected); err != nil {
+		return err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &actual); err != nil {
+		return err
+	}
+	for field, value := range expected {
+		if !matchValue(value, actual[field]) {
+			return fmt.Errorf("%s is %v, expected %v", field, actual[field], value)
+		}
+	}
+	return nil
+}
+
+// matchValue compares a recorded value with an actual one. Objects, including those in arrays, only need to match the
+// fields present in the recorded object.
+func matchValue(expected, actual interface{}) bool {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		actual, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for field, value := range expected {
+			if !matchValue(value, actual[field]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actual, ok := actual.([]interface{})
+		if !ok || len(actual) != len(expected) {
+			return false
+		}
+		for i := range expected {
+			if !matchValue(expected[i], actual[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// CreateLogGroup replays a CreateLogGroup call.
+func (r *Replayer) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+
+	output := &cloudwatchlogs.CreateLogGroupOutput{}
+	if err := r.replay("CreateLogGroup", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// CreateLogStream replays a CreateLogStream call.
+func (r *Replayer) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+
+	output := &cloudwatchlogs.CreateLogStreamOutput{}
+	if err := r.replay("CreateLogStream", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// DescribeLogGroups replays a DescribeLogGroups call.
+func (r *Replayer) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+
+	output := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	if err := r.replay("DescribeLogGroups", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// DescribeLogStreams replays a DescribeLogStreams call.
+func (r *Replayer) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	output := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	if err := r.replay("DescribeLogStreams", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// PutLogEvents replays a PutLogEvents call.
+func (r *Replayer) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	output := &cloudwatchlogs.PutLogEventsOutput{}
+	if err := r.replay("PutLogEvents", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// PutRetentionPolicy replays a PutRetentionPolicy call.
+func (r *Replayer) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+
+	output := &cloudwatchlogs.PutRetentionPolicyOutput{}
+	if err := r.replay("PutRetentionPolicy", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// DeleteRetentionPolicy replays a DeleteRetentionPolicy call.
+func (r *Replayer) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+
+	output := &cloudwatchlogs.DeleteRetentionPolicyOutput{}
+	if err := r.replay("DeleteRetentionPolicy", params, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,164 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DroppedFieldsField is the name of the field holding the number of fields dropped by a Flattener once it reached
+	// its maximum number of keys.
+	DroppedFieldsField = "dropped_fields"
+
+	// defaultFlattenDepth is the number of levels of nesting flattened unless the Flattener sets MaxDepth.
+	defaultFlattenDepth = 5
+
+	// defaultFlattenKeys is the number of fields kept unless the Flattener sets MaxKeys.
+	defaultFlattenKeys = 200
+)
+
+// Flattener converts nested maps and structs in the fields of an entry into fields with joined keys, such as
+// http.request.method, so that CloudWatch Logs Insights can query them directly. Structs are flattened in their JSON
+// form. Characters other than letters, digits, underscores and @ in keys are replaced with underscores.
+type Flattener struct {
+	// Separator joins the keys of nested values. It defaults to ".".
+	Separator string
+
+	// MaxDepth is the number of levels of nesting flattened, 5 by default. Values nested deeper are kept as JSON text.
+	MaxDepth int
+
+	// MaxKeys is the maximum number of fields kept, 200 by default. The fields beyond it, in order of their keys, are
+	// dropped and counted in the dropped_fields field.
+	MaxKeys int
+}
+
+// Flatten returns the flattened fields. The fields given are not changed.
+func (f Flattener) Flatten(data logrus.Fields) logrus.Fields {
+	if f.Separator == "" {
+		f.Separator = "."
+	}
+	if f.MaxDepth <= 0 {
+		f.MaxDepth = defaultFlattenDepth
+	}
+	if f.MaxKeys <= 0 {
+		f.MaxKeys = defaultFlattenKeys
+	}
+	flat := map[string]interface{}{}
+	for k, v := range data {
+		f.flatten(flat, sanitizeKey(k, f.Separator), v, 1)
+	}
+	if len(flat) <= f.MaxKeys {
+		return flat
+	}
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys[f.MaxKeys:] {
+		delete(flat, k)
+	}
+	flat[DroppedFieldsField] = len(keys) - f.MaxKeys
+	return flat
+}
+
+// flatten adds the value to the flattened fields under the key, expanding nested values up to the maximum depth. The
+// first value for a key is kept if sanitizing makes two keys the same. The defaults must have been applied.
+func (f Flattener) flatten(flat map[string]interface{}, key string, v interface{}, depth int) {
+	nested, ok := nestedFields(v)
+	if !ok || len(nested) == 0 {
+		if _, exists := flat[key]; !exists {
+			flat[key] = v
+		}
+		return
+	}
+	if depth > f.MaxDepth {
+		if _, exists := flat[key]; !exists {
+			flat[key] = fieldText(v)
+		}
+		return
+	}
+	for k, nestedValue := range nested {
+		f.flatten(flat, key+f.Separator+sanitizeKey(k, f.Separator), nestedValue, depth+1)
+	}
+}
+
+// nestedFields returns the fields of a map or struct value, which are read from its JSON form unless it is a map of
+// strings to values already. Errors are not expanded, since they are rendered as their message.
+func nestedFields(v interface{}) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case logrus.Fields:
+		return v, true
+	case error, json.Marshaler:
+		return nil, false
+	}
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Map && value.Kind() != reflect.Struct {
+		return nil, false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// sanitizeKey replaces the characters of the key which CloudWatch Logs Insights does not allow in field names without
+// quoting them, other than the separator, with underscores.
+func sanitizeKey(key, separator string) string {
+	if key == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		if strings.HasPrefix(key[i:], separator) {
+			b.WriteString(separator)
+			i += len(separator)
+			continue
+		}
+		c := key[i]
+		if c == '_' || c == '@' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else if utf8.RuneStart(c) {
+			// a single underscore for each character, however many bytes it takes
+			b.WriteByte('_')
+		}
+		i++
+	}
+	return b.String()
+}
+
+// FlatteningCodec flattens the nested fields of each entry with its Flattener before encoding it with Codec, which
+// defaults to FormatterCodec.
+type FlatteningCodec struct {
+	Flattener Flattener
+	Codec     Codec
+}
+
+// Encode flattens the fields of a copy of the entry and encodes it.
+func (c FlatteningCodec) Encode(entry *logrus.Entry) ([]byte, error) {
+	e := *entry
+	e.Data = c.Flattener.Flatten(entry.Data)
+	codec := c.Codec
+	if codec == nil {
+		codec = FormatterCodec{}
+	}
+	return codec.Encode(&e)
+}
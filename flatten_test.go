@@ -0,0 +1,47 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFlattener(t *testing.T) {
+	type request struct {
+		Method  string            `json:"method"`
+		Headers map[string]string `json:"headers"`
+	}
+	data := logrus.Fields{
+		"http": map[string]interface{}{
+			"request": request{Method: "GET", Headers: map[string]string{"X-Request-ID": "abc"}},
+			"status":  200,
+		},
+		"user name": "alice",
+		"err":       errors.New("timeout"),
+		"deep":      map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}},
+		"empty":     map[string]interface{}{},
+	}
+	flat := Flattener{MaxDepth: 2}.Flatten(data)
+
+	b, _ := json.Marshal(flat)
+	expected := `{"deep.a.b":"{\"c\":1}","empty":{},"err":{},"http.request.headers":"{\"X-Request-ID\":\"abc\"}",` +
+		`"http.request.method":"GET","http.status":200,"user_name":"alice"}`
+	if string(b) != expected {
+		t.Errorf("unexpected fields:\n%s\nexpected:\n%s", b, expected)
+	}
+	if id := (Flattener{}).Flatten(data)["http.request.headers.X_Request_ID"]; id != "abc" {
+		t.Errorf("expected the nested key to be sanitized, got %v", id)
+	}
+	if _, ok := data["user name"]; !ok {
+		t.Errorf("expected the original fields to be left alone")
+	}
+
+	flat = Flattener{Separator: "_", MaxKeys: 2}.Flatten(data)
+	b, _ = json.Marshal(flat)
+	expected = `{"deep_a_b_c":1,"dropped_fields":5,"empty":{}}`
+	if string(b) != expected {
+		t.Errorf("unexpected capped fields:\n%s\nexpected:\n%s", b, expected)
+	}
+}
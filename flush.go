@@ -0,0 +1,20 @@
+package cloudwatchhook
+
+import (
+	"time"
+)
+
+// nextFlush returns how long to wait from the given time until the next batch is flushed. Without jitter or alignment,
+// batches are flushed every batch duration. When many replicas start at the same time, aligning flushes to multiples
+// of the batch duration on the wall clock and adding a random delay spreads their API calls out rather than having
+// them arrive in synchronized bursts.
+func (h *CloudWatchLogsHook) nextFlush(now time.Time) time.Duration {
+	wait := h.batchDuration()
+	if h.alignedFlush {
+		wait = now.Truncate(wait).Add(wait).Sub(now)
+	}
+	if h.batchJitter > 0 {
+		wait += randDuration(h.batchJitter)
+	}
+	return wait
+}
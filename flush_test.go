@@ -0,0 +1,152 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestNextFlush(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, int(300*time.Millisecond), time.UTC)
+	h := newTestHook(t, "group", "stream", WithBatchDuration(time.Second))
+	if wait := h.nextFlush(now); wait != time.Second {
+		t.Errorf("expected to wait 1s, got %v", wait)
+	}
+
+	h.alignedFlush = true
+	if wait := h.nextFlush(now); wait != 700*time.Millisecond {
+		t.Errorf("expected to wait until the next whole second, got %v", wait)
+	}
+
+	h.batchJitter = 200 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if wait := h.nextFlush(now); wait < 700*time.Millisecond || wait >= 900*time.Millisecond {
+			t.Fatalf("expected to wait between 700ms and 900ms, got %v", wait)
+		}
+	}
+}
+
+func TestBatchSet(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	batches := newBatchSet(0)
+	late := batches.add(streamTarget{group: "group", stream: "late"}, 0, start.Add(2*time.Second))
+	early := batches.add(streamTarget{group: "group", stream: "early"}, 0, start.Add(time.Second))
+	if next, ok := batches.next(); !ok || !next.Equal(early.deadline) {
+		t.Errorf("expected the earliest deadline to be next, got %v", next)
+	}
+	if due := batches.expired(start.Add(time.Second)); len(due) != 1 || due[0] != early {
+		t.Errorf("expected only the early batch to be due, got %v", due)
+	}
+	late.size = MaxBatchBytes - 100
+	if !late.fits(100, 0) || late.fits(101, 0) {
+		t.Errorf("expected the batch to hold events up to the PutLogEvents limit")
+	}
+	late.size, late.events = 0, append(late.events, types.InputLogEvent{})
+	span := int64(MaxBatchSpan / time.Millisecond)
+	if !late.fits(1, span-1) || late.fits(1, span) {
+		t.Errorf("expected the batch to hold events spanning less than 24 hours")
+	}
+	if due := batches.expired(time.Time{}); len(due) != 1 || due[0] != late {
+		t.Errorf("expected the remaining batch to be returned, got %v", due)
+	}
+	if _, ok := batches.next(); ok {
+		t.Errorf("expected no batches to be left")
+	}
+}
+
+func TestBatchSetClosedBuckets(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	bucket := start.UnixNano() / int64(time.Millisecond)
+	target := streamTarget{group: "group", stream: "stream"}
+	batches := newBatchSet(time.Minute)
+
+	// a bucket sent early when draining is left open
+	batches.add(target, bucket, start.Add(time.Minute))
+	batches.expired(time.Time{})
+	if batches.late(target, bucket) {
+		t.Errorf("expected a bucket drained before it ended to be left open")
+	}
+
+	// as is one whose batch is due before the bucket ends
+	batches.add(target, bucket, start.Add(30*time.Second))
+	batches.expired(start.Add(30 * time.Second))
+	if batches.late(target, bucket) {
+		t.Errorf("expected a bucket sent before it ended to be left open")
+	}
+
+	batches.add(target, bucket, start.Add(time.Minute))
+	batches.expired(start.Add(time.Minute))
+	if !batches.late(target, bucket) {
+		t.Errorf("expected a bucket sent once it ended to be closed")
+	}
+}
+
+func TestSpanSlices(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	var events []types.InputLogEvent
+	for _, ts := range []int64{0, 10 * hour, 24*hour - 1, 24 * hour, 47 * hour, 50 * hour} {
+		events = append(events, newEvent("event", ts))
+	}
+	var sizes []int
+	for _, slice := range spanSlices(events) {
+		sizes = append(sizes, len(slice))
+		span := aws.ToInt64(slice[len(slice)-1].Timestamp) - aws.ToInt64(slice[0].Timestamp)
+		if span >= int64(MaxBatchSpan/time.Millisecond) {
+			t.Errorf("expected each slice to span less than 24 hours, got %dh", span/hour)
+		}
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("expected slices of 3, 2 and 1 events, got %v", sizes)
+	}
+}
+
+func TestBatchAdapter(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := newBatchAdapter(2, 250*time.Millisecond, time.Second)
+	a.check(now)
+
+	// the batch duration is halved after every two intervals in which more events were queued than sent
+	var durations []time.Duration
+	for i := 0; i < 6; i++ {
+		now = now.Add(a.duration())
+		a.queued, a.sent = 100, 10
+		if duration, changed := a.check(now); changed {
+			durations = append(durations, duration)
+		}
+	}
+	if len(durations) != 2 || durations[0] != 500*time.Millisecond || durations[1] != 250*time.Millisecond {
+		t.Errorf("expected the batch duration to be shortened down to the minimum, got %v", durations)
+	}
+
+	// and restored once sending keeps up
+	for i := 0; i < 4; i++ {
+		now = now.Add(a.duration())
+		a.queued, a.sent = 10, 10
+		a.check(now)
+	}
+	if a.duration() != time.Second || a.adaptations != 4 {
+		t.Errorf("expected the batch duration to be restored after 4 adaptations, got %v after %d", a.duration(),
+			a.adaptations)
+	}
+}
+
+func TestMarkLate(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"fourth", "fourth late=true"},
+		{"level=info late=false msg=fourth", "level=info late=false msg=fourth"},
+		{`{"msg":"third"}`, `{"late":true,"msg":"third"}`},
+		{`{}`, `{"late":true}`},
+		{`{"late":false,"msg":"third"}`, `{"late":false,"msg":"third"}`},
+		{`{"lateness":1}`, `{"late":true,"lateness":1}`},
+	}
+	for _, test := range tests {
+		if actual := markLate(test.msg); actual != test.want {
+			t.Errorf("markLate(%q) = %q, want %q", test.msg, actual, test.want)
+		}
+	}
+}
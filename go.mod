@@ -5,6 +5,10 @@ go 1.16
 require (
 	github.com/aws/aws-sdk-go-v2 v1.2.0
 	github.com/aws/aws-sdk-go-v2/config v1.1.1
+	github.com/aws/aws-sdk-go-v2/credentials v1.1.1
+	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.0.2
 	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.1.1
+	github.com/aws/aws-sdk-go-v2/service/sts v1.1.1
+	github.com/aws/smithy-go v1.1.0
 	github.com/sirupsen/logrus v1.8.0
 )
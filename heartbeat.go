@@ -0,0 +1,51 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// heartbeatEvent is the structured event periodically emitted when the hook is created with the WithHeartbeat
+// option.
+type heartbeatEvent struct {
+	Message   string        `json:"msg"`
+	Level     string        `json:"level"`
+	Time      string        `json:"time"`
+	Heartbeat heartbeatInfo `json:"heartbeat"`
+}
+
+// heartbeatInfo holds the details of a single heartbeat.
+type heartbeatInfo struct {
+	Sequence int64  `json:"seq"`
+	Interval string `json:"interval"`
+}
+
+// heartbeat emits a heartbeat event at the given interval until the hook is closed.
+func (h *CloudWatchLogsHook) heartbeat(interval time.Duration) {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(interval)
+	defer timer.Stop()
+	for seq := int64(1); ; seq++ {
+		select {
+		case <-h.done:
+			return
+		case t := <-timer.C():
+			timer.Reset(interval)
+			line, err := json.Marshal(heartbeatEvent{
+				Message: "heartbeat",
+				Level:   "info",
+				Time:    t.Format(time.RFC3339),
+				Heartbeat: heartbeatInfo{
+					Sequence: seq,
+					Interval: interval.String(),
+				},
+			})
+			if err != nil {
+				continue
+			}
+
+			// failures are deliberately ignored; the absence of the heartbeat is the signal
+			h.Write(line)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cloudwatchhook_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestHeartbeat(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Second),
+		cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithHeartbeat(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+
+	// a heartbeat is emitted each time the interval passes, however quiet the application is
+	for i := 0; i < 100 && len(client.Events("group", "stream")) < 2; i++ {
+		clock.Advance(30 * time.Second)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	events := client.Events("group", "stream")
+	if len(events) < 2 {
+		t.Fatalf("expected at least 2 heartbeats, got %d", len(events))
+	}
+	for i, event := range events[:2] {
+		var heartbeat struct {
+			Message   string `json:"msg"`
+			Heartbeat struct {
+				Sequence int64  `json:"seq"`
+				Interval string `json:"interval"`
+			} `json:"heartbeat"`
+		}
+		if err := json.Unmarshal([]byte(aws.ToString(event.Message)), &heartbeat); err != nil {
+			t.Fatalf("invalid heartbeat %q: %v", aws.ToString(event.Message), err)
+		}
+		if heartbeat.Message != "heartbeat" || heartbeat.Heartbeat.Sequence != int64(i+1) ||
+			heartbeat.Heartbeat.Interval != "1m0s" {
+			t.Errorf("unexpected heartbeat %q", aws.ToString(event.Message))
+		}
+	}
+
+	// closing the hook stops the heartbeat and rejects further messages
+	clock.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if n := len(client.Events("group", "stream")); n != len(events) {
+		t.Errorf("expected no heartbeats once the hook is closed, got %d more", n-len(events))
+	}
+	if _, err := hook.Write([]byte("late")); err != cloudwatchhook.ErrClosed {
+		t.Errorf("expected ErrClosed writing to a closed hook, got %v", err)
+	}
+}
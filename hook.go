@@ -2,117 +2,1358 @@ package cloudwatchhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrClosed is returned when writing to a hook which has been closed.
+var ErrClosed = errors.New("Hook has been closed")
+
+const (
+	// defaultQueueCapacity is the number of events the batch queue holds unless the WithQueueCapacity option is given.
+	defaultQueueCapacity = 10000
+
+	// maxQueueCapacity bounds the batch queue, whose slots are allocated up front.
+	maxQueueCapacity = 10000000
+)
+
 // CloudWatchLogsHook is used to store configuration settings for and log messages to Amazon CloudWatch.
 type CloudWatchLogsHook struct {
+	hookOptions
+
 	// required fields
-	client            *cloudwatchlogs.Client
 	group             string
 	stream            string
 	nextSequenceToken *string
 
-	// options
-	retentionDays int32
-	kmsKeyID      string
-	tags          map[string]string
-	logFrequency  time.Duration
+	// tiering fields
+	tiers []*tierArchive
 
 	// batching fields
-	mutex sync.Mutex
-	ch    chan types.InputLogEvent
-	err   *error
+	mutex         sync.Mutex
+	queueMutex    sync.RWMutex
+	ch            chan queuedEvent
+	priorityCh    chan queuedEvent
+	burst         *burstBuffer
+	drainCh       chan chan struct{}
+	errMutex      sync.Mutex
+	err           *error
+	errDedup      *errorDeduper
+	tokenFallback bool
+	adapter       *batchAdapter
+
+	// writeCtx bounds the direct write in progress when a write timeout is set
+	writeCtx context.Context
+
+	// intake fields
+	intakeMutex   sync.Mutex
+	intakeSeq     uint64
+	lastTimestamp int64
+
+	// retargeting fields
+	target  streamTarget
+	sending streamTarget
+
+	// lastRequest identifies the most recent PutLogEvents call
+	lastRequest requestIDs
+
+	// kubernetesMetadata is looked up when the hook is created with the WithKubernetesMetadata option
+	kubernetesMetadata kubernetesMetadata
+
+	// pipelineEnrichers are the enrichers run on each entry, those given with WithEnricher followed by those of the
+	// other options
+	pipelineEnrichers []Enricher
+
+	// statistics fields
+	stats    *statsCounters
+	lag      *lagTracker
+	latency  *latencyRecorder
+	verifier *verifier
+	ops      *opsShipper
+
+	// resource fields
+	resourceMutex      sync.RWMutex
+	groupARN           string
+	streamARN          string
+	streamCreationTime time.Time
+	knownStreams       map[streamTarget]bool
+
+	// child fields
+	config     aws.Config
+	options    []CloudWatchLogsHookOption
+	childMutex sync.Mutex
+	children   []*CloudWatchLogsHook
+
+	// lifecycle fields
+	done       chan struct{}
+	abandon    chan struct{}
+	sendCtx    context.Context
+	cancelSend context.CancelFunc
+	closed     int32
+	closeOnce  sync.Once
+	workers    sync.WaitGroup
+	inflight   sync.WaitGroup
+}
+
+// hookOptions holds the settings made by the options of a hook. They are applied once, while the hook is constructed,
+// and are never changed afterwards, so they can be read without locking; the state which does change while the hook
+// runs lives in the hook itself and is only changed through its methods, such as Update.
+type hookOptions struct {
+	// client fields
+	client     CloudWatchLogsAPI
+	region     string
+	partition  string
+	apiOptions []func(*middleware.Stack) error
+	appName    string
+	appVersion string
+	parent     *CloudWatchLogsHook
+
+	// resource fields
+	retentionDays  int32
+	kmsKeyID       string
+	tags           map[string]string
+	streamTags     map[string]string
+	tagStandard    *TagStandard
+	namingPolicies []NamingPolicy
+	noCreate       bool
+	destinationARN string
+	groupSelector  *groupSelector
+	retargetPolicy RetargetPolicy
+
+	// delivery fields
+	logFrequency     time.Duration
+	batchJitter      time.Duration
+	alignedFlush     bool
+	timestampBuckets time.Duration
+	clock            Clock
+	noSeqTokens      bool
+	tokenRefresh     time.Duration
+	tokenCallback    func(TokenConflict)
+	sharedStream     bool
+	backoff          Backoff
+	creationBackoff  Backoff
+	writeTimeout     time.Duration
+	deadLetters      DeadLetterSink
+	relay            string
+	sqsClient        SQSClient
+	transport        Transport
+	dataKeys         DataKeyProvider
+	tierRules        []TierRule
+	sampler          *adaptiveSampler
+	priority         bool
+	queueCapacity    int
+	burstSize        int
+	backpressure     *backpressureGate
+	deliveryCallback func(BatchReceipt)
+	lagThreshold     time.Duration
+	lagCallback      func(time.Duration)
+	verificationRate float64
+	adaptIntervals   int
+	adaptMin         time.Duration
+	debugLogger      DebugLogger
+	dedupErrors      bool
+	fallbackLogger   DebugLogger
+	returnFmtErrors  bool
+	opsStream        string
+
+	// event fields
+	patternKey          bool
+	eventIDExtractor    func(*logrus.Entry) int
+	eventUIDGenerator   func() string
+	severityMapping     map[logrus.Level]Severity
+	startupEvent        bool
+	heartbeatInterval   time.Duration
+	caller              bool
+	callerTrimPrefixes  []string
+	callerPolicy        CallerPolicy
+	callerPolicyTrims   []string
+	errorStacks         bool
+	kubernetes          bool
+	routingTag          string
+	routingTagFn        func(*logrus.Entry) string
+	valueMarshaler      func(interface{}) (interface{}, error)
+	fieldTypes          map[string]FieldType
+	flattener           *Flattener
+	unitSuffixes        map[string]Unit
+	fieldBudget         int
+	schemaVersion       string
+	timestampLayout     string
+	timestampLocation   *time.Location
+	noInstanceMetadata  bool
+	metadataTimeout     time.Duration
+	stripControlChars   bool
+	allowedControlChars string
+	stripANSI           bool
+	levelPrefix         bool
+	compactJSON         bool
+	emptyPolicy         EmptyMessagePolicy
+	emptyPlaceholder    string
+
+	// pipeline fields
+	enrichers       []Enricher
+	filters         []Filter
+	codec           Codec
+	directEncoding  bool
+	eventDecorator  func(*logrus.Entry, *types.InputLogEvent)
+	messageTemplate string
+	filterPattern   string
+	patternFilter   filterPattern
+	rawMessages     bool
 }
 
-// CloudWatchLogsHookOption is used for creation of optional settings functions.
-type CloudWatchLogsHookOption func(*CloudWatchLogsHook)
+// CloudWatchLogsHookOption is used for creation of optional settings functions. Options can only be applied while a
+// hook is created; use Update to change a running hook.
+type CloudWatchLogsHookOption func(*hookOptions)
 
 // NewCloudWatchLogsHook creates a new hook for sending log message to Amazon CloudWatch Logs.
 func NewCloudWatchLogsHook(config aws.Config, group, stream string, options ...CloudWatchLogsHookOption) (
 	*CloudWatchLogsHook, error) {
 
+	hook, err := newHook(config, group, stream, options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := hook.start(); err != nil {
+		// stop any workers started before the failure
+		hook.Close()
+		return nil, err
+	}
+	return hook, nil
+}
+
+// newHook creates a hook from the options and validates its configuration without interacting with AWS.
+func newHook(config aws.Config, group, stream string, options ...CloudWatchLogsHookOption) (
+	*CloudWatchLogsHook, error) {
+
 	// create the hook
 	hook := &CloudWatchLogsHook{
-		client:            cloudwatchlogs.NewFromConfig(config),
-		group:             group,
-		stream:            stream,
-		nextSequenceToken: nil,
-		retentionDays:     0,
-		kmsKeyID:          "",
-		tags:              map[string]string{},
-		logFrequency:      0,
-		ch:                nil,
-		err:               nil,
+		hookOptions: hookOptions{
+			client:              nil,
+			region:              "",
+			partition:           "",
+			apiOptions:          nil,
+			appName:             "",
+			appVersion:          "",
+			parent:              nil,
+			retentionDays:       0,
+			kmsKeyID:            "",
+			tags:                map[string]string{},
+			streamTags:          nil,
+			tagStandard:         nil,
+			namingPolicies:      nil,
+			noCreate:            false,
+			destinationARN:      "",
+			groupSelector:       nil,
+			retargetPolicy:      RetargetKeepOriginal,
+			logFrequency:        0,
+			batchJitter:         0,
+			alignedFlush:        false,
+			timestampBuckets:    0,
+			clock:               systemClock{},
+			noSeqTokens:         false,
+			tokenRefresh:        0,
+			tokenCallback:       nil,
+			sharedStream:        false,
+			backoff:             nil,
+			creationBackoff:     defaultCreationBackoff,
+			writeTimeout:        0,
+			deadLetters:         nil,
+			relay:               "",
+			sqsClient:           nil,
+			transport:           nil,
+			dataKeys:            nil,
+			tierRules:           nil,
+			sampler:             nil,
+			priority:            false,
+			queueCapacity:       0,
+			burstSize:           0,
+			backpressure:        nil,
+			deliveryCallback:    nil,
+			lagThreshold:        0,
+			lagCallback:         nil,
+			verificationRate:    0,
+			adaptIntervals:      0,
+			adaptMin:            0,
+			debugLogger:         nil,
+			dedupErrors:         false,
+			fallbackLogger:      nil,
+			returnFmtErrors:     false,
+			opsStream:           "",
+			patternKey:          false,
+			eventIDExtractor:    nil,
+			eventUIDGenerator:   nil,
+			severityMapping:     nil,
+			startupEvent:        false,
+			heartbeatInterval:   0,
+			caller:              false,
+			callerTrimPrefixes:  nil,
+			callerPolicy:        CallerKeep,
+			callerPolicyTrims:   nil,
+			errorStacks:         false,
+			kubernetes:          false,
+			routingTag:          "",
+			routingTagFn:        nil,
+			valueMarshaler:      nil,
+			fieldTypes:          nil,
+			flattener:           nil,
+			unitSuffixes:        nil,
+			fieldBudget:         0,
+			schemaVersion:       "",
+			timestampLayout:     "",
+			timestampLocation:   nil,
+			noInstanceMetadata:  false,
+			metadataTimeout:     defaultMetadataTimeout,
+			stripControlChars:   false,
+			allowedControlChars: "",
+			stripANSI:           false,
+			levelPrefix:         false,
+			compactJSON:         false,
+			emptyPolicy:         EmptyMessageDrop,
+			emptyPlaceholder:    "",
+			enrichers:           nil,
+			filters:             nil,
+			codec:               FormatterCodec{},
+			directEncoding:      false,
+			eventDecorator:      nil,
+			messageTemplate:     "",
+			filterPattern:       "",
+			patternFilter:       nil,
+			rawMessages:         false,
+		},
+		group:              group,
+		stream:             stream,
+		nextSequenceToken:  nil,
+		ch:                 nil,
+		priorityCh:         nil,
+		burst:              nil,
+		drainCh:            nil,
+		err:                nil,
+		tokenFallback:      false,
+		adapter:            nil,
+		writeCtx:           nil,
+		intakeSeq:          0,
+		lastTimestamp:      0,
+		target:             streamTarget{},
+		sending:            streamTarget{},
+		lastRequest:        requestIDs{},
+		kubernetesMetadata: kubernetesMetadata{},
+		pipelineEnrichers:  nil,
+		stats:              &statsCounters{},
+		lag:                newLagTracker(),
+		latency:            newLatencyRecorder(),
+		verifier:           nil,
+		ops:                nil,
+		groupARN:           "",
+		streamARN:          "",
+		knownStreams:       nil,
+		config:             config,
+		options:            append([]CloudWatchLogsHookOption{}, options...),
+		children:           nil,
+		done:               make(chan struct{}),
+		abandon:            make(chan struct{}),
 	}
+	hook.sendCtx, hook.cancelSend = context.WithCancel(context.Background())
 
 	// process options
 	for _, opt := range options {
-		opt(hook)
+		opt(&hook.hookOptions)
+	}
+	if hook.kubernetes {
+		hook.kubernetesMetadata = lookupKubernetesMetadata()
+		target, err := hook.expandKubernetesNames(streamTarget{group: hook.group, stream: hook.stream})
+		if err != nil {
+			return nil, err
+		}
+		hook.group, hook.stream = target.group, target.stream
+	}
+	if err := hook.applyNamingPolicies(); err != nil {
+		return nil, err
+	}
+	if err := hook.applyDefaultTags(); err != nil {
+		return nil, err
+	}
+	if err := hook.validate(); err != nil {
+		return nil, err
+	}
+	if hook.dedupErrors && hook.parent == nil {
+		hook.errDedup = &errorDeduper{}
+	}
+	if hook.directEncoding {
+		hook.codec = EntryCodec{}
+	}
+	if hook.messageTemplate != "" {
+		hostname, _ := os.Hostname()
+		codec, err := NewTemplateCodec(hook.messageTemplate, map[string]string{
+			"group":    hook.group,
+			"stream":   hook.stream,
+			"hostname": hostname,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Invalid message template: %v", err)
+		}
+		hook.codec = codec
+	}
+	if hook.flattener != nil {
+		hook.codec = FlatteningCodec{Flattener: *hook.flattener, Codec: hook.codec}
+	}
+	if hook.unitSuffixes != nil {
+		// outermost, so that fields are renamed before being flattened
+		hook.codec = UnitSuffixCodec{Units: hook.unitSuffixes, Codec: hook.codec}
+	}
+	return hook, nil
+}
+
+// start creates the client, starts the background workers and makes sure the log group and stream exist. This is
+// where the hook first interacts with AWS.
+func (h *CloudWatchLogsHook) start() error {
+	h.createClient()
+	h.pipelineEnrichers = append(append([]Enricher(nil), h.enrichers...), h.optionEnrichers()...)
+
+	// batch the messages; children queue theirs with their parent
+	if h.logFrequency > 0 && h.parent == nil {
+		if h.adaptIntervals > 0 {
+			h.adapter = newBatchAdapter(h.adaptIntervals, h.adaptMin, h.logFrequency)
+		}
+		h.ch = make(chan queuedEvent, h.resolvedQueueCapacity())
+		if h.priority {
+			h.priorityCh = make(chan queuedEvent, 1000)
+		}
+		if h.burstSize > 0 {
+			h.burst = newBurstBuffer(h.burstSize)
+		}
+		h.drainCh = make(chan chan struct{})
+		h.workers.Add(1)
+		go h.putBatch()
+	}
+
+	// archive entries sent to cheaper tiers, in the archives of the parent for a child
+	if h.parent != nil {
+		h.tierRules, h.tiers = h.parent.tierRules, h.parent.tiers
+	} else if len(h.tierRules) > 0 {
+		h.tiers = make([]*tierArchive, len(h.tierRules))
+		for i, rule := range h.tierRules {
+			if rule.Bucket != nil {
+				h.tiers[i] = &tierArchive{bucket: rule.Bucket, prefix: rule.Prefix}
+			}
+		}
+		h.workers.Add(1)
+		go h.archiveTiers()
+	}
+
+	// look up the instance metadata for the startup event in the background while the group and stream are created
+	if h.startupEvent && !h.noInstanceMetadata && h.parent == nil {
+		instanceMetadataLookup.start(fetchInstanceMetadata(h.config), h.metadataTimeout)
 	}
 
-	// batch the messages
-	if hook.logFrequency > 0 {
-		hook.ch = make(chan types.InputLogEvent, 10000)
-		go hook.putBatch(time.Tick(hook.logFrequency))
+	// find the group by its tag; children share the group already selected by their parent
+	if h.groupSelector != nil && h.parent == nil {
+		if err := h.selectGroup(); err != nil {
+			return err
+		}
 	}
 
-	// make sure the group and stream exist; if not, create them
-	err := hook.createLogGroup()
+	// make sure the group and stream exist; the write timeout bounds this too, so that a misconfigured hook fails
+	// quickly instead of hanging at start-up
+	if h.writeTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), h.writeTimeout)
+		defer cancel()
+		h.writeCtx = ctx
+	}
+	err := h.prepareResources()
 	if err != nil {
-		return nil, err
+		err = h.writeError(streamTarget{group: h.group, stream: h.stream}, err)
 	}
-	err = hook.createLogStream()
+	h.writeCtx = nil
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// the remaining workers only run once for the family, in the parent, whose ops stream the children report to
+	if h.parent != nil {
+		h.ops = h.parent.ops
+		return nil
+	}
+
+	// ship health events to the ops stream
+	if h.opsStream != "" {
+		h.ops = newOpsShipper(streamTarget{group: h.group, stream: h.opsStream})
+		if err := h.createOpsStream(); err != nil {
+			return err
+		}
+		h.opsf("info", "lifecycle", "hook started")
+		h.workers.Add(1)
+		go h.shipOps()
+	}
+
+	// announce the logger
+	if h.startupEvent {
+		if err := h.sendStartupEvent(); err != nil {
+			return err
+		}
+	}
+	if h.heartbeatInterval > 0 {
+		h.workers.Add(1)
+		go h.heartbeat(h.heartbeatInterval)
+	}
+	if h.lagCallback != nil {
+		h.workers.Add(1)
+		go h.monitorLag(lagCheckInterval(h.lagThreshold))
+	}
+	if h.tokenRefresh > 0 {
+		h.workers.Add(1)
+		go h.refreshTokens(h.tokenRefresh)
+	}
+	if h.verificationRate > 0 {
+		client, ok := h.client.(FilterLogEventsAPI)
+		if !ok {
+			return fmt.Errorf("Unable to verify deliveries: client does not support filtering log events")
+		}
+		h.verifier = newVerifier(h.verificationRate)
+		h.workers.Add(1)
+		go h.verifyDeliveries(client)
+	}
+	return nil
+}
+
+// createClient creates the Amazon CloudWatch Logs client from the AWS configuration unless one was given or is
+// inherited from the parent of the hook.
+func (h *CloudWatchLogsHook) createClient() {
+	if h.parent != nil {
+		h.client = h.parent.client
+	}
+	if h.client == nil {
+		h.client = cloudwatchlogs.NewFromConfig(h.config, func(o *cloudwatchlogs.Options) {
+			if h.region != "" {
+				o.Region = h.region
+			}
+			if h.config.EndpointResolver == nil {
+				// the client is given the default resolver before the options are applied, so it is wrapped to add
+				// the FIPS pseudo regions; an endpoint resolver set in the AWS configuration is left alone
+				o.EndpointResolver = fipsEndpointResolver(o.EndpointResolver)
+			}
+			o.APIOptions = append(o.APIOptions, h.apiOptions...)
+			if h.appVersion != "" {
+				o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKeyValue(h.appName, h.appVersion))
+			} else if h.appName != "" {
+				o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKey(h.appName))
+			}
+		})
+	}
+}
+
+// WithClient replaces the Amazon CloudWatch Logs client created from the AWS configuration with the given client. This
+// is mainly useful for testing with a fake client, such as the one provided by the chaos package.
+func WithClient(client CloudWatchLogsAPI) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.client = client
+	}
+}
+
+// WithAPIOptions adds middleware to the Amazon CloudWatch Logs client created by the hook, for example to log or audit
+// requests, inject headers or change how requests are signed, without replacing the client.
+func WithAPIOptions(fns ...func(*middleware.Stack) error) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.apiOptions = append(o.apiOptions, fns...)
+	}
+}
+
+// WithAppID appends the name and version of the application to the user agent of all Amazon CloudWatch Logs calls
+// made by the hook, so that API usage can be attributed to each service in AWS CloudTrail.
+func WithAppID(name, version string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.appName = name
+		o.appVersion = version
 	}
-	return hook, nil
 }
 
 // WithGroupRetentionDays sets the number of days to retain logs for the log group. This is only valid if the log
 // group is being created and does not already exist.
 func WithGroupRetentionDays(days int32) CloudWatchLogsHookOption {
-	return func(h *CloudWatchLogsHook) {
-		h.retentionDays = days
+	return func(o *hookOptions) {
+		o.retentionDays = days
 	}
 }
 
 // WithGroupKmsKeyID sets the Amazon KMS key ID to use for encryption of log data. This is only valid if the log
 // group is being created and does not already exist.
 func WithGroupKmsKeyID(id string) CloudWatchLogsHookOption {
-	return func(h *CloudWatchLogsHook) {
-		h.kmsKeyID = id
+	return func(o *hookOptions) {
+		o.kmsKeyID = id
 	}
 }
 
 // WithGroupTags sets any tags to associate with the log group. This is only valid if the log group is being created
 // and does not already exist.
 func WithGroupTags(tags map[string]string) CloudWatchLogsHookOption {
-	return func(h *CloudWatchLogsHook) {
-		h.tags = tags
+	return func(o *hookOptions) {
+		o.tags = tags
+	}
+}
+
+// WithStreamTags sets tags to apply to the streams the hook writes to, such as per-tenant streams, for cost allocation.
+// The tags are applied with TagResource when the hook is created, when it is pointed at a different stream with Update
+// and to the streams created by EnsureStreams. Streams are only tagged where supported: the client must implement
+// ResourceTagsAPI and streams which the service does not allow to be tagged are reported to the debug logger.
+func WithStreamTags(tags map[string]string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.streamTags = tags
+	}
+}
+
+// WithNamingPolicy adds a policy which can reject or rewrite the names of the log group and stream, allowing
+// platform teams to enforce naming conventions, such as RequireGroupPrefix("/org/team/"), at the library level.
+// Policies are applied in the order given, before the names are validated.
+func WithNamingPolicy(policy NamingPolicy) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.namingPolicies = append(o.namingPolicies, policy)
+	}
+}
+
+// WithDefaultTags adds the tags required by the given tag standard, such as DefaultTagStandard, to the log group
+// tags. The value of each tag is looked up when the hook is created, and creating the hook fails if any of them is
+// missing or invalid. Like WithGroupTags, this is only valid if the log group is being created and does not already
+// exist.
+func WithDefaultTags(standard TagStandard) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.tagStandard = &standard
 	}
 }
 
 // WithBatchDuration specifies the frequency with which to upload messages to Amazon CloudWatch. If this option is not
 // specified, messages are uploaded immediately.
 func WithBatchDuration(frequency time.Duration) CloudWatchLogsHookOption {
-	return func(h *CloudWatchLogsHook) {
-		h.logFrequency = frequency
+	return func(o *hookOptions) {
+		o.logFrequency = frequency
+	}
+}
+
+// WithAdaptiveBatching shortens the batch duration when events are queued faster than they are sent for the given
+// number of consecutive intervals, halving it each time down to the given minimum, and lengthens it again, up to the
+// duration given by WithBatchDuration, once sending keeps up. Batches are always filled up to the Amazon CloudWatch
+// limits, so the batch duration is the only setting which needs to adapt. Changes are reported to the debug logger
+// and by Stats.
+func WithAdaptiveBatching(intervals int, min time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.adaptIntervals = intervals
+		o.adaptMin = min
+	}
+}
+
+// WithVerification samples the given fraction of the events accepted by Amazon CloudWatch and searches for them with
+// FilterLogEvents once they should be searchable, counting the events found and those which never appear. The success
+// rate reported by Stats is a built-in canary for events silently lost after being accepted. Each search is an API
+// call, so keep the rate small, such as 0.001. The client must implement FilterLogEventsAPI.
+func WithVerification(sampleRate float64) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.verificationRate = sampleRate
+	}
+}
+
+// WithDebugLogger sets the logger which receives diagnostic messages about the inner workings of the hook, such as
+// adaptations of the batch duration. It must not be a logger the hook is attached to.
+func WithDebugLogger(logger DebugLogger) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.debugLogger = logger
+	}
+}
+
+// WithErrorDeduplication collapses identical consecutive errors from sending batches. An error is returned by the next
+// write and reported to the debug logger and the ops stream when it is first seen. While it keeps recurring, it is
+// only reported once a minute with the number of times it was repeated, and a final report is made once a batch is
+// sent successfully again. It requires batching.
+func WithErrorDeduplication() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.dedupErrors = true
+	}
+}
+
+// WithSafeFallbackLogger sets the logger which receives the errors of the hook itself, such as entries holding values
+// which cannot be serialized. These errors are no longer returned to Logrus, which prints them to stderr, and are
+// written to the debug logger if no fallback logger is given. It must not be a logger the hook is attached to.
+func WithSafeFallbackLogger(logger DebugLogger) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.fallbackLogger = logger
+	}
+}
+
+// WithReturnFormatErrors returns the error from Fire when an entry cannot be encoded, so that Logrus reports it,
+// rather than passing it to the fallback logger.
+func WithReturnFormatErrors() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.returnFmtErrors = true
+	}
+}
+
+// WithOpsStream sends structured events describing the health of the hook to the given stream in the log group of the
+// hook, so that the health of the logging pipeline of a whole fleet can be queried with CloudWatch Logs Insights. The
+// events cover the hook starting and closing, the messages written to the debug logger, summaries of the entries
+// dropped and changes in the state of the backpressure gate. They are collected and sent once a minute.
+func WithOpsStream(stream string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.opsStream = stream
+	}
+}
+
+// WithClock replaces the clock which all of the timing of the hook flows through: the timestamps of events, the
+// scheduling of batch uploads, heartbeats and delivery lag checks, and the waits between retries. This allows the
+// timing to be tested deterministically with a fake clock, such as the one provided by the chaos package. A nil clock
+// restores the system clock.
+func WithClock(clock Clock) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		if clock == nil {
+			clock = systemClock{}
+		}
+		o.clock = clock
+	}
+}
+
+// WithBatchJitter delays each batch upload by a random amount of time up to the given maximum, so that replicas
+// started at the same time do not upload their batches in synchronized bursts. Unless WithAlignedFlush is also used,
+// this lengthens the average time between uploads by half the maximum.
+func WithBatchJitter(max time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.batchJitter = max
+	}
+}
+
+// WithAlignedFlush uploads batches at multiples of the batch duration on the wall clock, such as on every whole
+// second for a batch duration of 1s, rather than relative to when the hook was created. Combined with
+// WithBatchJitter, this spreads the uploads of a fleet of replicas evenly within each interval.
+func WithAlignedFlush() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.alignedFlush = true
+	}
+}
+
+// WithTimestampBuckets keys batches by buckets of the given width, which must be a whole number of minutes, so that
+// each batch only holds events whose timestamps fall in the same bucket and the batch is sent once the bucket ends.
+// Insights queries over windows aligned to the buckets then always see complete buckets. Events which arrive after the
+// batch for their bucket was sent are flagged with a late field set to true and sent after the batch duration.
+func WithTimestampBuckets(width time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.timestampBuckets = width
+	}
+}
+
+// WithoutSequenceTokens disables management of the upload sequence token. CloudWatch no longer requires sequence
+// tokens for PutLogEvents calls, so the hook skips the DescribeLogStreams calls otherwise needed to track the token.
+// If the service rejects a request because a token is still required, the hook automatically falls back to managing
+// the token itself.
+func WithoutSequenceTokens() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.noSeqTokens = true
+	}
+}
+
+// WithBackoff sets the policy used to retry uploads to Amazon CloudWatch that fail due to throttling, service
+// unavailability or sequence token conflicts. If this option is not specified, failed uploads are not retried.
+func WithBackoff(b Backoff) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.backoff = b
+	}
+}
+
+// WithCreationBackoff sets the policy used to wait for a log group or stream the hook has just created, or which
+// another process created at the same time, to be found. The service is eventually consistent, so newly created
+// resources are not always listed straight away. The policy also paces the calls of PurgeOldStreams which are
+// throttled. A nil policy gives up after the first attempt. If this option is not specified, the hook retries for a
+// few seconds.
+func WithCreationBackoff(b Backoff) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.creationBackoff = b
+	}
+}
+
+// WithWriteTimeout bounds the time taken by each write sent directly to Amazon CloudWatch, including any retries made
+// by the AWS SDK or the backoff policy, so that a misconfigured hook fails the first write quickly instead of hanging.
+// It also bounds looking up and creating the log group and stream when the hook is created. A write, or the creation of
+// the hook, which times out returns a WriteError matching ErrWriteTimeout. It requires the hook to not batch events.
+func WithWriteTimeout(d time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.writeTimeout = d
+	}
+}
+
+// WithDeadLetterSink sets the sink that receives any log events which could not be delivered to Amazon CloudWatch
+// after exhausting all retries.
+func WithDeadLetterSink(sink DeadLetterSink) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.deadLetters = sink
+	}
+}
+
+// WithSQSRelay sends log events to the Amazon SQS queue with the given URL rather than directly to Amazon CloudWatch.
+// An SQSRelay must be run to drain the queue into Amazon CloudWatch. This decouples the latency of the application from
+// the availability of Amazon CloudWatch. The log group and stream are not created by the hook when this option is
+// used. The client used to send to the queue must be set using the WithSQSClient option.
+func WithSQSRelay(queueURL string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.relay = queueURL
+	}
+}
+
+// WithSQSClient sets the client used to send log events to the queue given by the WithSQSRelay option.
+func WithSQSClient(client SQSClient) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.sqsClient = client
+	}
+}
+
+// WithDeliveryCallback sets a function which is called with a receipt after each batch is successfully delivered to
+// Amazon CloudWatch, for example to track the delivery lag of logs against an SLO. The function is called while the
+// hook is sending events, so it must return quickly and must not log through the hook.
+func WithDeliveryCallback(callback func(receipt BatchReceipt)) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.deliveryCallback = callback
+	}
+}
+
+// WithDeliveryLagAlarm sets a function which is called when the delivery lag, as reported by DeliveryLag, rises above
+// the threshold. The function is not called again until the lag has fallen back below the threshold. It is called
+// from a background goroutine, so it may block briefly, but it must not log through the hook.
+func WithDeliveryLagAlarm(threshold time.Duration, callback func(lag time.Duration)) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.lagThreshold = threshold
+		o.lagCallback = callback
+	}
+}
+
+// WithTransport delivers batches of log events using the given transport rather than to Amazon CloudWatch, while
+// keeping the batching, retry and dead letter handling of the hook. This allows alternative destinations, such as
+// OpenSearch, Loki or Amazon S3, to be implemented without forking the batching logic. The log group and stream are
+// not created by the hook when this option is used.
+func WithTransport(transport Transport) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.transport = transport
+	}
+}
+
+// WithBatchEncryption encrypts each batch of events client-side with AES-256-GCM, using a new data key from the given
+// provider for every batch, for workloads where even readers of the log group must not see the plaintext. Each batch
+// is sent as one or more events holding an Envelope, which can be decrypted with OpenEnvelope or the cwhook-decrypt
+// command.
+func WithBatchEncryption(provider DataKeyProvider) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.dataKeys = provider
+	}
+}
+
+// WithTiering sends entries to cheaper storage tiers according to the given rules, such as archiving debug and trace
+// entries to Amazon S3 while sending warnings and errors to Amazon CloudWatch, to cut ingestion costs. The first rule
+// matching the level of an entry applies, and entries matching no rule are sent to Amazon CloudWatch. Archived entries
+// are written every minute, and when the hook is closed, as gzip-compressed lines of JSON partitioned by group,
+// stream and hour so that they can be queried with Amazon Athena.
+func WithTiering(rules []TierRule) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.tierRules = rules
+	}
+}
+
+// WithAdaptiveSampling keeps the volume of events sent to Amazon CloudWatch under the given number of bytes per
+// minute by sampling warnings and less severe entries. Every minute, the sampling rate of each level is adjusted to
+// the volume of the previous minute, giving the more severe levels the first share of the budget left over after
+// errors, which are never sampled. The current rates are reported by Stats.
+func WithAdaptiveSampling(targetBytesPerMinute int64) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.sampler = newAdaptiveSampler(targetBytesPerMinute)
+	}
+}
+
+// WithDestinationARN forwards the log group to the given cross-account Amazon CloudWatch Logs destination, as used
+// in vended log setups, by subscribing the group to it. The log group and stream must already exist since the hook
+// does not create them, and the access policy of the destination must allow the account writing the logs.
+func WithDestinationARN(arn string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.destinationARN = arn
+	}
+}
+
+// WithRegion creates the client for the given region rather than the region of the AWS configuration. FIPS pseudo
+// regions, such as fips-us-gov-west-1 or us-east-1-fips, select the FIPS endpoints of the region, unless the AWS
+// configuration has its own endpoint resolver; creating the hook fails if the region has no FIPS endpoints. The
+// region has no effect on a client given with WithClient, other than determining the partition of the hook.
+func WithRegion(region string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.region = region
+	}
+}
+
+// WithPartition sets the AWS partition of the hook, such as PartitionAWSUSGov for AWS GovCloud (US) or PartitionAWSCN
+// for the China regions, rather than inferring it from the region. Creating the hook fails if the region, or any
+// destination ARN or KMS key ARN given to the hook, is not in the partition.
+func WithPartition(partition string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.partition = partition
+	}
+}
+
+// WithNoCreate stops the hook from creating its log group and stream, which must instead be provisioned ahead of
+// time, for example by the cwhook-provision command during deployment. Creating the hook fails if either does not
+// exist, and the application then only needs permission to describe and write to them.
+func WithNoCreate() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.noCreate = true
+	}
+}
+
+// WithGroupSelector locates an existing log group by the given tag rather than by its exact name, which is useful
+// when groups are provisioned by infrastructure as code with generated names. The group name given to the hook is
+// used as a prefix to narrow the search and may be empty. Exactly one group must have the tag, and the hook does not
+// create the group.
+func WithGroupSelector(tagKey, tagValue string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.groupSelector = &groupSelector{key: tagKey, value: tagValue}
+	}
+}
+
+// WithPatternKey adds a pattern_key field to each entry which is computed by stripping variable tokens, such as
+// numbers, UUIDs and IP addresses, from the message. Entries from the same log statement share the same key, which
+// allows CloudWatch Logs Insights pattern analysis and anomaly detection to cluster them reliably.
+func WithPatternKey() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.patternKey = true
+	}
+}
+
+// WithEventIDExtractor adds an event_id field to each entry holding the ID returned by the extractor, such as one
+// looked up from a field or from the message, so that SIEM rules keyed on event IDs keep working for applications
+// migrating from the Windows Event Log. Entries for which the extractor returns 0 have no event ID.
+func WithEventIDExtractor(extractor func(*logrus.Entry) int) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.eventIDExtractor = extractor
+	}
+}
+
+// WithEventID adds an event_uid field to each entry holding a unique ID returned by the generator, or by NewULID if
+// the generator is nil. The ID is assigned once, when the entry is fired, so that duplicates resulting from an upload
+// being retried can be removed downstream and individual events can be referenced, for example in tickets. Unlike
+// the event_id field added by WithEventIDExtractor, the ID is unique to each entry.
+func WithEventID(generator func() string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		if generator == nil {
+			generator = NewULID
+		}
+		o.eventUIDGenerator = generator
+	}
+}
+
+// WithSeverityMapping adds severity and severity_code fields to each entry holding the severity its level maps to,
+// such as with WindowsEventSeverity.
+func WithSeverityMapping(mapping map[logrus.Level]Severity) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.severityMapping = mapping
+	}
+}
+
+// WithPriorityQueue sends warning, error, fatal and panic events through a dedicated queue when batching is enabled.
+// These events jump ahead of any queued lower severity events and are uploaded immediately, so they do not wait
+// behind thousands of debug messages when the queue is saturated. This option has no effect unless batching is
+// enabled.
+func WithPriorityQueue() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.priority = true
+	}
+}
+
+// WithStartupEvent emits a structured "logger started" event when the hook is created. The event contains the hook
+// version, build information, host metadata and the effective hook configuration, making it easy to correlate
+// deployments with changes in log behavior.
+func WithStartupEvent() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.startupEvent = true
+	}
+}
+
+// WithoutInstanceMetadata disables the lookup of Amazon EC2 instance metadata for the startup event. Use this in
+// environments where the instance metadata service is disabled or unreachable to avoid waiting for the lookup to time
+// out.
+func WithoutInstanceMetadata() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.noInstanceMetadata = true
+	}
+}
+
+// WithMetadataTimeout sets the maximum time allowed for Amazon EC2 instance metadata lookups. The lookups are done in
+// the background and cached, so this bounds how long the startup event waits for them. If this option is not
+// specified, lookups time out after one second.
+func WithMetadataTimeout(timeout time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.metadataTimeout = timeout
+	}
+}
+
+// WithControlCharStripping strips control characters, other than those in allowed, from messages before they are sent
+// to Amazon CloudWatch. For example, pass "\t\n" to keep tabs and newlines. Invalid UTF-8 sequences are always
+// replaced, since Amazon CloudWatch rejects them.
+func WithControlCharStripping(allowed string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.stripControlChars = true
+		o.allowedControlChars = allowed
+	}
+}
+
+// WithANSIStripping strips ANSI escape sequences, such as the colors added by text formatters writing to a terminal,
+// from messages before they are sent to Amazon CloudWatch.
+func WithANSIStripping() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.stripANSI = true
+	}
+}
+
+// WithLevelPrefix prefixes each message with a normalized level token, such as "[ERROR]" or "[WARN]", so the messages
+// of plain text formatters can be searched by level regardless of how the formatter renders it. It is not intended
+// for JSON formatters, since the prefix makes the message invalid JSON.
+func WithLevelPrefix() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.levelPrefix = true
+	}
+}
+
+// WithJSONCompaction rewrites JSON messages spanning several lines, such as those produced by a pretty-printing
+// formatter, as a single line before they are sent. Amazon CloudWatch keeps each event intact either way, but compact
+// JSON is cheaper to ingest and parsed more reliably by CloudWatch Logs Insights. Messages which are not valid JSON are
+// sent unchanged.
+func WithJSONCompaction() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.compactJSON = true
+	}
+}
+
+// WithEmptyMessagePolicy sets how empty and whitespace-only messages, which Amazon CloudWatch rejects along with the
+// rest of their batch, are handled. The placeholder is only used with EmptyMessagePlaceholder. If this option is not
+// specified, such messages are dropped. The number of messages dropped or replaced is reported by Stats.
+func WithEmptyMessagePolicy(policy EmptyMessagePolicy, placeholder string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.emptyPolicy = policy
+		o.emptyPlaceholder = placeholder
+	}
+}
+
+// WithRetargetPolicy sets which stream events still queued when the hook is pointed at a different stream are
+// delivered to. If this option is not specified, they are delivered to the stream which was active when they were
+// emitted.
+func WithRetargetPolicy(policy RetargetPolicy) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.retargetPolicy = policy
+	}
+}
+
+// WithTokenRefresh periodically refreshes the upload sequence token of the stream at the given interval, so that the
+// token held by a quiet hook does not go stale while another writer shares the stream. Whenever the token of the
+// stream changes without the hook writing to it, or an upload is rejected because of it, the conflict is counted in
+// Stats and the callback, which may be nil, is called to warn that several writers are contending for the stream. The
+// callback is called while the hook is sending events, so it must return quickly and must not log through the hook.
+func WithTokenRefresh(interval time.Duration, callback func(conflict TokenConflict)) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.tokenRefresh = interval
+		o.tokenCallback = callback
+	}
+}
+
+// WithSharedStream makes the hook cooperate with other processes writing to the same stream. Uploads rejected because
+// another writer changed the upload sequence token are retried with the refreshed token, after a short random delay
+// which keeps the writers from retrying in lockstep, instead of failing or waiting for the backoff policy. Each
+// conflict is counted in Stats and reported to the callback given to WithTokenRefresh, if any. Giving each writer its
+// own stream remains the better option where possible.
+func WithSharedStream() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.sharedStream = true
+	}
+}
+
+// WithHeartbeat periodically emits a small heartbeat event at the given interval. The absence of heartbeat events in
+// Amazon CloudWatch is a reliable signal that delivery is broken, even when the application is quiet.
+func WithHeartbeat(interval time.Duration) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.heartbeatInterval = interval
+	}
+}
+
+// WithCaller adds file, line and func fields identifying the caller to each entry. The caller recorded by logrus is
+// used when ReportCaller is enabled on the logger; otherwise the hook finds the caller itself. The first matching
+// prefix, such as a GOPATH or module path, is trimmed from the file and function names.
+func WithCaller(trimPrefixes ...string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.caller = true
+		o.callerTrimPrefixes = trimPrefixes
 	}
 }
 
-// Fire is called every time an entry needs to be written to the log.
+// WithCallerPolicy sets how the caller recorded by logrus when ReportCaller is enabled on the logger is reported in the
+// messages sent to Amazon CloudWatch, without affecting the output of the logger. The prefixes are trimmed from the
+// file and function names by the CallerTrim policy.
+func WithCallerPolicy(policy CallerPolicy, trimPrefixes ...string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.callerPolicy = policy
+		o.callerPolicyTrims = trimPrefixes
+	}
+}
+
+// WithKubernetesMetadata adds a kubernetes field to each entry identifying the cluster, namespace, pod, node and
+// container running the hook, and expands the {cluster}, {namespace}, {pod}, {node} and {container} placeholders in
+// the names of the log group and stream, such as "/eks/{cluster}/{namespace}" and "{pod}". The metadata is read from
+// the CLUSTER_NAME, POD_NAMESPACE, POD_NAME, NODE_NAME and CONTAINER_NAME environment variables, which are typically
+// populated with the downward API. The namespace otherwise defaults to that of the service account and the pod to the
+// hostname. Creating the hook fails if a placeholder is used whose value is not known.
+func WithKubernetesMetadata() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.kubernetes = true
+	}
+}
+
+// WithRoutingTag adds a tag field holding the given tag, such as app.payments.web, to each entry, following the
+// conventions of Fluentd and Fluent Bit, so that subscription consumers which route events by tag keep working after
+// moving from agent-based shipping. A tag is made of letters, digits, underscores and hyphens, in parts separated by
+// dots.
+func WithRoutingTag(tag string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.routingTag = tag
+	}
+}
+
+// WithRoutingTagFunc adds a tag field holding the tag returned by the function for each entry, such as one derived
+// from its fields. An empty tag leaves the entry without the field.
+func WithRoutingTagFunc(tag func(*logrus.Entry) string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.routingTagFn = tag
+	}
+}
+
+// WithValueMarshaler sets how field values which are not JSON-native, such as durations, structs, errors and byte
+// slices, are rendered: each is replaced with the value returned by the marshaler, or left as it is if the marshaler
+// returns an error. MillisecondDurations renders durations as a number of milliseconds. With WithErrorStacks, errors
+// are expanded before the marshaler is called.
+func WithValueMarshaler(marshaler func(interface{}) (interface{}, error)) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.valueMarshaler = marshaler
+	}
+}
+
+// WithFieldTypes coerces the fields named in the map to a consistent type, such as latency_ms to a number even when
+// it is logged as a string, since fields whose type varies between events break CloudWatch Logs Insights
+// aggregations. Values which cannot be coerced are replaced with null and listed in an uncoerced_fields field.
+func WithFieldTypes(types map[string]FieldType) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.fieldTypes = make(map[string]FieldType, len(types))
+		for name, t := range types {
+			o.fieldTypes[name] = t
+		}
+	}
+}
+
+// WithUnitSuffixes renames numeric fields with a suffix naming their unit, so that dashboards can rely on the same
+// names across services. Every time.Duration field is rendered as a number of milliseconds, such as latency as
+// latency_ms, or of seconds if the map gives it UnitSeconds. Integer fields named in the map are renamed with the
+// suffix of their unit, such as size as size_bytes with UnitBytes. The map may be nil to only rename durations.
+func WithUnitSuffixes(units map[string]Unit) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.unitSuffixes = make(map[string]Unit, len(units))
+		for name, unit := range units {
+			o.unitSuffixes[name] = unit
+		}
+	}
+}
+
+// WithFlattener flattens nested maps and structs in the fields of each entry into fields with joined keys, such as
+// http.request.method, sanitizes the keys and caps the depth and number of fields, so that events stay queryable and
+// bounded. The fields added by the hook itself, such as kubernetes, are flattened too.
+func WithFlattener(flattener Flattener) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.flattener = &flattener
+	}
+}
+
+// WithFieldBudget truncates the value of any field larger than the given number of bytes, such as a giant request
+// body, to a string ending with an ellipsis and lists the truncated fields in a truncated_fields field, so that the
+// event stays within the Amazon CloudWatch limits rather than being dropped.
+func WithFieldBudget(maxBytesPerField int) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.fieldBudget = maxBytesPerField
+	}
+}
+
+// WithErrorStacks expands error values in entry fields into structured sub-fields holding the message, type, chain of
+// wrapped errors and stack trace rather than just the error message. Both Go 1.13 wrapping and github.com/pkg/errors
+// are supported.
+func WithErrorStacks() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.errorStacks = true
+	}
+}
+
+// WithBackpressureLevel raises the minimum level of entries sent to Amazon CloudWatch to the given level while the
+// batch queue is backed up. Once the number of queued events reaches the high-water mark, entries less severe than
+// the level are dropped until the queue drains to the low-water mark, keeping critical entries flowing during
+// incidents. This option has no effect unless batching is enabled.
+func WithBackpressureLevel(level logrus.Level, highWaterMark, lowWaterMark int) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.backpressure = &backpressureGate{
+			level:         level,
+			highWaterMark: highWaterMark,
+			lowWaterMark:  lowWaterMark,
+		}
+	}
+}
+
+// WithQueueCapacity sets the number of events the batch queue holds before the goroutines logging entries have to
+// wait for room, which is 10,000 by default or if n is 0. Shrink it in low-memory environments, such as AWS Lambda or
+// small containers, or grow it for high-throughput services. It requires batching.
+func WithQueueCapacity(n int) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.queueCapacity = n
+	}
+}
+
+// WithBurstBuffer adds a ring buffer holding the given number of events, rounded up to a power of two, ahead of the
+// batch queue. While the queue is full, events are put in the buffer without taking a lock or blocking the goroutine
+// logging them, which absorbs short bursts. Once the buffer overflows too, entries less severe than the level of
+// WithBackpressureLevel, if given, are dropped and other entries wait for room in the queue. It requires batching.
+func WithBurstBuffer(size int) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.burstSize = size
+	}
+}
+
+// WithSchemaVersion adds a schema_version field holding the given version to each entry. Register a Schema for the
+// version with RegisterSchema to describe the layout of the payloads, so downstream queries and ETL jobs can handle
+// payloads written under each version as the layout evolves.
+func WithSchemaVersion(version string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.schemaVersion = version
+	}
+}
+
+// WithTimestampFormat adds a timestamp field holding the time of each entry rendered using the given layout, such as
+// time.RFC3339Nano or a custom layout, or as milliseconds since the Unix epoch when TimestampEpochMillis is given. The
+// field is independent of both the time rendered by the logger's formatter and the timestamp of the event in Amazon
+// CloudWatch, which allows the format to match what downstream parsers expect.
+func WithTimestampFormat(layout string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.timestampLayout = layout
+	}
+}
+
+// WithTimestampLocation adds a timestamp field holding the time of each entry converted to the given location, such
+// as time.UTC or time.Local. The field uses the layout set by WithTimestampFormat, or time.RFC3339Nano if no layout
+// is given.
+func WithTimestampLocation(loc *time.Location) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.timestampLocation = loc
+	}
+}
+
+// WithEnricher adds an enricher which adds fields to each entry before it is encoded. Enrichers are applied in the
+// order given, before those added by options such as WithPatternKey and WithCaller.
+func WithEnricher(enricher Enricher) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.enrichers = append(o.enrichers, enricher)
+	}
+}
+
+// WithFilter adds a filter which decides whether or not each entry is sent to Amazon CloudWatch. An entry is only
+// sent if every filter allows it.
+func WithFilter(filter Filter) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.filters = append(o.filters, filter)
+	}
+}
+
+// WithClientSideFilterPattern drops the messages which do not match the Amazon CloudWatch filter pattern before they
+// are sent, saving the cost of ingesting events which only a subscription or metric filter with the same pattern would
+// ever use. Terms, such as ERROR -Exiting or ?ERROR ?WARN, and JSON patterns, such as { $.level = "error" }, are
+// supported; space-delimited patterns and regular expressions are not. Messages are matched after they have been
+// formatted and sanitized, the way the service would see them.
+func WithClientSideFilterPattern(pattern string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.filterPattern = pattern
+	}
+}
+
+// WithEventDecorator sets a function which is called with each entry and the event it was encoded into before the
+// event is queued, so that attributes of events added by newer versions of the SDK can be populated without waiting
+// for the hook to support them. The message and timestamp of the event are managed by the hook and changes to them are
+// discarded. Messages written with Write have no entry and are not decorated.
+func WithEventDecorator(decorator func(entry *logrus.Entry, event *types.InputLogEvent)) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.eventDecorator = decorator
+	}
+}
+
+// WithCodec sets the codec used to encode each entry into the message sent to Amazon CloudWatch. If this option is
+// not specified, entries are encoded using the formatter of the logger.
+func WithCodec(codec Codec) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.codec = codec
+	}
+}
+
+// WithDirectEncoding encodes each entry as JSON built directly from its time, level, message and fields using
+// EntryCodec, so that no logrus formatter is run for the hook. Combined with a logger whose output is discarded, each
+// entry is serialized only once; AttachExclusive sets up such a logger. It cannot be combined with WithCodec or
+// WithMessageTemplate.
+func WithDirectEncoding() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.directEncoding = true
+	}
+}
+
+// WithRawMessages sends the messages given to Write exactly as they are, for callers piping lines which are already
+// formatted, such as JSON, through the hook as an io.Writer. Control character stripping and the empty message policy
+// are not applied; instead, messages which are empty, too large for a CloudWatch event or not valid UTF-8 are rejected
+// with an error. Entries fired by loggers are still encoded by the codec.
+func WithRawMessages() CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.rawMessages = true
+	}
+}
+
+// WithMessageTemplate encodes each entry using the given text/template, so that the messages sent to Amazon
+// CloudWatch have exactly the format downstream parsers expect without writing a custom logrus formatter. The template
+// is given a TemplateData holding the time, level, message, fields and caller of the entry along with metadata holding
+// the group, stream and hostname, for example:
+//
+//	{{.Time.Format "2006-01-02T15:04:05Z07:00"}} [{{.Level}}] {{.Message}} {{json .Fields}}
+func WithMessageTemplate(tmpl string) CloudWatchLogsHookOption {
+	return func(o *hookOptions) {
+		o.messageTemplate = tmpl
+	}
+}
+
+// Fire is called every time an entry needs to be written to the log. It is safe for concurrent use, so a single hook
+// may be shared by several loggers, each with its own formatter.
 func (h *CloudWatchLogsHook) Fire(entry *logrus.Entry) error {
-	line, err := entry.String()
+	if q := h.queueOwner(); q.ch != nil && h.backpressure != nil && h.backpressure.drop(entry.Level, q.queueLength()) {
+		atomic.AddInt64(&h.stats.backpressureDropped, 1)
+		return nil
+	}
+	for _, filter := range h.filters {
+		if !filter.Allow(entry) {
+			return nil
+		}
+	}
+
+	line, err := h.format(entry)
 	if err != nil {
-		return fmt.Errorf("Unable to parse entry: %v", err)
+		return h.formatError(entry, err)
+	}
+	if tier := h.tierFor(entry.Level); tier != nil {
+		return h.archive(tier, entry.Level, h.prefixLevel(line, entry), entry.Time)
+	}
+	if h.sampler != nil && !h.sampler.sample(entry.Level, len(line)+26, h.clock.Now()) {
+		atomic.AddInt64(&h.stats.sampledOut, 1)
+		return nil
 	}
 
 	switch entry.Level {
@@ -125,16 +1366,26 @@ func (h *CloudWatchLogsHook) Fire(entry *logrus.Entry) error {
 	case logrus.InfoLevel:
 		fallthrough
 	case logrus.DebugLevel:
-		_, err := h.Write([]byte(line))
+		_, err := h.write(line, entry.Level <= logrus.WarnLevel, entry)
 		return err
 	default:
 		return nil
 	}
 }
 
+// format renders the entry using the codec along with any fields added by the enrichers.
+func (h *CloudWatchLogsHook) format(entry *logrus.Entry) (string, error) {
+	if entry.Caller != nil && h.callerPolicy != CallerKeep {
+		e := *entry
+		e.Caller = h.callerPolicy.apply(entry.Caller, h.callerPolicyTrims)
+		entry = &e
+	}
+	return encodeEntry(entry, h.pipelineEnrichers, h.codec)
+}
+
 // Levels returns the valid levels for the hook.
 func (h *CloudWatchLogsHook) Levels() []logrus.Level {
-	return []logrus.Level{
+	levels := []logrus.Level{
 		logrus.PanicLevel,
 		logrus.FatalLevel,
 		logrus.ErrorLevel,
@@ -142,47 +1393,245 @@ func (h *CloudWatchLogsHook) Levels() []logrus.Level {
 		logrus.InfoLevel,
 		logrus.DebugLevel,
 	}
+	if i := h.tierRuleFor(logrus.TraceLevel); i >= 0 && h.tierRules[i].Bucket != nil {
+		// trace entries are only accepted when they are archived
+		levels = append(levels, logrus.TraceLevel)
+	}
+	return levels
 }
 
-// Write handles writing the message to Amazon CloudWatch or to the channel if batching is enabled.
+// Write handles writing the message to Amazon CloudWatch or to the channel if batching is enabled. The message is
+// copied, so the caller is free to reuse msg as soon as Write returns.
 func (h *CloudWatchLogsHook) Write(msg []byte) (int, error) {
-	event := types.InputLogEvent{
-		Message:   aws.String(string(msg)),
-		Timestamp: aws.Int64(int64(time.Nanosecond) * time.Now().UnixNano() / int64(time.Millisecond)),
+	if h.rawMessages {
+		return h.writeRaw(msg)
+	}
+	return h.write(string(msg), false, nil)
+}
+
+// Send delivers the events to Amazon CloudWatch immediately, bypassing the batching of the hook, which allows the hook
+// to be used as the Transport of a Pipeline. The events are split into as many PutLogEvents calls as the limits
+// returned by PutLogEventsLimits require, the same way batched events are.
+func (h *CloudWatchLogsHook) Send(ctx context.Context, events []Event) error {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return ErrClosed
+	}
+	batch := getBatchSlice()
+	defer func() {
+		putBatchSlice(batch)
+	}()
+	for _, e := range events {
+		msg, ok := h.applyEmptyPolicy(h.sanitize(e.Message))
+		if !ok {
+			continue
+		}
+		h.countLogged(len(e.Message), nil)
+		batch = append(batch, newEvent(msg, e.Timestamp.UnixNano()/int64(time.Millisecond)))
+	}
+	if len(batch) == 0 {
+		return nil
 	}
 
-	// write the message to the batched channel
+	// events must be in chronological order within a batch
+	sort.SliceStable(batch, func(i, j int) bool {
+		return aws.ToInt64(batch[i].Timestamp) < aws.ToInt64(batch[j].Timestamp)
+	})
+	target := h.bind(streamTarget{})
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.deliver(target, batch)
+}
+
+// write handles writing the message, sending priority messages through the priority channel if it is enabled. The
+// entry the message was encoded from is given for Fire, and is nil for Write.
+func (h *CloudWatchLogsHook) write(msg string, priority bool, entry *logrus.Entry) (int, error) {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return 0, ErrClosed
+	}
+	n := len(msg)
+	msg, ok := h.applyEmptyPolicy(h.sanitize(msg))
+	if !ok || h.filtered(msg) {
+		return n, nil
+	}
+	h.countLogged(n, entry)
+	if err := h.enqueue(h.prefixLevel(msg, entry), priority, entry); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// enqueue sends the message through the batched channel, or directly to Amazon CloudWatch if batching is disabled.
+// The event is decorated first if the message was encoded from an entry. A child hands its events to the root of its
+// family, pinned to the stream of the child, so the whole family shares one queue and batching worker.
+func (h *CloudWatchLogsHook) enqueue(msg string, priority bool, entry *logrus.Entry) error {
+	queued := h.intake(msg)
+	if entry != nil && h.eventDecorator != nil {
+		h.decorate(entry, &queued.event)
+	}
+	if h.parent != nil {
+		queued.pinned = true
+		return h.queueOwner().queue(queued, priority, entry)
+	}
+	return h.queue(queued, priority, entry)
+}
+
+// queue sends the event through the batched channel, or directly to Amazon CloudWatch if batching is disabled.
+func (h *CloudWatchLogsHook) queue(queued queuedEvent, priority bool, entry *logrus.Entry) error {
+	// write the message to the batched channel; the hook cannot be closed between checking and sending, so the
+	// batching worker is always there to receive the event
 	if h.ch != nil {
-		h.ch <- event
-		if h.err != nil {
-			lastErr := h.err
-			h.err = nil
-			return 0, fmt.Errorf("%v", *lastErr)
+		h.queueMutex.RLock()
+		if atomic.LoadInt32(&h.closed) != 0 {
+			h.queueMutex.RUnlock()
+			return ErrClosed
 		}
-		return len(msg), nil
+		atomic.AddInt64(&h.stats.queuedBytes, queuedSize(queued.event))
+		if priority && h.priorityCh != nil {
+			h.priorityCh <- queued
+		} else if h.burst != nil {
+			h.queueEvent(queued, entry)
+		} else {
+			h.ch <- queued
+		}
+		h.queueMutex.RUnlock()
+		return h.takeErr()
+	}
+
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return ErrClosed
 	}
 
 	// write the message directly to Amazon CloudWatch
+	defer h.lag.done(h.lag.track(aws.ToInt64(queued.event.Timestamp)))
+	target := h.bind(h.batchTarget(queued))
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	input := &cloudwatchlogs.PutLogEventsInput{
-		LogEvents:     []types.InputLogEvent{event},
-		LogGroupName:  aws.String(h.group),
-		LogStreamName: aws.String(h.stream),
-		SequenceToken: h.nextSequenceToken,
+	if h.writeTimeout > 0 {
+		ctx, cancel := context.WithTimeout(h.sendContext(), h.writeTimeout)
+		defer cancel()
+		h.writeCtx = ctx
+		defer func() { h.writeCtx = nil }()
 	}
-	result, err := h.client.PutLogEvents(context.TODO(), input)
-	if err != nil {
-		return 0, err
+	if err := h.deliver(target, []types.InputLogEvent{queued.event}); err != nil {
+		return h.writeError(target, err)
 	}
-	h.nextSequenceToken = result.NextSequenceToken
-	return len(msg), nil
+	h.latency.observe(aws.ToInt64(queued.event.Timestamp), h.clock.Now())
+	return nil
+}
+
+// Close stops any background workers and sends any queued log events to Amazon CloudWatch. Messages written after the
+// hook has been closed are rejected with ErrClosed. Any children created with Child are closed first.
+func (h *CloudWatchLogsHook) Close() error {
+	_, err := h.CloseContext(context.Background())
+	return err
+}
+
+// setErr records the error from the last batch so it can be returned by the next write.
+func (h *CloudWatchLogsHook) setErr(err error) {
+	h.errMutex.Lock()
+	defer h.errMutex.Unlock()
+	h.err = &err
+}
+
+// takeErr returns and clears the error from the last batch, if any.
+func (h *CloudWatchLogsHook) takeErr() error {
+	h.errMutex.Lock()
+	defer h.errMutex.Unlock()
+	if h.err == nil {
+		return nil
+	}
+	lastErr := h.err
+	h.err = nil
+	return *lastErr
+}
+
+// optionEnrichers returns the enrichers which add the fields of the other options, such as WithCaller, to each entry.
+// They run after those given with WithEnricher.
+func (h *CloudWatchLogsHook) optionEnrichers() []Enricher {
+	var enrichers []Enricher
+	if h.patternKey {
+		enrichers = append(enrichers, PatternKeyEnricher())
+	}
+	if h.eventIDExtractor != nil {
+		enrichers = append(enrichers, EventIDEnricher(h.eventIDExtractor))
+	}
+	if h.eventUIDGenerator != nil {
+		enrichers = append(enrichers, UniqueIDEnricher(h.eventUIDGenerator))
+	}
+	if h.severityMapping != nil {
+		enrichers = append(enrichers, SeverityEnricher(h.severityMapping))
+	}
+	if h.caller {
+		enrichers = append(enrichers, CallerEnricher(h.callerTrimPrefixes...))
+	}
+	if h.errorStacks {
+		enrichers = append(enrichers, ErrorStackEnricher())
+	}
+	if h.schemaVersion != "" {
+		enrichers = append(enrichers, SchemaVersionEnricher(h.schemaVersion))
+	}
+	if h.timestampLayout != "" || h.timestampLocation != nil {
+		layout := h.timestampLayout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		enrichers = append(enrichers, TimestampEnricher(layout, h.timestampLocation))
+	}
+	if h.kubernetes {
+		enrichers = append(enrichers, kubernetesEnricher(h.kubernetesMetadata))
+	}
+	if tag := h.routingTagFunc(); tag != nil {
+		enrichers = append(enrichers, RoutingTagEnricher(tag))
+	}
+	if h.valueMarshaler != nil {
+		enrichers = append(enrichers, ValueMarshalerEnricher(h.valueMarshaler))
+	}
+	if len(h.fieldTypes) > 0 {
+		enrichers = append(enrichers, FieldTypeEnricher(h.fieldTypes))
+	}
+	if h.fieldBudget > 0 {
+		// last, so that the fields added by the other enrichers are kept within the budget too
+		enrichers = append(enrichers, FieldBudgetEnricher(h.fieldBudget))
+	}
+	return enrichers
+}
+
+// prepareResources makes sure the log group and stream exist, creating them if needed (the relay worker is
+// responsible for this when relaying through SQS, there is nothing to create when using a different transport and,
+// when publishing to a destination or when they are provisioned ahead of time, they must already exist).
+func (h *CloudWatchLogsHook) prepareResources() error {
+	if h.destinationARN != "" {
+		if err := h.subscribeDestination(); err != nil {
+			return err
+		}
+	} else if h.noCreate && h.relay == "" && h.transport == nil {
+		if err := h.requireResources(); err != nil {
+			return err
+		}
+	} else if h.relay == "" && h.transport == nil {
+		if h.parent != nil && h.group == h.parent.group {
+			h.inheritGroupInfo()
+		} else if h.groupSelector == nil {
+			if err := h.createLogGroup(); err != nil {
+				return err
+			}
+		}
+		err := h.createLogStream()
+		if err != nil {
+			return err
+		}
+	}
+
+	if h.destinationARN == "" && h.relay == "" && h.transport == nil {
+		return h.tagStream(h.lookupContext(), streamTarget{group: h.group, stream: h.stream})
+	}
+	return nil
 }
 
 // createLogGroup will create the CloudWatch log group if it does not exist already
 func (h *CloudWatchLogsHook) createLogGroup() error {
 	// find any existing group and return it
-	group, err := h.findLogGroup()
+	group, err := h.findLogGroup(h.lookupContext(), h.group)
 	if err != nil {
 		return err
 	}
@@ -190,7 +1639,8 @@ func (h *CloudWatchLogsHook) createLogGroup() error {
 		return nil
 	}
 
-	// create the group
+	// create the group; another process starting at the same time may have beaten us to it, in which case it also
+	// sets the retention policy
 	input := &cloudwatchlogs.CreateLogGroupInput{
 		LogGroupName: aws.String(h.group),
 	}
@@ -200,17 +1650,63 @@ func (h *CloudWatchLogsHook) createLogGroup() error {
 	if h.kmsKeyID != "" {
 		input.KmsKeyId = aws.String(h.kmsKeyID)
 	}
-	_, err = h.client.CreateLogGroup(context.TODO(), input)
+	_, err = h.client.CreateLogGroup(h.lookupContext(), input)
+	var existsErr *types.ResourceAlreadyExistsException
+	if err != nil && !errors.As(err, &existsErr) {
+		return err
+	}
+	created := err == nil
+
+	// find the group so we know its ARN
+	found, err := h.retryCreation(func() (bool, error) {
+		group, err := h.findLogGroup(h.lookupContext(), h.group)
+		return group != nil, err
+	})
 	if err != nil {
 		return err
 	}
-	return h.setRetentionPolicy()
+	if !found {
+		h.debugf("log group %s was created but is not listed yet", h.group)
+	}
+	if !created {
+		return nil
+	}
+	_, err = h.retryCreation(func() (bool, error) {
+		err := h.setRetentionPolicy()
+		return err == nil, err
+	})
+	return err
 }
 
 // createLogStream will create the CloudWatch log group stream if it does not exist already.
 func (h *CloudWatchLogsHook) createLogStream() error {
+	// the group may not be visible yet if it was just created, and another process starting at the same time may
+	// create the stream first
+	create := func() (bool, error) {
+		_, err := h.client.CreateLogStream(h.lookupContext(), &cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(h.group),
+			LogStreamName: aws.String(h.stream),
+		})
+		var existsErr *types.ResourceAlreadyExistsException
+		if err != nil && errors.As(err, &existsErr) {
+			return true, nil
+		}
+		return err == nil, err
+	}
+
+	// without sequence tokens there is no need to describe the stream; simply create it
+	if h.noSeqTokens {
+		_, err := h.retryCreation(create)
+		return err
+	}
+
 	// find any existing stream and return it
-	stream, err := h.findLogStream()
+	var stream *types.LogStream
+	_, err := h.retryCreation(func() (bool, error) {
+		var err error
+		stream, err = h.findLogStream()
+		return err == nil, err
+	})
 	if err != nil {
 		return err
 	}
@@ -219,29 +1715,31 @@ func (h *CloudWatchLogsHook) createLogStream() error {
 	}
 
 	// create the stream
-	input := &cloudwatchlogs.CreateLogStreamInput{
-		LogGroupName:  aws.String(h.group),
-		LogStreamName: aws.String(h.stream),
-	}
-	_, err = h.client.CreateLogStream(context.TODO(), input)
-	if err != nil {
+	if _, err := h.retryCreation(create); err != nil {
 		return err
 	}
 
-	// find the stream so we update the current upload sequence token
-	_, err = h.findLogStream()
+	// find the stream so we update the current upload sequence token and know its ARN
+	found, err := h.retryCreation(func() (bool, error) {
+		stream, err := h.findLogStream()
+		return stream != nil, err
+	})
 	if err != nil {
 		return err
 	}
+	if !found {
+		h.debugf("log stream %s was created but is not listed yet", h.stream)
+	}
 	return nil
 }
 
-// findLogGroup finds the hook log group, if it exists. If it does not, it will return nil with no errors.
-func (h *CloudWatchLogsHook) findLogGroup() (*types.LogGroup, error) {
+// findLogGroup finds the log group with the given name, if it exists. If it does not, it will return nil with no
+// errors. The ARN of the hook log group is recorded whenever it is found.
+func (h *CloudWatchLogsHook) findLogGroup(ctx context.Context, name string) (*types.LogGroup, error) {
 	var nextToken *string = nil
 	for {
-		result, err := h.client.DescribeLogGroups(context.TODO(), &cloudwatchlogs.DescribeLogGroupsInput{
-			LogGroupNamePrefix: aws.String(h.group),
+		result, err := h.client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+			LogGroupNamePrefix: aws.String(name),
 			NextToken:          nextToken,
 		})
 		if err != nil {
@@ -249,7 +1747,10 @@ func (h *CloudWatchLogsHook) findLogGroup() (*types.LogGroup, error) {
 		}
 
 		for _, group := range result.LogGroups {
-			if aws.ToString(group.LogGroupName) == h.group {
+			if aws.ToString(group.LogGroupName) == name {
+				if name == h.group {
+					h.setGroupInfo(&group)
+				}
 				return &group, nil
 			}
 		}
@@ -266,7 +1767,7 @@ func (h *CloudWatchLogsHook) findLogGroup() (*types.LogGroup, error) {
 func (h *CloudWatchLogsHook) findLogStream() (*types.LogStream, error) {
 	var nextToken *string = nil
 	for {
-		result, err := h.client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
+		result, err := h.client.DescribeLogStreams(h.lookupContext(), &cloudwatchlogs.DescribeLogStreamsInput{
 			LogGroupName:        aws.String(h.group),
 			LogStreamNamePrefix: aws.String(h.stream),
 			NextToken:           nextToken,
@@ -278,6 +1779,7 @@ func (h *CloudWatchLogsHook) findLogStream() (*types.LogStream, error) {
 		for _, stream := range result.LogStreams {
 			if aws.ToString(stream.LogStreamName) == h.stream {
 				h.nextSequenceToken = stream.UploadSequenceToken
+				h.setStreamInfo(&stream)
 				return &stream, nil
 			}
 		}
@@ -290,32 +1792,218 @@ func (h *CloudWatchLogsHook) findLogStream() (*types.LogStream, error) {
 	return nil, nil
 }
 
+// resolvedQueueCapacity returns the capacity of the batch queue, which is the default unless WithQueueCapacity was
+// given.
+func (h *CloudWatchLogsHook) resolvedQueueCapacity() int {
+	if h.queueCapacity > 0 {
+		return h.queueCapacity
+	}
+	return defaultQueueCapacity
+}
+
 // putBatch is responsible for batching log events and sending them on a set frequency.
-func (h *CloudWatchLogsHook) putBatch(ticker <-chan time.Time) {
-	var batch []types.InputLogEvent
-	size := 0
+func (h *CloudWatchLogsHook) putBatch() {
+	defer h.workers.Done()
+	wait := h.nextFlush(h.clock.Now())
+	timer := h.clock.NewTimer(wait)
+	defer timer.Stop()
+	armed := h.clock.Now().Add(wait)
+	batches := newBatchSet(h.timestampBuckets)
+	var burstSignal <-chan struct{}
+	if h.burst != nil {
+		burstSignal = h.burst.signal
+	}
+
+	// the timer is kept armed for the earliest deadline of the batches being collected
+	schedule := func() {
+		next, ok := batches.next()
+		if !ok || (!armed.IsZero() && !next.Before(armed)) {
+			return
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(next.Sub(h.clock.Now()))
+		armed = next
+	}
+	// each batch being sent, including any retries, is tracked until it completes so that a drain can wait for it
+	var flyingMutex sync.Mutex
+	flying := make(map[chan struct{}]struct{})
+	flush := func(b *targetBatch) {
+		batches.remove(b)
+		atomic.AddInt64(&h.stats.batchedBytes, -int64(b.size))
+		atomic.AddInt64(&h.stats.inFlightBytes, int64(b.size))
+		sent := make(chan struct{})
+		flyingMutex.Lock()
+		flying[sent] = struct{}{}
+		flyingMutex.Unlock()
+		h.inflight.Add(1)
+		go func() {
+			defer h.inflight.Done()
+			defer func() {
+				flyingMutex.Lock()
+				delete(flying, sent)
+				flyingMutex.Unlock()
+				close(sent)
+			}()
+			defer h.lag.done(b.lagID)
+			defer atomic.AddInt64(&h.stats.inFlightBytes, -int64(b.size))
+			h.sendBatch(b.events, b.seqs, b.target)
+		}()
+	}
+	add := func(p queuedEvent) *targetBatch {
+		// events are batched by the stream they were queued for unless they follow the hook to its current stream
+		target := h.batchTarget(p)
+		atomic.AddInt64(&h.stats.queuedBytes, -queuedSize(p.event))
+		timestamp := aws.ToInt64(p.event.Timestamp)
+		bucket, late := int64(0), false
+		if h.timestampBuckets > 0 {
+			bucket = timestampBucket(timestamp, h.timestampBuckets)
+			if late = batches.late(target, bucket); late {
+				p.event.Message = aws.String(markLate(*p.event.Message))
+			}
+		}
+		eventSize := len(*p.event.Message) + EventOverhead
+		b := batches.get(target, bucket)
+		if b != nil && !b.fits(eventSize, timestamp) {
+			flush(b)
+			b = nil
+		}
+		if b == nil {
+			now := h.clock.Now()
+			wait := h.nextFlush(now)
+			if h.timestampBuckets > 0 && !late {
+				// the batch is due when the bucket ends, relative to the timestamp of its first event
+				wait = time.Duration(bucket-timestamp)*time.Millisecond + h.timestampBuckets
+			}
+			b = batches.add(target, bucket, now.Add(wait))
+			b.lagID = h.lag.track(timestamp)
+			schedule()
+		}
+		b.extend(len(b.events), eventSize, timestamp)
+		atomic.AddInt64(&h.stats.batchedBytes, int64(eventSize))
+		b.events = append(b.events, p.event)
+		b.seqs = append(b.seqs, p.seq)
+		if h.adapter != nil {
+			h.adapter.queued++
+		}
+		return b
+	}
+	addPriority := func(p queuedEvent) {
+		// send the batches holding priority events as soon as the priority queue is empty
+		touched := []*targetBatch{add(p)}
+		for {
+			select {
+			case p := <-h.priorityCh:
+				touched = append(touched, add(p))
+			default:
+				for _, b := range touched {
+					if batches.get(b.target, b.bucket) == b {
+						flush(b)
+					}
+				}
+				return
+			}
+		}
+	}
+
 	for {
+		// priority events always jump ahead of queued events
+		select {
+		case p := <-h.priorityCh:
+			addPriority(p)
+			continue
+		default:
+		}
+
 		select {
+		case p := <-h.priorityCh:
+			addPriority(p)
+
 		case p := <-h.ch:
-			messageSize := len(*p.Message) + 26
-			if size+messageSize > 1048576 || len(batch) == 10000 {
-				go h.sendBatch(batch)
-				batch = nil
-				size = 0
+			add(p)
+
+		case <-burstSignal:
+			for p, ok := h.burst.pop(); ok; p, ok = h.burst.pop() {
+				add(p)
+			}
+
+		case <-timer.C():
+			armed = time.Time{}
+			for _, b := range batches.expired(h.clock.Now()) {
+				flush(b)
 			}
-			batch = append(batch, p)
-			size += messageSize
+			h.adaptBatching()
+			schedule()
 
-		case <-ticker:
-			go h.sendBatch(batch)
-			batch = nil
-			size = 0
+		case drained := <-h.drainCh:
+			// send the events queued before the drain was requested, leaving those queued since for later
+			for n := len(h.priorityCh); n > 0; n-- {
+				add(<-h.priorityCh)
+			}
+			for n := len(h.ch); n > 0; n-- {
+				add(<-h.ch)
+			}
+			if h.burst != nil {
+				for n := h.burst.len(); n > 0; n-- {
+					p, ok := h.burst.pop()
+					if !ok {
+						break
+					}
+					add(p)
+				}
+			}
+			// wait for these batches and for those already being sent, which may still be retrying
+			for _, b := range batches.expired(time.Time{}) {
+				flush(b)
+			}
+			flyingMutex.Lock()
+			pending := make([]chan struct{}, 0, len(flying))
+			for sent := range flying {
+				pending = append(pending, sent)
+			}
+			flyingMutex.Unlock()
+			go func() {
+				for _, sent := range pending {
+					<-sent
+				}
+				close(drained)
+			}()
+
+		case <-h.done:
+			// drain anything left in the queues and send it before stopping
+			for {
+				select {
+				case p := <-h.priorityCh:
+					add(p)
+				case p := <-h.ch:
+					add(p)
+				default:
+					if h.burst != nil {
+						for p, ok := h.burst.pop(); ok; p, ok = h.burst.pop() {
+							add(p)
+						}
+					}
+					for _, b := range batches.expired(time.Time{}) {
+						flush(b)
+					}
+					return
+				}
+			}
 		}
 	}
 }
 
-// sendBatch sends the batch of log events to Amazon CloudWatch.
-func (h *CloudWatchLogsHook) sendBatch(batch []types.InputLogEvent) {
+// sendBatch sends the batch of log events to Amazon CloudWatch. The events are sent in the order in which they were
+// queued, given by their sequence numbers, regardless of whether they were written by Fire or Write. The batch is
+// delivered to the stream it was queued for, or the current stream, according to the retarget policy.
+func (h *CloudWatchLogsHook) sendBatch(batch []types.InputLogEvent, seqs []uint64, target streamTarget) {
+	defer putBatchSlice(batch)
+	defer putSeqSlice(seqs)
+	target = h.bind(target)
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
@@ -324,19 +2012,174 @@ func (h *CloudWatchLogsHook) sendBatch(batch []types.InputLogEvent) {
 		return
 	}
 
+	// events must be in chronological order within a batch, which is the order of intake
+	sort.Sort(orderedBatch{events: batch, seqs: seqs})
+
+	// the deadline for closing the hook passed while the batch was waiting to be sent
+	if h.abandoning() {
+		h.lastRequest = requestIDs{}
+		h.setErr(h.abandonEvents(batch))
+		return
+	}
+
 	// send events
+	oldest := aws.ToInt64(batch[0].Timestamp)
+	err := h.deliver(target, batch)
+	if err == nil {
+		h.latency.observe(oldest, h.clock.Now())
+	}
+	if err = h.reportErr(err); err != nil {
+		h.setErr(err)
+	}
+	if h.adapter != nil {
+		atomic.AddInt64(&h.adapter.sent, int64(len(batch)))
+	}
+}
+
+// deliver encrypts the events if batch encryption is enabled and sends them to the given stream. The events must be
+// in chronological order; events spanning more than 24 hours are sent in several batches. The first error is returned
+// once all of the batches have been attempted. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) deliver(target streamTarget, events []types.InputLogEvent) error {
+	h.bindBatch(target)
+	var firstErr error
+	for _, slice := range spanSlices(events) {
+		// envelopes are larger than the events they hold, so they are batched again within the limits
+		sealed, err := h.seal(slice)
+		for _, batch := range limitSlices(sealed) {
+			if sendErr := h.sendEvents(batch); sendErr != nil && err == nil {
+				err = sendErr
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sendEvents sends the events to Amazon CloudWatch, retrying failed uploads according to the backoff policy. Batches
+// rejected as invalid are split to isolate the offending events. Events which still cannot be delivered, or which are
+// rejected individually, are handed to the dead letter sink, if one is configured. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) sendEvents(events []types.InputLogEvent) error {
+	var delay time.Duration
+	var expired <-chan struct{}
+	if h.writeCtx != nil {
+		expired = h.writeCtx.Done()
+	}
+	for attempt := 1; ; attempt++ {
+		start := h.clock.Now()
+		put := h.putLogEvents
+		if h.sharedStream {
+			put = h.putSharedLogEvents
+		}
+		rejected, err := put(events)
+		if err == nil {
+			atomic.AddInt64(&h.stats.delivered, int64(len(events)-len(rejectedEvents(events, rejected))))
+			h.countShipped(acceptedEvents(events, rejected))
+			if h.verifier != nil {
+				h.verifier.sample(h.boundTarget(), acceptedEvents(events, rejected), h.clock.Now())
+			}
+			if h.deliveryCallback != nil {
+				h.deliveryCallback(h.newBatchReceipt(events, rejected, attempt, h.clock.Now().Sub(start)))
+			}
+			return h.quarantine(events, rejected)
+		}
+		if h.abandoning() {
+			return h.abandonEvents(events)
+		}
+		retry := h.backoff != nil && isRetryable(err)
+		if retry {
+			delay, retry = h.backoff.Next(attempt, delay)
+		}
+		if !retry && isInvalidParameter(err) {
+			return h.sendSplit(events, err)
+		}
+		if !retry {
+			return h.deadLetter(events, err)
+		}
+		timer := h.clock.NewTimer(delay)
+		select {
+		case <-timer.C():
+		case <-expired:
+			timer.Stop()
+			return h.deadLetter(events, err)
+		case <-h.abandon:
+			timer.Stop()
+			return h.abandonEvents(events)
+		}
+	}
+}
+
+// deadLetter hands undeliverable events to the dead letter sink and returns the original error. The caller must hold
+// the mutex.
+func (h *CloudWatchLogsHook) deadLetter(events []types.InputLogEvent, err error) error {
+	if h.deadLetters == nil {
+		return err
+	}
+	target := h.boundTarget()
+	letter := DeadLetter{
+		Group:             target.group,
+		Stream:            target.stream,
+		Events:            events,
+		Err:               err,
+		Time:              h.clock.Now(),
+		RequestID:         h.lastRequest.id,
+		ExtendedRequestID: h.lastRequest.extended,
+	}
+	if sinkErr := h.deadLetters.Send(h.putContext(), letter); sinkErr != nil {
+		return fmt.Errorf("%w (dead letter sink failed: %v)", err, sinkErr)
+	}
+	return err
+}
+
+// seqTokens reports whether the upload sequence token is managed, either because it was not disabled or because the
+// service turned out to still require it. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) seqTokens() bool {
+	return !h.noSeqTokens || h.tokenFallback
+}
+
+// putLogEvents sends the events to Amazon CloudWatch, tracking the upload sequence token unless it has been disabled.
+// Any information about individual events rejected by Amazon CloudWatch is returned. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) putLogEvents(events []types.InputLogEvent) (*types.RejectedLogEventsInfo, error) {
+	h.lastRequest = requestIDs{}
+	if h.relay != "" {
+		return nil, h.relayEvents(events)
+	}
+	if h.transport != nil {
+		return nil, h.transport.Send(h.putContext(), toEvents(events))
+	}
+
+	target := h.boundTarget()
 	input := &cloudwatchlogs.PutLogEventsInput{
-		LogEvents:     batch,
-		LogGroupName:  aws.String(h.group),
-		LogStreamName: aws.String(h.stream),
-		SequenceToken: h.nextSequenceToken,
+		LogEvents:     events,
+		LogGroupName:  aws.String(target.group),
+		LogStreamName: aws.String(target.stream),
+	}
+	if h.seqTokens() {
+		input.SequenceToken = h.nextSequenceToken
 	}
-	result, err := h.client.PutLogEvents(context.TODO(), input)
+	result, err := h.client.PutLogEvents(h.putContext(), input)
 	if err != nil {
-		h.err = &err
-	} else {
-		h.nextSequenceToken = result.NextSequenceToken
+		h.lastRequest = errorRequestIDs(err)
+
+		// the service still requires sequence tokens so fall back to managing them and try again
+		var tokenErr *types.InvalidSequenceTokenException
+		if !h.seqTokens() && errors.As(err, &tokenErr) {
+			h.tokenFallback = true
+			h.nextSequenceToken = tokenErr.ExpectedSequenceToken
+			return h.putLogEvents(events)
+		}
+
+		// pick up the expected token so the next attempt can succeed
+		if errors.As(err, &tokenErr) {
+			h.tokenConflict(aws.ToString(input.SequenceToken), aws.ToString(tokenErr.ExpectedSequenceToken))
+			h.nextSequenceToken = tokenErr.ExpectedSequenceToken
+		}
+		return nil, err
 	}
+	h.lastRequest = responseRequestIDs(result.ResultMetadata)
+	h.nextSequenceToken = result.NextSequenceToken
+	return result.RejectedLogEventsInfo, nil
 }
 
 // setRetentionPolicy updates the retention policy for the log group.
@@ -347,12 +2190,12 @@ func (h *CloudWatchLogsHook) setRetentionPolicy() error {
 			LogGroupName:    aws.String(h.group),
 			RetentionInDays: aws.Int32(h.retentionDays),
 		}
-		_, err = h.client.PutRetentionPolicy(context.TODO(), input)
+		_, err = h.client.PutRetentionPolicy(h.lookupContext(), input)
 	} else {
 		input := &cloudwatchlogs.DeleteRetentionPolicyInput{
 			LogGroupName: aws.String(h.group),
 		}
-		_, err = h.client.DeleteRetentionPolicy(context.TODO(), input)
+		_, err = h.client.DeleteRetentionPolicy(h.lookupContext(), input)
 	}
 	if err != nil {
 		return err
@@ -1,3 +1,18 @@
 package cloudwatchhook
 
-// TODO: implement unit testing for the hook
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// newTestHook creates a hook from the options the same way NewCloudWatchLogsHook does, without starting it, so that
+// it never interacts with AWS. Pair it with WithTransport or WithSQSRelay to exercise the sending path.
+func newTestHook(t testing.TB, group, stream string, options ...CloudWatchLogsHookOption) *CloudWatchLogsHook {
+	t.Helper()
+	h, err := newHook(aws.Config{}, group, stream, options...)
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	return h
+}
@@ -0,0 +1,57 @@
+package cloudwatchhook
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// queuedEvent is an event waiting in the intake queue to be batched, along with its intake sequence number and the
+// stream which was active when it was emitted. Events queued by a child are pinned to the stream of the child, whatever
+// the retarget policy.
+type queuedEvent struct {
+	seq    uint64
+	target streamTarget
+	pinned bool
+	event  types.InputLogEvent
+}
+
+// intake stamps the message with its timestamp and sequence number. Both are taken together, and timestamps never go
+// backwards even if the system clock does, so ordering events by sequence number also orders them chronologically,
+// whichever path the event arrived through. They are taken from the hook owning the queue, so that the events of a
+// family of hooks, which may be batched together, share a single sequence.
+func (h *CloudWatchLogsHook) intake(msg string) queuedEvent {
+	owner := h.queueOwner()
+	now := owner.clock.Now().UnixNano() / int64(time.Millisecond)
+	if owner != h {
+		h.intakeMutex.Lock()
+		defer h.intakeMutex.Unlock()
+	}
+	owner.intakeMutex.Lock()
+	defer owner.intakeMutex.Unlock()
+	if now < owner.lastTimestamp {
+		now = owner.lastTimestamp
+	}
+	owner.lastTimestamp = now
+	owner.intakeSeq++
+	return queuedEvent{seq: owner.intakeSeq, target: h.currentTarget(), event: newEvent(msg, now)}
+}
+
+// orderedBatch sorts a batch of events by their intake sequence numbers.
+type orderedBatch struct {
+	events []types.InputLogEvent
+	seqs   []uint64
+}
+
+func (b orderedBatch) Len() int {
+	return len(b.events)
+}
+
+func (b orderedBatch) Less(i, j int) bool {
+	return b.seqs[i] < b.seqs[j]
+}
+
+func (b orderedBatch) Swap(i, j int) {
+	b.events[i], b.events[j] = b.events[j], b.events[i]
+	b.seqs[i], b.seqs[j] = b.seqs[j], b.seqs[i]
+}
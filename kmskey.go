@@ -0,0 +1,90 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// kmsKeyARNRegex matches the ARN of a KMS key, which is the only form of key ID accepted by AssociateKmsKey.
+var kmsKeyARNRegex = regexp.MustCompile(`^arn:[a-z-]+:kms:[a-z0-9-]+:[0-9]{12}:key/[A-Za-z0-9-]+$`)
+
+// KMSKeyAPI is the part of the Amazon CloudWatch Logs API used to change the KMS key of a log group. It is only
+// required of the client by RotateKMSKey and RemoveKMSKey.
+type KMSKeyAPI interface {
+	AssociateKmsKey(ctx context.Context, params *cloudwatchlogs.AssociateKmsKeyInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.AssociateKmsKeyOutput, error)
+	DisassociateKmsKey(ctx context.Context, params *cloudwatchlogs.DisassociateKmsKeyInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DisassociateKmsKeyOutput, error)
+}
+
+// RotateKMSKey encrypts the data ingested into the current log group of the hook from now on with the KMS key with the
+// given ARN, which must have a key policy allowing Amazon CloudWatch Logs to use it. Data already ingested remains
+// encrypted with the previous key, which must stay enabled for it to be read. The new key replaces the previous one in
+// a single call, so the group is never left without a key, and the change is checked by describing the group
+// afterwards. Rotating to the key already in use does nothing. Groups created by the hook later still use the key
+// given by WithGroupKmsKeyID.
+func (h *CloudWatchLogsHook) RotateKMSKey(ctx context.Context, newKeyID string) error {
+	if len(newKeyID) > maxKmsKeyIDLength || !kmsKeyARNRegex.MatchString(newKeyID) {
+		return fmt.Errorf("Invalid KMS key ID %q: must be the ARN of a KMS key", newKeyID)
+	}
+	return h.changeKMSKey(ctx, newKeyID)
+}
+
+// RemoveKMSKey removes the KMS key from the current log group of the hook, after which the data ingested into it is
+// encrypted with keys owned by the service. Data already ingested remains encrypted with the removed key, which must
+// stay enabled for it to be read. Removing the key of a group which has none does nothing.
+func (h *CloudWatchLogsHook) RemoveKMSKey(ctx context.Context) error {
+	return h.changeKMSKey(ctx, "")
+}
+
+// changeKMSKey associates the key with the current log group, or disassociates its key if the key ID is empty, and
+// checks that the group reports the change.
+func (h *CloudWatchLogsHook) changeKMSKey(ctx context.Context, newKeyID string) error {
+	client, ok := h.client.(KMSKeyAPI)
+	if !ok {
+		return fmt.Errorf("Unable to change KMS key: client does not support KMS keys")
+	}
+	h.intakeMutex.Lock()
+	group := h.currentTarget().group
+	h.intakeMutex.Unlock()
+
+	current, err := h.findLogGroup(ctx, group)
+	if err != nil {
+		return fmt.Errorf("Unable to change KMS key of log group %s: %v", group, err)
+	}
+	if current == nil {
+		return fmt.Errorf("Unable to change KMS key: log group %s does not exist", group)
+	}
+	if aws.ToString(current.KmsKeyId) == newKeyID {
+		return nil
+	}
+
+	if newKeyID == "" {
+		_, err = client.DisassociateKmsKey(ctx, &cloudwatchlogs.DisassociateKmsKeyInput{
+			LogGroupName: aws.String(group),
+		})
+	} else {
+		_, err = client.AssociateKmsKey(ctx, &cloudwatchlogs.AssociateKmsKeyInput{
+			LogGroupName: aws.String(group),
+			KmsKeyId:     aws.String(newKeyID),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to change KMS key of log group %s: %v", group, err)
+	}
+
+	// describing the group again also refreshes the group info reported by the hook
+	changed, err := h.findLogGroup(ctx, group)
+	if err != nil {
+		return fmt.Errorf("Unable to verify KMS key of log group %s: %v", group, err)
+	}
+	if changed == nil || aws.ToString(changed.KmsKeyId) != newKeyID {
+		return fmt.Errorf("Unable to verify KMS key of log group %s: the group does not report the new key", group)
+	}
+	h.debugf("changed KMS key of log group %s from %q to %q", group, aws.ToString(current.KmsKeyId), newKeyID)
+	return nil
+}
@@ -0,0 +1,54 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestRotateKMSKey(t *testing.T) {
+	const (
+		oldKey = "arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+		newKey = "arn:aws:kms:us-east-1:111111111111:key/0987dcba-09fe-87dc-65ba-ab0987654321"
+	)
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithGroupKmsKeyID(oldKey))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	ctx := context.Background()
+
+	if err := hook.RotateKMSKey(ctx, "alias/logs"); err == nil {
+		t.Errorf("expected a key which is not an ARN to be rejected")
+	}
+	if err := hook.RotateKMSKey(ctx, newKey); err != nil {
+		t.Fatalf("unable to rotate key: %v", err)
+	}
+	if key := client.KMSKey("group"); key != newKey {
+		t.Errorf("expected the new key to be associated, got %q", key)
+	}
+	if err := hook.RotateKMSKey(ctx, newKey); err != nil {
+		t.Errorf("unexpected error rotating to the same key: %v", err)
+	}
+	if n := client.Calls("AssociateKmsKey"); n != 1 {
+		t.Errorf("expected a single association, got %d", n)
+	}
+
+	if err := hook.RotateKMSKey(ctx, ""); err == nil {
+		t.Errorf("expected an empty key to be rejected")
+	}
+	if err := hook.RemoveKMSKey(ctx); err != nil {
+		t.Fatalf("unable to remove key: %v", err)
+	}
+	if key := client.KMSKey("group"); key != "" || client.Calls("DisassociateKmsKey") != 1 {
+		t.Errorf("expected the key to be disassociated, got %q", key)
+	}
+	if err := hook.RemoveKMSKey(ctx); err != nil || client.Calls("DisassociateKmsKey") != 1 {
+		t.Errorf("expected removing the key again to do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// KubernetesField is the name of the field holding the Kubernetes metadata added by the WithKubernetesMetadata option.
+const KubernetesField = "kubernetes"
+
+// serviceAccountDir is where Kubernetes mounts the service account of the pod, including its namespace.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesMetadata identifies the Kubernetes pod and container running the hook.
+type kubernetesMetadata struct {
+	cluster   string
+	namespace string
+	pod       string
+	node      string
+	container string
+}
+
+// lookupKubernetesMetadata reads the metadata of the pod from the environment variables conventionally populated with
+// the downward API, falling back to the namespace of the service account and the hostname of the pod, which is its
+// name unless overridden.
+func lookupKubernetesMetadata() kubernetesMetadata {
+	metadata := kubernetesMetadata{
+		cluster:   os.Getenv("CLUSTER_NAME"),
+		namespace: os.Getenv("POD_NAMESPACE"),
+		pod:       os.Getenv("POD_NAME"),
+		node:      os.Getenv("NODE_NAME"),
+		container: os.Getenv("CONTAINER_NAME"),
+	}
+	if metadata.namespace == "" {
+		if b, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+			metadata.namespace = strings.TrimSpace(string(b))
+		}
+	}
+	if metadata.pod == "" && (metadata.namespace != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "") {
+		metadata.pod, _ = os.Hostname()
+	}
+	return metadata
+}
+
+// values returns the metadata by name, omitting anything unknown.
+func (m kubernetesMetadata) values() map[string]string {
+	values := map[string]string{}
+	for name, value := range map[string]string{
+		"cluster":   m.cluster,
+		"namespace": m.namespace,
+		"pod":       m.pod,
+		"node":      m.node,
+		"container": m.container,
+	} {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// expand replaces the {cluster}, {namespace}, {pod}, {node} and {container} placeholders in a group or stream name with
+// the metadata, failing if a placeholder is used whose value is not known.
+func (m kubernetesMetadata) expand(name string) (string, error) {
+	values := m.values()
+	for _, key := range []string{"cluster", "namespace", "pod", "node", "container"} {
+		placeholder := "{" + key + "}"
+		if !strings.Contains(name, placeholder) {
+			continue
+		}
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("Unable to expand %s in %s: the Kubernetes %s is not known", placeholder, name, key)
+		}
+		name = strings.Replace(name, placeholder, value, -1)
+	}
+	return name, nil
+}
+
+// kubernetesEnricher returns an enricher which adds the metadata to the kubernetes field of each entry.
+func kubernetesEnricher(metadata kubernetesMetadata) Enricher {
+	values := metadata.values()
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		if len(values) > 0 {
+			fields[KubernetesField] = values
+		}
+	})
+}
+
+// expandKubernetesNames replaces the Kubernetes placeholders in the names of the target, if the hook was created with
+// the WithKubernetesMetadata option.
+func (h *CloudWatchLogsHook) expandKubernetesNames(target streamTarget) (streamTarget, error) {
+	if !h.kubernetes {
+		return target, nil
+	}
+	group, err := h.kubernetesMetadata.expand(target.group)
+	if err != nil {
+		return target, err
+	}
+	stream, err := h.kubernetesMetadata.expand(target.stream)
+	if err != nil {
+		return target, err
+	}
+	return streamTarget{group: group, stream: stream}, nil
+}
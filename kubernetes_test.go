@@ -0,0 +1,46 @@
+package cloudwatchhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestKubernetesMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serviceaccount")
+	if err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("payments\n"), 0644); err != nil {
+		t.Fatalf("unable to write namespace: %v", err)
+	}
+	defer func(previous string) { serviceAccountDir = previous }(serviceAccountDir)
+	serviceAccountDir = dir
+	env := map[string]string{"CLUSTER_NAME": "prod", "POD_NAME": "api-7d9f", "NODE_NAME": "", "CONTAINER_NAME": ""}
+	for name, value := range env {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	metadata := lookupKubernetesMetadata()
+	if metadata.namespace != "payments" || metadata.pod != "api-7d9f" || metadata.cluster != "prod" {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	}
+	if group, err := metadata.expand("/eks/{cluster}/{namespace}"); err != nil || group != "/eks/prod/payments" {
+		t.Errorf("expected the group name to be expanded, got %s (%v)", group, err)
+	}
+	if _, err := metadata.expand("{node}"); err == nil {
+		t.Errorf("expected an error expanding an unknown node")
+	}
+
+	fields := logrus.Fields{}
+	kubernetesEnricher(metadata).Enrich(&logrus.Entry{}, fields)
+	values, _ := fields[KubernetesField].(map[string]string)
+	if len(values) != 3 || values["pod"] != "api-7d9f" {
+		t.Errorf("expected the known metadata to be added, got %v", fields)
+	}
+}
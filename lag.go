@@ -0,0 +1,96 @@
+package cloudwatchhook
+
+import (
+	"sync"
+	"time"
+)
+
+// lagTracker is used to track the timestamps of the oldest events in the batches which have not yet been delivered to
+// Amazon CloudWatch, so that the delivery lag of the hook can be computed.
+type lagTracker struct {
+	mutex   sync.Mutex
+	next    uint64
+	pending map[uint64]int64
+}
+
+// newLagTracker creates a tracker with no pending batches.
+func newLagTracker() *lagTracker {
+	return &lagTracker{
+		pending: map[uint64]int64{},
+	}
+}
+
+// track registers a pending batch whose oldest event has the given timestamp in milliseconds and returns the ID used
+// to mark it as done.
+func (t *lagTracker) track(oldest int64) uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.next++
+	t.pending[t.next] = oldest
+	return t.next
+}
+
+// done marks the pending batch as delivered or otherwise finished with.
+func (t *lagTracker) done(id uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.pending, id)
+}
+
+// lag returns the age of the oldest pending event at the given time, or 0 if nothing is pending.
+func (t *lagTracker) lag(now time.Time) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var oldest int64
+	for _, ts := range t.pending {
+		if oldest == 0 || ts < oldest {
+			oldest = ts
+		}
+	}
+	if oldest == 0 {
+		return 0
+	}
+	lag := now.Sub(time.Unix(0, oldest*int64(time.Millisecond)))
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// DeliveryLag returns the age of the oldest event written to the hook which has not yet been delivered to Amazon
+// CloudWatch, or 0 if every event has been delivered. A steadily growing lag is a direct signal that delivery is
+// falling behind.
+func (h *CloudWatchLogsHook) DeliveryLag() time.Duration {
+	return h.lag.lag(h.clock.Now())
+}
+
+// lagCheckInterval returns how often the delivery lag is checked against the given threshold.
+func lagCheckInterval(threshold time.Duration) time.Duration {
+	interval := threshold / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	return interval
+}
+
+// monitorLag periodically checks the delivery lag and calls the lag alarm callback whenever it rises above the
+// threshold. The callback is not called again until the lag has fallen back below the threshold.
+func (h *CloudWatchLogsHook) monitorLag(interval time.Duration) {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(interval)
+	defer timer.Stop()
+	alarmed := false
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-timer.C():
+			timer.Reset(interval)
+			lag := h.DeliveryLag()
+			if lag > h.lagThreshold && !alarmed {
+				h.lagCallback(lag)
+			}
+			alarmed = lag > h.lagThreshold
+		}
+	}
+}
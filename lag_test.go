@@ -0,0 +1,28 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLagTracker(t *testing.T) {
+	tracker := newLagTracker()
+	now := time.Unix(100, 0)
+	if lag := tracker.lag(now); lag != 0 {
+		t.Errorf("expected no lag with nothing pending, got %v", lag)
+	}
+
+	first := tracker.track(90000)
+	second := tracker.track(95000)
+	if lag := tracker.lag(now); lag != 10*time.Second {
+		t.Errorf("expected a lag of 10s, got %v", lag)
+	}
+	tracker.done(first)
+	if lag := tracker.lag(now); lag != 5*time.Second {
+		t.Errorf("expected a lag of 5s once the oldest batch is done, got %v", lag)
+	}
+	tracker.done(second)
+	if lag := tracker.lag(now); lag != 0 {
+		t.Errorf("expected no lag once every batch is done, got %v", lag)
+	}
+}
@@ -0,0 +1,133 @@
+package cloudwatchhook
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the buckets of the delivery latency histogram. Latencies above the last bound
+// are counted in an overflow bucket.
+var latencyBounds = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+}
+
+// LatencyHistogram is a snapshot of the distribution of the time taken to deliver batches, from when the oldest event
+// of a batch was written to the hook until Amazon CloudWatch accepted the batch.
+type LatencyHistogram struct {
+	// Bounds are the upper bounds of the buckets and Counts the number of batches in each bucket, which are not
+	// cumulative. Counts has an extra bucket for the batches slower than the last bound.
+	Bounds []time.Duration `json:"bounds"`
+	Counts []uint64        `json:"counts"`
+
+	// Count is the number of batches delivered and Sum their total latency.
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+}
+
+// Buckets returns the cumulative count of batches in each bucket keyed by its upper bound in seconds, which is the
+// form taken by the histograms of the Prometheus client.
+func (h LatencyHistogram) Buckets() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(h.Bounds))
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		buckets[bound.Seconds()] = cumulative
+	}
+	return buckets
+}
+
+// Quantile estimates the latency below which the given fraction of batches, such as 0.99, were delivered by
+// interpolating within the bucket holding it. It returns 0 if no batches have been delivered, and the last bound if
+// the quantile falls in the overflow bucket.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var cumulative uint64
+	for i, count := range h.Counts {
+		if float64(cumulative+count) < rank || count == 0 {
+			cumulative += count
+			continue
+		}
+		if i == len(h.Bounds) {
+			break
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = h.Bounds[i-1]
+		}
+		fraction := (rank - float64(cumulative)) / float64(count)
+		return lower + time.Duration(fraction*float64(h.Bounds[i]-lower))
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// latencyRecorder collects the delivery latency of batches into a histogram.
+type latencyRecorder struct {
+	mutex  sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+}
+
+// newLatencyRecorder creates a recorder with no batches.
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{
+		counts: make([]uint64, len(latencyBounds)+1),
+		count:  0,
+		sum:    0,
+	}
+}
+
+// observe records the delivery of a batch whose oldest event was written to the hook at the given time in
+// milliseconds. Hooks built without a recorder, as in tests, record nothing.
+func (r *latencyRecorder) observe(oldest int64, now time.Time) {
+	if r == nil {
+		return
+	}
+	latency := now.Sub(time.Unix(0, oldest*int64(time.Millisecond)))
+	if latency < 0 {
+		latency = 0
+	}
+	i := 0
+	for i < len(latencyBounds) && latency > latencyBounds[i] {
+		i++
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.counts[i]++
+	r.count++
+	r.sum += latency
+}
+
+// snapshot returns a copy of the histogram.
+func (r *latencyRecorder) snapshot() LatencyHistogram {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return LatencyHistogram{
+		Bounds: append([]time.Duration{}, latencyBounds...),
+		Counts: append([]uint64{}, r.counts...),
+		Count:  r.count,
+		Sum:    r.sum,
+	}
+}
+
+// DeliveryLatency returns the histogram of the time taken to deliver batches written to the hook with Fire or Write,
+// from when the oldest event of each batch was written until it was accepted. Batches which fail are not counted.
+func (h *CloudWatchLogsHook) DeliveryLatency() LatencyHistogram {
+	return h.latency.snapshot()
+}
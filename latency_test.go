@@ -0,0 +1,41 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorder(t *testing.T) {
+	recorder := newLatencyRecorder()
+	now := time.Unix(100, 0)
+	if p99 := recorder.snapshot().Quantile(0.99); p99 != 0 {
+		t.Errorf("expected no latency with nothing delivered, got %v", p99)
+	}
+
+	// 90 fast batches and 10 slow ones
+	for i := 0; i < 90; i++ {
+		recorder.observe(99995, now)
+	}
+	for i := 0; i < 10; i++ {
+		recorder.observe(97000, now)
+	}
+	histogram := recorder.snapshot()
+	if histogram.Count != 100 || histogram.Sum != 90*5*time.Millisecond+10*3*time.Second {
+		t.Errorf("unexpected count %d and sum %v", histogram.Count, histogram.Sum)
+	}
+	if p50 := histogram.Quantile(0.5); p50 <= 0 || p50 > 10*time.Millisecond {
+		t.Errorf("expected a p50 within the first bucket, got %v", p50)
+	}
+	if p99 := histogram.Quantile(0.99); p99 <= 2500*time.Millisecond || p99 > 5*time.Second {
+		t.Errorf("expected a p99 between 2.5s and 5s, got %v", p99)
+	}
+	buckets := histogram.Buckets()
+	if buckets[0.01] != 90 || buckets[2.5] != 90 || buckets[5] != 100 || buckets[300] != 100 {
+		t.Errorf("unexpected cumulative buckets %v", buckets)
+	}
+
+	recorder.observe(0, now.Add(time.Hour))
+	if p100 := recorder.snapshot().Quantile(1); p100 != 5*time.Minute {
+		t.Errorf("expected the last bound for the overflow bucket, got %v", p100)
+	}
+}
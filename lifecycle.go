@@ -0,0 +1,168 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// DeleteResourcesAPI is the part of the Amazon CloudWatch Logs API used to delete log groups and streams. It is only
+// required of the client by DeleteLogStream, DeleteLogGroup and PurgeOldStreams.
+type DeleteResourcesAPI interface {
+	DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error)
+	DeleteLogStream(ctx context.Context, params *cloudwatchlogs.DeleteLogStreamInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error)
+}
+
+// deleteClient returns the client as a DeleteResourcesAPI, if it supports deleting resources.
+func (h *CloudWatchLogsHook) deleteClient() (DeleteResourcesAPI, error) {
+	client, ok := h.client.(DeleteResourcesAPI)
+	if !ok {
+		return nil, fmt.Errorf("Unable to delete resources: client does not support deleting log groups and streams")
+	}
+	return client, nil
+}
+
+// DeleteLogStream deletes the current log stream of the hook along with its events, which is intended for tearing down
+// test environments and tenants. The hook should be closed first, since events sent to a deleted stream are rejected.
+// Deleting a stream which no longer exists is not an error.
+func (h *CloudWatchLogsHook) DeleteLogStream(ctx context.Context) error {
+	client, err := h.deleteClient()
+	if err != nil {
+		return err
+	}
+	h.intakeMutex.Lock()
+	target := h.currentTarget()
+	h.intakeMutex.Unlock()
+	if err := h.deleteStream(ctx, client, target.group, target.stream); err != nil {
+		return err
+	}
+	h.forgetStreams(target.group, target.stream)
+	return nil
+}
+
+// DeleteLogGroup deletes the current log group of the hook along with all of its streams and events, which is
+// intended for tearing down test environments and tenants. The hook should be closed first, since events sent to a
+// deleted group are rejected. Deleting a group which no longer exists is not an error.
+func (h *CloudWatchLogsHook) DeleteLogGroup(ctx context.Context) error {
+	client, err := h.deleteClient()
+	if err != nil {
+		return err
+	}
+	h.intakeMutex.Lock()
+	group := h.currentTarget().group
+	h.intakeMutex.Unlock()
+	_, err = client.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String(group)})
+	var notFoundErr *types.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return fmt.Errorf("Unable to delete log group %s: %v", group, err)
+	}
+	h.forgetStreams(group, "")
+	return nil
+}
+
+// PurgeOldStreams deletes the streams in the current log group of the hook whose last event, or creation if they have
+// no events, is older than the given age, and returns the names of the streams deleted. The current streams of the
+// whole family of the hook, from the root to its children and their own children, along with the ops stream, are never
+// deleted. Throttled calls are retried according to the creation backoff policy. Amazon CloudWatch updates the time of
+// the last event of a stream eventually, so keep the age well above the few hours this can take.
+func (h *CloudWatchLogsHook) PurgeOldStreams(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	client, err := h.deleteClient()
+	if err != nil {
+		return nil, err
+	}
+	h.intakeMutex.Lock()
+	current := h.currentTarget()
+	h.intakeMutex.Unlock()
+	keep := h.liveStreams(current.group)
+	cutoff := h.clock.Now().Add(-olderThan).UnixNano() / int64(time.Millisecond)
+
+	// find the streams first, since deleting them while paging through the streams could skip some
+	var old []string
+	var nextToken *string
+	for {
+		var result *cloudwatchlogs.DescribeLogStreamsOutput
+		err := h.retryThrottled(ctx, func() error {
+			var err error
+			result, err = h.client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+				LogGroupName: aws.String(current.group),
+				NextToken:    nextToken,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to list the streams of log group %s: %v", current.group, err)
+		}
+		for _, stream := range result.LogStreams {
+			last := aws.ToInt64(stream.LastEventTimestamp)
+			if stream.LastEventTimestamp == nil {
+				last = aws.ToInt64(stream.CreationTime)
+			}
+			if name := aws.ToString(stream.LogStreamName); !keep[name] && last < cutoff {
+				old = append(old, name)
+			}
+		}
+		nextToken = result.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	var deleted []string
+	for _, stream := range old {
+		err := h.retryThrottled(ctx, func() error {
+			return h.deleteStream(ctx, client, current.group, stream)
+		})
+		if err != nil {
+			return deleted, err
+		}
+		h.forgetStreams(current.group, stream)
+		deleted = append(deleted, stream)
+	}
+	return deleted, nil
+}
+
+// liveStreams returns the streams in the log group which the family of the hook is writing to: the current streams of
+// the root of the family and each of its children, including their own children, and the ops stream.
+func (h *CloudWatchLogsHook) liveStreams(group string) map[string]bool {
+	root := h.queueOwner()
+	family := []*CloudWatchLogsHook{root}
+	for i := 0; i < len(family); i++ {
+		family[i].childMutex.Lock()
+		family = append(family, family[i].children...)
+		family[i].childMutex.Unlock()
+	}
+
+	live := make(map[string]bool)
+	for _, member := range family {
+		member.intakeMutex.Lock()
+		target := member.currentTarget()
+		member.intakeMutex.Unlock()
+		if target.group == group {
+			live[target.stream] = true
+		}
+	}
+	if root.ops != nil && root.ops.target.group == group {
+		live[root.ops.target.stream] = true
+	}
+	return live
+}
+
+// deleteStream deletes the stream, ignoring the error returned if it no longer exists.
+func (h *CloudWatchLogsHook) deleteStream(ctx context.Context, client DeleteResourcesAPI, group, stream string) error {
+	_, err := client.DeleteLogStream(ctx, &cloudwatchlogs.DeleteLogStreamInput{
+		LogGroupName:  aws.String(group),
+		LogStreamName: aws.String(stream),
+	})
+	var notFoundErr *types.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return fmt.Errorf("Unable to delete log stream %s: %w", stream, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// throttlingClient throttles every other call which lists or deletes log streams once throttling is started.
+type throttlingClient struct {
+	*chaos.Client
+	mutex     sync.Mutex
+	active    bool
+	throttles int
+}
+
+func (c *throttlingClient) start() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active = true
+}
+
+func (c *throttlingClient) throttled() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.active {
+		return false
+	}
+	c.active = false
+	c.throttles++
+	return true
+}
+
+func (c *throttlingClient) succeeded() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active = c.throttles > 0
+}
+
+func (c *throttlingClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	if c.throttled() {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	defer c.succeeded()
+	return c.Client.DescribeLogStreams(ctx, params, optFns...)
+}
+
+func (c *throttlingClient) DeleteLogStream(ctx context.Context, params *cloudwatchlogs.DeleteLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+
+	if c.throttled() {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	defer c.succeeded()
+	return c.Client.DeleteLogStream(ctx, params, optFns...)
+}
+
+func TestLifecycle(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/tenants/acme", "current",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	ctx := context.Background()
+	if err := hook.EnsureStreams(ctx, "stale", "fresh"); err != nil {
+		t.Fatalf("unable to create streams: %v", err)
+	}
+
+	// the current stream is kept however old its events are
+	now := time.Now()
+	for stream, age := range map[string]time.Duration{"current": 72 * time.Hour, "stale": 48 * time.Hour, "fresh": 0} {
+		_, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String("/tenants/acme"),
+			LogStreamName: aws.String(stream),
+			LogEvents: []types.InputLogEvent{{
+				Message:   aws.String("event"),
+				Timestamp: aws.Int64(now.Add(-age).UnixNano() / int64(time.Millisecond)),
+			}},
+		})
+		if err != nil {
+			t.Fatalf("unable to write to %s: %v", stream, err)
+		}
+	}
+	deleted, err := hook.PurgeOldStreams(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unable to purge streams: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "stale" || client.Events("/tenants/acme", "stale") != nil {
+		t.Errorf("expected only the stale stream to be deleted, got %v", deleted)
+	}
+
+	if err := hook.DeleteLogStream(ctx); err != nil {
+		t.Fatalf("unable to delete stream: %v", err)
+	}
+	if client.Events("/tenants/acme", "current") != nil || client.Events("/tenants/acme", "fresh") == nil {
+		t.Errorf("expected only the current stream to be deleted")
+	}
+	for i := 0; i < 2; i++ {
+		if err := hook.DeleteLogGroup(ctx); err != nil {
+			t.Fatalf("unable to delete group: %v", err)
+		}
+	}
+	if client.Events("/tenants/acme", "fresh") != nil {
+		t.Errorf("expected the streams of the group to be deleted along with it")
+	}
+}
+
+func TestPurgeOldStreamsFamily(t *testing.T) {
+	client := &throttlingClient{Client: chaos.NewClient(chaos.Faults{})}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/tenants/acme", "current",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithCreationBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Millisecond, MaxRetries: 1}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	child, err := hook.Child("-worker")
+	if err != nil {
+		t.Fatalf("unable to create child: %v", err)
+	}
+	ctx := context.Background()
+	if err := hook.EnsureStreams(ctx, "stale"); err != nil {
+		t.Fatalf("unable to create streams: %v", err)
+	}
+
+	// the streams of the family are live, however old their events are
+	old := time.Now().Add(-48*time.Hour).UnixNano() / int64(time.Millisecond)
+	for _, stream := range []string{"current", "current-worker", "stale"} {
+		_, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String("/tenants/acme"),
+			LogStreamName: aws.String(stream),
+			LogEvents:     []types.InputLogEvent{{Message: aws.String("event"), Timestamp: aws.Int64(old)}},
+		})
+		if err != nil {
+			t.Fatalf("unable to write to %s: %v", stream, err)
+		}
+	}
+
+	// each call is throttled once before it succeeds
+	client.start()
+	deleted, err := child.PurgeOldStreams(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unable to purge streams: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "stale" {
+		t.Errorf("expected only the stale stream to be deleted, got %v", deleted)
+	}
+	if client.throttles != 2 {
+		t.Errorf("expected the listing and the deletion to be throttled, got %d throttles", client.throttles)
+	}
+}
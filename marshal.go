@@ -0,0 +1,53 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValueMarshalerEnricher returns an enricher which passes the value of each field which is not a JSON-native value to
+// the marshaler and replaces it with the result. Strings, booleans, the built-in numeric types, json.Number, nil and
+// maps and slices of interface{} are JSON-native; named types such as time.Duration, structs, errors and byte slices
+// are not. If the marshaler returns an error, the value is left for the codec to render as it otherwise would.
+func ValueMarshalerEnricher(marshaler func(interface{}) (interface{}, error)) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		marshal := func(k string, v interface{}) {
+			if jsonNative(v) {
+				return
+			}
+			if marshaled, err := marshaler(v); err == nil {
+				fields[k] = marshaled
+			}
+		}
+		for k, v := range fields {
+			marshal(k, v)
+		}
+		for k, v := range entry.Data {
+			if _, ok := fields[k]; !ok {
+				marshal(k, v)
+			}
+		}
+	})
+}
+
+// jsonNative determines whether or not the value is encoded as JSON the same way whatever the marshaling strategy.
+func jsonNative(v interface{}) bool {
+	switch v.(type) {
+	case nil, string, bool, json.Number, map[string]interface{}, []interface{},
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// MillisecondDurations is a value marshaler for WithValueMarshaler which renders durations as a number of
+// milliseconds, such as 1500 rather than the nanoseconds of the JSON formatter or the "1.5s" of the text formatter.
+// Other values are rendered as they otherwise would be.
+func MillisecondDurations(v interface{}) (interface{}, error) {
+	if d, ok := v.(time.Duration); ok {
+		return float64(d) / float64(time.Millisecond), nil
+	}
+	return v, nil
+}
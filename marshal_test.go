@@ -0,0 +1,46 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestValueMarshaler(t *testing.T) {
+	type point struct{ X, Y int }
+	entry := &logrus.Entry{
+		Message: "request",
+		Data: logrus.Fields{
+			"elapsed": 1500 * time.Millisecond,
+			"body":    []byte("hello"),
+			"point":   point{1, 2},
+			"count":   3,
+			"name":    "alice",
+		},
+	}
+	var seen []string
+	marshaler := func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case []byte:
+			seen = append(seen, "body")
+			return string(v), nil
+		case point:
+			seen = append(seen, "point")
+			return nil, errors.New("not supported")
+		}
+		return MillisecondDurations(v)
+	}
+	fields := logrus.Fields{}
+	ValueMarshalerEnricher(marshaler).Enrich(entry, fields)
+
+	b, _ := json.Marshal(fields)
+	if string(b) != `{"body":"hello","elapsed":1500}` {
+		t.Errorf("unexpected fields %s", b)
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected only the values which are not JSON-native to be marshaled, got %v", seen)
+	}
+}
@@ -0,0 +1,43 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// MemoryUsage describes the memory held by the events of a hook which have not been delivered yet. The sizes are
+// those counted against the PutLogEvents limits: the length of each message plus EventOverhead bytes.
+type MemoryUsage struct {
+	// Queued is the size of the events waiting to be batched, in the batch queue, the priority queue and the burst
+	// buffer, including events whose writers are blocked waiting for room in a full queue.
+	Queued int64 `json:"queued"`
+
+	// Batched is the size of the events collected into batches which are not due to be sent yet.
+	Batched int64 `json:"batched"`
+
+	// InFlight is the size of the batches being sent to Amazon CloudWatch, including those waiting to be retried.
+	InFlight int64 `json:"in_flight"`
+
+	// Total is the sum of the other sizes.
+	Total int64 `json:"total"`
+}
+
+// MemoryUsage returns the memory held by the events of the hook which have not been delivered yet. It is zero if the
+// hook does not batch events.
+func (h *CloudWatchLogsHook) MemoryUsage() MemoryUsage {
+	usage := MemoryUsage{
+		Queued:   atomic.LoadInt64(&h.stats.queuedBytes),
+		Batched:  atomic.LoadInt64(&h.stats.batchedBytes),
+		InFlight: atomic.LoadInt64(&h.stats.inFlightBytes),
+		Total:    0,
+	}
+	usage.Total = usage.Queued + usage.Batched + usage.InFlight
+	return usage
+}
+
+// queuedSize returns the size of the event counted against the memory usage while it is queued.
+func queuedSize(event types.InputLogEvent) int64 {
+	return int64(len(aws.ToString(event.Message)) + EventOverhead)
+}
@@ -0,0 +1,48 @@
+package cloudwatchhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{ThrottleRate: 1})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithBatchDuration(time.Minute),
+		cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: time.Minute, MaxRetries: 3}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	size := int64(len("hello") + cloudwatchhook.EventOverhead)
+	waitFor := func(want cloudwatchhook.MemoryUsage) {
+		var usage cloudwatchhook.MemoryUsage
+		for i := 0; i < 100; i++ {
+			if usage = hook.MemoryUsage(); usage == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected memory usage %+v, got %+v", want, usage)
+	}
+
+	if _, err := hook.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(cloudwatchhook.MemoryUsage{Queued: 0, Batched: size, InFlight: 0, Total: size})
+
+	// the batch is held in flight while it waits to be retried
+	clock.Advance(time.Minute)
+	waitFor(cloudwatchhook.MemoryUsage{Queued: 0, Batched: 0, InFlight: size, Total: size})
+
+	client.SetFaults(chaos.Faults{})
+	clock.Advance(time.Minute)
+	waitFor(cloudwatchhook.MemoryUsage{})
+}
@@ -0,0 +1,93 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// defaultMetadataTimeout is the default time allowed for instance metadata lookups.
+const defaultMetadataTimeout = time.Second
+
+// instanceMetadata holds information about the Amazon EC2 instance running the hook.
+type instanceMetadata struct {
+	InstanceID       string `json:"instance_id"`
+	InstanceType     string `json:"instance_type,omitempty"`
+	ImageID          string `json:"image_id,omitempty"`
+	AccountID        string `json:"account_id,omitempty"`
+	Region           string `json:"region,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+}
+
+// metadataFetchFunc fetches the metadata of the instance running the hook.
+type metadataFetchFunc func(ctx context.Context) (*instanceMetadata, error)
+
+// metadataLookup is used to fetch instance metadata in the background once per process and cache the result, so that
+// environments where the instance metadata service is disabled or unreachable never block on it for longer than the
+// timeout.
+type metadataLookup struct {
+	once     sync.Once
+	done     chan struct{}
+	metadata *instanceMetadata
+}
+
+// instanceMetadataLookup caches the instance metadata shared by all hooks in the process.
+var instanceMetadataLookup = newMetadataLookup()
+
+// newMetadataLookup creates a lookup which has not been started.
+func newMetadataLookup() *metadataLookup {
+	return &metadataLookup{
+		done: make(chan struct{}),
+	}
+}
+
+// start begins fetching the metadata in the background unless it has already been started. The fetch is abandoned
+// once the timeout expires.
+func (l *metadataLookup) start(fetch metadataFetchFunc, timeout time.Duration) {
+	l.once.Do(func() {
+		go func() {
+			defer close(l.done)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if metadata, err := fetch(ctx); err == nil {
+				l.metadata = metadata
+			}
+		}()
+	})
+}
+
+// wait returns the metadata once it has been fetched, or nil if it is unavailable or has not been fetched within the
+// timeout.
+func (l *metadataLookup) wait(clock Clock, timeout time.Duration) *instanceMetadata {
+	timer := clock.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-l.done:
+		return l.metadata
+	case <-timer.C():
+		return nil
+	}
+}
+
+// fetchInstanceMetadata returns a function which fetches the instance identity document from the instance metadata
+// service. The SDK client uses IMDSv2 session tokens, so this works on instances which require them.
+func fetchInstanceMetadata(config aws.Config) metadataFetchFunc {
+	client := imds.NewFromConfig(config)
+	return func(ctx context.Context) (*instanceMetadata, error) {
+		output, err := client.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+		if err != nil {
+			return nil, err
+		}
+		return &instanceMetadata{
+			InstanceID:       output.InstanceID,
+			InstanceType:     output.InstanceType,
+			ImageID:          output.ImageID,
+			AccountID:        output.AccountID,
+			Region:           output.Region,
+			AvailabilityZone: output.AvailabilityZone,
+		}, nil
+	}
+}
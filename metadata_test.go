@@ -0,0 +1,32 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMetadataLookup(t *testing.T) {
+	lookup := newMetadataLookup()
+	lookup.start(func(ctx context.Context) (*instanceMetadata, error) {
+		return &instanceMetadata{InstanceID: "i-0123456789abcdef0"}, nil
+	}, time.Second)
+	metadata := lookup.wait(systemClock{}, time.Second)
+	if metadata == nil || metadata.InstanceID != "i-0123456789abcdef0" {
+		t.Errorf("expected the fetched metadata, got %+v", metadata)
+	}
+
+	// a metadata service which never responds must not block for longer than the timeout
+	lookup = newMetadataLookup()
+	lookup.start(func(ctx context.Context) (*instanceMetadata, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 50*time.Millisecond)
+	start := time.Now()
+	if metadata = lookup.wait(systemClock{}, time.Second); metadata != nil {
+		t.Errorf("expected no metadata, got %+v", metadata)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the lookup to time out after 50ms, took %v", elapsed)
+	}
+}
@@ -0,0 +1,130 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AccountIDField is the name of the field conventionally used to route entries between accounts.
+	AccountIDField = "account_id"
+
+	// AccountIDPlaceholder is replaced with the account ID of each role in the group name template given to
+	// NewMultiAccountHook.
+	AccountIDPlaceholder = "{account_id}"
+)
+
+// MultiAccountHook is used to send log messages to Amazon CloudWatch in several AWS accounts. It holds a child hook
+// per account, each of which writes using credentials obtained by assuming a role in that account.
+type MultiAccountHook struct {
+	routeField string
+	hooks      map[string]*CloudWatchLogsHook
+	accounts   []string
+}
+
+// NewMultiAccountHook creates a child hook in each of the accounts owning the given roles. The credentials in the
+// given configuration are used to assume each role, and AccountIDPlaceholder in the group name template is replaced
+// with the account ID of the role. If routeField is empty, every entry is sent to all accounts; otherwise each entry
+// is only sent to the account whose ID is held in that field, such as AccountIDField. The options are applied to
+// every child hook.
+func NewMultiAccountHook(config aws.Config, roleARNs []string, groupTemplate, stream, routeField string,
+	options ...CloudWatchLogsHookOption) (*MultiAccountHook, error) {
+
+	m := &MultiAccountHook{
+		routeField: routeField,
+		hooks:      map[string]*CloudWatchLogsHook{},
+		accounts:   nil,
+	}
+	client := sts.NewFromConfig(config)
+	for _, roleARN := range roleARNs {
+		parsed, err := arn.Parse(roleARN)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Invalid role ARN %q: %v", roleARN, err)
+		}
+		if _, ok := m.hooks[parsed.AccountID]; ok {
+			m.Close()
+			return nil, fmt.Errorf("Invalid role ARN %q: more than one role given for account %s", roleARN,
+				parsed.AccountID)
+		}
+
+		accountConfig := config.Copy()
+		accountConfig.Credentials = &aws.CredentialsCache{
+			Provider: stscreds.NewAssumeRoleProvider(client, roleARN),
+		}
+		group := strings.Replace(groupTemplate, AccountIDPlaceholder, parsed.AccountID, -1)
+		hook, err := NewCloudWatchLogsHook(accountConfig, group, stream, options...)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Unable to create hook for account %s: %v", parsed.AccountID, err)
+		}
+		m.hooks[parsed.AccountID] = hook
+		m.accounts = append(m.accounts, parsed.AccountID)
+	}
+	sort.Strings(m.accounts)
+	return m, nil
+}
+
+// Accounts returns the IDs of the accounts written to by the hook.
+func (m *MultiAccountHook) Accounts() []string {
+	return append([]string{}, m.accounts...)
+}
+
+// Hook returns the child hook writing to the given account, or nil if there is none.
+func (m *MultiAccountHook) Hook(accountID string) *CloudWatchLogsHook {
+	return m.hooks[accountID]
+}
+
+// Fire sends the entry to every account, or only to the account held in the route field if one is configured.
+func (m *MultiAccountHook) Fire(entry *logrus.Entry) error {
+	if m.routeField == "" {
+		var firstErr error
+		for _, account := range m.accounts {
+			if err := m.hooks[account].Fire(entry); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	value, ok := entry.Data[m.routeField]
+	if !ok {
+		return fmt.Errorf("Unable to route entry: missing %s field", m.routeField)
+	}
+	account := fmt.Sprint(value)
+	hook, ok := m.hooks[account]
+	if !ok {
+		return fmt.Errorf("Unable to route entry: no hook for account %s", account)
+	}
+	return hook.Fire(entry)
+}
+
+// Levels returns the valid levels for the hook.
+func (m *MultiAccountHook) Levels() []logrus.Level {
+	return []logrus.Level{
+		logrus.PanicLevel,
+		logrus.FatalLevel,
+		logrus.ErrorLevel,
+		logrus.WarnLevel,
+		logrus.InfoLevel,
+		logrus.DebugLevel,
+	}
+}
+
+// Close closes every child hook, returning the first error encountered.
+func (m *MultiAccountHook) Close() error {
+	var firstErr error
+	for _, hook := range m.hooks {
+		if err := hook.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
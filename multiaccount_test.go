@@ -0,0 +1,37 @@
+package cloudwatchhook_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMultiAccountHookRouting(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewMultiAccountHook(aws.Config{},
+		[]string{"arn:aws:iam::111111111111:role/logs", "arn:aws:iam::222222222222:role/logs"},
+		"/app/{account_id}", "stream", cloudwatchhook.AccountIDField, cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	logger.WithField(cloudwatchhook.AccountIDField, "222222222222").Info("routed")
+
+	if n := len(client.Events("/app/111111111111", "stream")); n != 0 {
+		t.Errorf("expected no events in the first account, got %d", n)
+	}
+	if n := len(client.Events("/app/222222222222", "stream")); n != 1 {
+		t.Errorf("expected 1 event in the second account, got %d", n)
+	}
+	if err := hook.Fire(logrus.NewEntry(logger)); err == nil {
+		t.Errorf("expected an error firing an entry without an account")
+	}
+}
@@ -0,0 +1,46 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamingPolicy is used to enforce naming conventions for log groups and streams. It is given the group and stream
+// names passed to the hook and returns the names to use, which may be rewritten, or an error rejecting them.
+type NamingPolicy func(group, stream string) (string, string, error)
+
+// RequireGroupPrefix returns a naming policy which rejects any log group whose name does not start with one of the
+// given prefixes, such as "/org/team/".
+func RequireGroupPrefix(prefixes ...string) NamingPolicy {
+	return func(group, stream string) (string, string, error) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(group, prefix) {
+				return group, stream, nil
+			}
+		}
+		return "", "", fmt.Errorf("Log group %s must start with one of %v", group, prefixes)
+	}
+}
+
+// PrefixGroup returns a naming policy which adds the given prefix, such as "/org/team/", to any log group whose name
+// does not already start with it.
+func PrefixGroup(prefix string) NamingPolicy {
+	return func(group, stream string) (string, string, error) {
+		if !strings.HasPrefix(group, prefix) {
+			group = prefix + strings.TrimPrefix(group, "/")
+		}
+		return group, stream, nil
+	}
+}
+
+// applyNamingPolicies passes the group and stream names through each naming policy in turn.
+func (h *CloudWatchLogsHook) applyNamingPolicies() error {
+	for _, policy := range h.namingPolicies {
+		group, stream, err := policy(h.group, h.stream)
+		if err != nil {
+			return fmt.Errorf("Naming policy rejected log group %s and stream %s: %v", h.group, h.stream, err)
+		}
+		h.group, h.stream = group, stream
+	}
+	return nil
+}
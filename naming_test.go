@@ -0,0 +1,20 @@
+package cloudwatchhook
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestApplyNamingPolicies(t *testing.T) {
+	h := newTestHook(t, "billing", "stream", WithNamingPolicy(PrefixGroup("/org/payments/")),
+		WithNamingPolicy(RequireGroupPrefix("/org/payments/", "/org/shared/")))
+	if h.group != "/org/payments/billing" || h.stream != "stream" {
+		t.Errorf("unexpected names %s and %s", h.group, h.stream)
+	}
+
+	if _, err := newHook(aws.Config{}, "/billing", "stream",
+		WithNamingPolicy(RequireGroupPrefix("/org/payments/"))); err == nil {
+		t.Errorf("expected the group to be rejected")
+	}
+}
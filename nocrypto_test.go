@@ -0,0 +1,29 @@
+//go:build cloudwatchhook_nocrypto
+// +build cloudwatchhook_nocrypto
+
+package cloudwatchhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// nullDataKeys is a DataKeyProvider which is never used.
+type nullDataKeys struct{}
+
+func (nullDataKeys) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (nullDataKeys) Decrypt(ctx context.Context, encrypted []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func TestBatchEncryptionUnavailable(t *testing.T) {
+	_, err := NewCloudWatchLogsHook(aws.Config{}, "group", "stream", WithBatchEncryption(nullDataKeys{}))
+	if err == nil {
+		t.Errorf("expected batch encryption to be rejected when built without it")
+	}
+}
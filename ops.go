@@ -0,0 +1,274 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	// opsInterval is how often the health events collected for the ops stream are sent, along with a summary of the
+	// entries dropped since the last one.
+	opsInterval = time.Minute
+
+	// maxPendingOpsEvents bounds the number of health events waiting to be sent, so that a hook which cannot reach its
+	// ops stream does not accumulate them without limit.
+	maxPendingOpsEvents = 1000
+)
+
+// opsEvent is the structured event describing the health of the hook which is sent to the ops stream.
+type opsEvent struct {
+	Message string  `json:"msg"`
+	Level   string  `json:"level"`
+	Time    string  `json:"time"`
+	Ops     opsInfo `json:"ops"`
+}
+
+// opsInfo holds the details of a health event. Kind is one of lifecycle, debug, errors, drops, backpressure or
+// amplification.
+type opsInfo struct {
+	Kind   string           `json:"kind"`
+	Group  string           `json:"group"`
+	Stream string           `json:"stream"`
+	Counts map[string]int64 `json:"counts,omitempty"`
+}
+
+// opsShipper collects health events for the ops stream until they are sent by the ops worker. A hook and its children
+// share a single shipper, run by the parent, so that only one writer manages the sequence token of the ops stream.
+type opsShipper struct {
+	target        streamTarget
+	mutex         sync.Mutex
+	events        []types.InputLogEvent
+	lastTimestamp int64
+	discarded     int64
+	token         *string
+
+	// the state of each hook of the family as of the last summary, only touched by the ops worker
+	summaries map[*CloudWatchLogsHook]*opsSummary
+}
+
+// opsSummary holds the counters of a hook and the state of its backpressure gate as of the last summary.
+type opsSummary struct {
+	reported     map[string]int64
+	backpressure bool
+}
+
+// newOpsShipper creates a shipper for the given ops stream.
+func newOpsShipper(target streamTarget) *opsShipper {
+	return &opsShipper{
+		target:        target,
+		events:        nil,
+		lastTimestamp: 0,
+		discarded:     0,
+		token:         nil,
+		summaries:     map[*CloudWatchLogsHook]*opsSummary{},
+	}
+}
+
+// summary returns the state of the hook as of the last summary.
+func (s *opsShipper) summary(h *CloudWatchLogsHook) *opsSummary {
+	summary, ok := s.summaries[h]
+	if !ok {
+		summary = &opsSummary{reported: map[string]int64{}, backpressure: false}
+		s.summaries[h] = summary
+	}
+	return summary
+}
+
+// record queues a health event, discarding it if too many are already waiting to be sent.
+func (s *opsShipper) record(now time.Time, level, kind, msg string, counts map[string]int64, source streamTarget) {
+	line, err := json.Marshal(opsEvent{
+		Message: msg,
+		Level:   level,
+		Time:    now.Format(time.RFC3339),
+		Ops: opsInfo{
+			Kind:   kind,
+			Group:  source.group,
+			Stream: source.stream,
+			Counts: counts,
+		},
+	})
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.events) >= maxPendingOpsEvents {
+		s.discarded++
+		return
+	}
+
+	// events must be in chronological order within a batch
+	timestamp := now.UnixNano() / int64(time.Millisecond)
+	if timestamp < s.lastTimestamp {
+		timestamp = s.lastTimestamp
+	}
+	s.lastTimestamp = timestamp
+	s.events = append(s.events, newEvent(string(line), timestamp))
+}
+
+// take removes and returns the events waiting to be sent along with the number discarded since the last call.
+func (s *opsShipper) take() ([]types.InputLogEvent, int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	events, discarded := s.events, s.discarded
+	s.events, s.discarded = nil, 0
+	return events, discarded
+}
+
+// opsf records a health event for the ops stream, if one is configured.
+func (h *CloudWatchLogsHook) opsf(level, kind, format string, args ...interface{}) {
+	if h.ops == nil {
+		return
+	}
+	h.intakeMutex.Lock()
+	source := h.currentTarget()
+	h.intakeMutex.Unlock()
+	h.ops.record(h.clock.Now(), level, kind, fmt.Sprintf(format, args...), nil, source)
+}
+
+// shipOps sends the health events collected for the ops stream at a fixed interval until the hook is closed.
+func (h *CloudWatchLogsHook) shipOps() {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(opsInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.done:
+			h.summarizeFamilyOps()
+			h.opsf("info", "lifecycle", "hook closing")
+			h.flushOps()
+			return
+		case <-timer.C():
+			h.summarizeFamilyOps()
+			h.flushOps()
+			timer.Reset(opsInterval)
+		}
+	}
+}
+
+// summarizeFamilyOps records a summary for the hook and each of its children, which share its ops stream.
+func (h *CloudWatchLogsHook) summarizeFamilyOps() {
+	h.childMutex.Lock()
+	children := append([]*CloudWatchLogsHook(nil), h.children...)
+	h.childMutex.Unlock()
+	h.summarizeOps(h.ops)
+	for _, child := range children {
+		child.summarizeOps(h.ops)
+	}
+}
+
+// summarizeOps records the entries dropped and the write amplification since the last summary, and any change in the
+// state of the backpressure gate.
+func (h *CloudWatchLogsHook) summarizeOps(ops *opsShipper) {
+	summary := ops.summary(h)
+	counts := map[string]int64{
+		"empty_dropped":        atomic.LoadInt64(&h.stats.emptyDropped),
+		"sampled_out":          atomic.LoadInt64(&h.stats.sampledOut),
+		"filtered_out":         atomic.LoadInt64(&h.stats.filteredOut),
+		"backpressure_dropped": atomic.LoadInt64(&h.stats.backpressureDropped),
+		"abandoned":            atomic.LoadInt64(&h.stats.abandoned),
+	}
+	dropped := map[string]int64{}
+	for name, count := range counts {
+		if delta := count - summary.reported[name]; delta > 0 {
+			dropped[name] = delta
+		}
+		summary.reported[name] = count
+	}
+	h.intakeMutex.Lock()
+	source := h.currentTarget()
+	h.intakeMutex.Unlock()
+	if len(dropped) > 0 {
+		ops.record(h.clock.Now(), "warning", "drops", "entries dropped", dropped, source)
+	}
+
+	// the write amplification of the bytes shipped since the last summary
+	logged := atomic.LoadInt64(&h.stats.loggedBytes)
+	shipped := atomic.LoadInt64(&h.stats.shippedBytes)
+	loggedDelta, shippedDelta := logged-summary.reported["logged_bytes"], shipped-summary.reported["shipped_bytes"]
+	if shippedDelta > 0 {
+		ops.record(h.clock.Now(), "info", "amplification",
+			fmt.Sprintf("write amplification %.2f", writeAmplification(loggedDelta, shippedDelta)),
+			map[string]int64{"logged_bytes": loggedDelta, "shipped_bytes": shippedDelta}, source)
+	}
+	summary.reported["logged_bytes"], summary.reported["shipped_bytes"] = logged, shipped
+
+	if h.backpressure != nil {
+		active := atomic.LoadInt32(&h.backpressure.active) == 1
+		if active != summary.backpressure {
+			msg := "backpressure released"
+			if active {
+				msg = "backpressure engaged"
+			}
+			ops.record(h.clock.Now(), "warning", "backpressure", msg, nil, source)
+			summary.backpressure = active
+		}
+	}
+}
+
+// flushOps sends the health events collected for the ops stream. Failures are reported to the debug logger only, since
+// reporting them to the ops stream would feed back into it.
+func (h *CloudWatchLogsHook) flushOps() {
+	events, discarded := h.ops.take()
+	if discarded > 0 && h.debugLogger != nil {
+		h.debugLogger.Printf("cloudwatchhook: discarded %d health events for the ops stream", discarded)
+	}
+	if len(events) == 0 {
+		return
+	}
+	if err := h.putOpsEvents(h.sendContext(), events); err != nil && h.debugLogger != nil {
+		h.debugLogger.Printf("cloudwatchhook: unable to send %d health events to the ops stream %s: %v", len(events),
+			h.ops.target.stream, err)
+	}
+}
+
+// putOpsEvents sends the events to the ops stream, which has its own upload sequence token.
+func (h *CloudWatchLogsHook) putOpsEvents(ctx context.Context, events []types.InputLogEvent) error {
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogEvents:     events,
+		LogGroupName:  aws.String(h.ops.target.group),
+		LogStreamName: aws.String(h.ops.target.stream),
+	}
+	if h.seqTokens() {
+		input.SequenceToken = h.ops.token
+	}
+	result, err := h.client.PutLogEvents(ctx, input)
+
+	// retry once with the expected token if the service still requires sequence tokens
+	var tokenErr *types.InvalidSequenceTokenException
+	if errors.As(err, &tokenErr) {
+		input.SequenceToken = tokenErr.ExpectedSequenceToken
+		result, err = h.client.PutLogEvents(ctx, input)
+	}
+	if err != nil {
+		return err
+	}
+	h.ops.token = result.NextSequenceToken
+	return nil
+}
+
+// createOpsStream creates the ops stream in the log group of the hook, or checks that it exists if the hook may not
+// create it.
+func (h *CloudWatchLogsHook) createOpsStream() error {
+	if h.noCreate {
+		return h.requireTarget(h.ops.target)
+	}
+	_, err := h.client.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(h.ops.target.group),
+		LogStreamName: aws.String(h.ops.target.stream),
+	})
+	var existsErr *types.ResourceAlreadyExistsException
+	if err != nil && !errors.As(err, &existsErr) {
+		return err
+	}
+	return nil
+}
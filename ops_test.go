@@ -0,0 +1,80 @@
+package cloudwatchhook_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestOpsStream(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithOpsStream("ops"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := hook.Write([]byte(" ")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+
+	var messages []string
+	for _, event := range client.Events("group", "ops") {
+		messages = append(messages, aws.ToString(event.Message))
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 health events, got %q", messages)
+	}
+	for i, expected := range []string{
+		`"msg":"hook started"`,
+		`"kind":"drops","group":"group","stream":"stream","counts":{"empty_dropped":3}`,
+		`"msg":"hook closing"`,
+	} {
+		if !strings.Contains(messages[i], expected) {
+			t.Errorf("expected health event %d to contain %s, got %s", i, expected, messages[i])
+		}
+	}
+	if n := len(client.Events("group", "stream")); n != 0 {
+		t.Errorf("expected no health events in the stream of the hook, got %d", n)
+	}
+}
+
+func TestOpsStreamChildren(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithOpsStream("ops"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	child, err := hook.Child("-worker")
+	if err != nil {
+		t.Fatalf("unable to create child: %v", err)
+	}
+	if _, err := child.Write([]byte(" ")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+
+	// the child reports to the ops stream of its parent, which is written by a single shipper
+	var drops []string
+	for _, event := range client.Events("group", "ops") {
+		if msg := aws.ToString(event.Message); strings.Contains(msg, `"kind":"drops"`) {
+			drops = append(drops, msg)
+		}
+	}
+	if len(drops) != 1 || !strings.Contains(drops[0], `"stream":"stream-worker","counts":{"empty_dropped":1}`) {
+		t.Errorf("expected the drops of the child in the ops stream, got %q", drops)
+	}
+	if n := client.Calls("CreateLogStream"); n != 3 {
+		t.Errorf("expected the streams of the hook and child and a single ops stream to be created, got %d", n)
+	}
+}
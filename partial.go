@@ -0,0 +1,102 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// isInvalidParameter determines whether or not the error is due to invalid events, in which case the batch may be
+// rejected because of a single bad event.
+func isInvalidParameter(err error) bool {
+	var paramErr *types.InvalidParameterException
+	return errors.As(err, &paramErr)
+}
+
+// rejectedEvents returns the events which Amazon CloudWatch accepted the batch without storing because they were too
+// old, too new or older than the retention period of the log group.
+func rejectedEvents(events []types.InputLogEvent, info *types.RejectedLogEventsInfo) []types.InputLogEvent {
+	if info == nil {
+		return nil
+	}
+	old, start := storedRange(events, info)
+	var rejected []types.InputLogEvent
+	rejected = append(rejected, events[:old]...)
+	return append(rejected, events[start:]...)
+}
+
+// acceptedEvents returns the events of the batch which Amazon CloudWatch stored.
+func acceptedEvents(events []types.InputLogEvent, info *types.RejectedLogEventsInfo) []types.InputLogEvent {
+	if info == nil {
+		return events
+	}
+	old, start := storedRange(events, info)
+	return events[old:start]
+}
+
+// storedRange returns the range of the events of the batch which were stored, since Amazon CloudWatch only rejects
+// events at the start of a batch, as too old or expired, and at its end, as too new.
+func storedRange(events []types.InputLogEvent, info *types.RejectedLogEventsInfo) (int, int) {
+	old := 0
+	if info.TooOldLogEventEndIndex != nil {
+		old = int(aws.ToInt32(info.TooOldLogEventEndIndex))
+	}
+	if info.ExpiredLogEventEndIndex != nil && int(aws.ToInt32(info.ExpiredLogEventEndIndex)) > old {
+		old = int(aws.ToInt32(info.ExpiredLogEventEndIndex))
+	}
+	if old > len(events) {
+		old = len(events)
+	}
+	start := len(events)
+	if info.TooNewLogEventStartIndex != nil {
+		start = int(aws.ToInt32(info.TooNewLogEventStartIndex))
+		if start < old {
+			start = old
+		}
+		if start > len(events) {
+			start = len(events)
+		}
+	}
+	return old, start
+}
+
+// quarantine hands events which Amazon CloudWatch rejected while accepting the rest of their batch to the dead letter
+// sink. Only a failure of the sink itself is returned, since the batch was delivered.
+func (h *CloudWatchLogsHook) quarantine(events []types.InputLogEvent, info *types.RejectedLogEventsInfo) error {
+	rejected := rejectedEvents(events, info)
+	if len(rejected) == 0 || h.deadLetters == nil {
+		return nil
+	}
+	err := fmt.Errorf("%d events were rejected by Amazon CloudWatch as too old, too new or expired", len(rejected))
+	target := h.boundTarget()
+	letter := DeadLetter{
+		Group:             target.group,
+		Stream:            target.stream,
+		Events:            rejected,
+		Err:               err,
+		Time:              h.clock.Now(),
+		RequestID:         h.lastRequest.id,
+		ExtendedRequestID: h.lastRequest.extended,
+	}
+	if err := h.deadLetters.Send(h.putContext(), letter); err != nil {
+		return fmt.Errorf("Unable to quarantine rejected events: %v", err)
+	}
+	return nil
+}
+
+// sendSplit isolates the bad events in a batch which was rejected as invalid by splitting it in half and sending each
+// half separately, so that the valid events are delivered and only the offending events reach the dead letter sink.
+// The caller must hold the mutex.
+func (h *CloudWatchLogsHook) sendSplit(events []types.InputLogEvent, err error) error {
+	if len(events) < 2 {
+		return h.deadLetter(events, err)
+	}
+	middle := len(events) / 2
+	firstErr := h.sendEvents(events[:middle])
+	if secondErr := h.sendEvents(events[middle:]); firstErr == nil {
+		firstErr = secondErr
+	}
+	return firstErr
+}
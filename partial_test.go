@@ -0,0 +1,38 @@
+package cloudwatchhook
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestRejectedEvents(t *testing.T) {
+	events := make([]types.InputLogEvent, 6)
+	for i := range events {
+		events[i].Message = aws.String(strconv.Itoa(i))
+	}
+	tests := []struct {
+		info     *types.RejectedLogEventsInfo
+		expected string
+	}{
+		{nil, ""},
+		{&types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(2)}, "01"},
+		{&types.RejectedLogEventsInfo{ExpiredLogEventEndIndex: aws.Int32(1)}, "0"},
+		{&types.RejectedLogEventsInfo{TooNewLogEventStartIndex: aws.Int32(4)}, "45"},
+		{&types.RejectedLogEventsInfo{
+			TooOldLogEventEndIndex:   aws.Int32(1),
+			TooNewLogEventStartIndex: aws.Int32(5),
+		}, "05"},
+	}
+	for _, test := range tests {
+		actual := ""
+		for _, event := range rejectedEvents(events, test.info) {
+			actual += aws.ToString(event.Message)
+		}
+		if actual != test.expected {
+			t.Errorf("rejectedEvents(%+v) = %q, want %q", test.info, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// The AWS partitions in which Amazon CloudWatch Logs is available.
+const (
+	PartitionAWS      = "aws"
+	PartitionAWSUSGov = "aws-us-gov"
+	PartitionAWSCN    = "aws-cn"
+	PartitionAWSISO   = "aws-iso"
+	PartitionAWSISOB  = "aws-iso-b"
+)
+
+// fipsRegionPrefix and fipsRegionSuffix mark the pseudo regions which select the FIPS endpoints of a region.
+const (
+	fipsRegionPrefix = "fips-"
+	fipsRegionSuffix = "-fips"
+)
+
+// fipsHosts maps the regions in which Amazon CloudWatch Logs has FIPS endpoints to their hosts. In AWS GovCloud (US),
+// the standard endpoints of the regions are FIPS validated.
+var fipsHosts = map[string]string{
+	"us-east-1":     "logs-fips.us-east-1.amazonaws.com",
+	"us-east-2":     "logs-fips.us-east-2.amazonaws.com",
+	"us-west-1":     "logs-fips.us-west-1.amazonaws.com",
+	"us-west-2":     "logs-fips.us-west-2.amazonaws.com",
+	"us-gov-east-1": "logs.us-gov-east-1.amazonaws.com",
+	"us-gov-west-1": "logs.us-gov-west-1.amazonaws.com",
+}
+
+// fipsBaseRegion returns the region a FIPS pseudo region is a variant of, and whether the region is one.
+func fipsBaseRegion(region string) (string, bool) {
+	if strings.HasPrefix(region, fipsRegionPrefix) {
+		return strings.TrimPrefix(region, fipsRegionPrefix), true
+	}
+	if strings.HasSuffix(region, fipsRegionSuffix) {
+		return strings.TrimSuffix(region, fipsRegionSuffix), true
+	}
+	return region, false
+}
+
+// fipsEndpointResolver returns a resolver which resolves FIPS pseudo regions to the FIPS endpoints of their region,
+// leaving other regions to the given resolver. The resolver of the SDK only knows the FIPS endpoints of the aws
+// partition, and derives hosts which do not exist for the other pseudo regions.
+func fipsEndpointResolver(next cloudwatchlogs.EndpointResolver) cloudwatchlogs.EndpointResolver {
+	return cloudwatchlogs.EndpointResolverFunc(func(region string, options cloudwatchlogs.EndpointResolverOptions) (
+		aws.Endpoint, error) {
+
+		if base, ok := fipsBaseRegion(region); ok {
+			if host, ok := fipsHosts[base]; ok {
+				return aws.Endpoint{
+					URL:           "https://" + host,
+					PartitionID:   partitionForRegion(base),
+					SigningName:   "logs",
+					SigningRegion: base,
+				}, nil
+			}
+		}
+		return next.ResolveEndpoint(region, options)
+	})
+}
+
+// partitionForRegion returns the partition the region belongs to. FIPS pseudo regions, such as fips-us-gov-west-1,
+// belong to the partition of the region they are a variant of.
+func partitionForRegion(region string) string {
+	region, _ = fipsBaseRegion(region)
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionAWSUSGov
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionAWSCN
+	case strings.HasPrefix(region, "us-isob-"):
+		return PartitionAWSISOB
+	case strings.HasPrefix(region, "us-iso-"):
+		return PartitionAWSISO
+	}
+	return PartitionAWS
+}
+
+// resolvedRegion returns the region the client is created for: the region given by the WithRegion option, if any, or
+// the region of the AWS configuration.
+func (h *CloudWatchLogsHook) resolvedRegion() string {
+	if h.region != "" {
+		return h.region
+	}
+	return h.config.Region
+}
+
+// resolvedPartition returns the partition given by the WithPartition option or, if there is none, the partition of
+// the region. It is empty if neither is known.
+func (h *CloudWatchLogsHook) resolvedPartition() string {
+	if h.partition != "" {
+		return h.partition
+	}
+	if region := h.resolvedRegion(); region != "" {
+		return partitionForRegion(region)
+	}
+	return ""
+}
+
+// validatePartition ensures the partition is known, that the region belongs to it and that the ARNs given to the hook
+// are in it, since resources cannot be shared across partitions.
+func (h *CloudWatchLogsHook) validatePartition() error {
+	switch h.partition {
+	case "", PartitionAWS, PartitionAWSUSGov, PartitionAWSCN, PartitionAWSISO, PartitionAWSISOB:
+	default:
+		return fmt.Errorf("Invalid partition %q: must be one of %s, %s, %s, %s or %s", h.partition, PartitionAWS,
+			PartitionAWSUSGov, PartitionAWSCN, PartitionAWSISO, PartitionAWSISOB)
+	}
+	region := h.resolvedRegion()
+	if h.partition != "" && region != "" && partitionForRegion(region) != h.partition {
+		return fmt.Errorf("Invalid region %q: not in partition %s", region, h.partition)
+	}
+	if base, ok := fipsBaseRegion(region); ok && fipsHosts[base] == "" {
+		return fmt.Errorf("Invalid region %q: Amazon CloudWatch Logs has no FIPS endpoint in %s", region, base)
+	}
+	partition := h.resolvedPartition()
+	if partition == "" {
+		return nil
+	}
+	for name, value := range map[string]string{"destination ARN": h.destinationARN, "KMS key ID": h.kmsKeyID} {
+		if parsed, err := arn.Parse(value); err == nil && parsed.Partition != partition {
+			return fmt.Errorf("Invalid %s %q: not in partition %s", name, value, partition)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cloudwatchhook_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestPartitions(t *testing.T) {
+	tests := []struct {
+		region    string
+		partition string
+		arnRegion string
+	}{
+		{"us-east-1", cloudwatchhook.PartitionAWS, "us-east-1"},
+		{"us-gov-west-1", cloudwatchhook.PartitionAWSUSGov, "us-gov-west-1"},
+		{"fips-us-gov-west-1", cloudwatchhook.PartitionAWSUSGov, "us-gov-west-1"},
+		{"us-gov-east-1-fips", cloudwatchhook.PartitionAWSUSGov, "us-gov-east-1"},
+		{"cn-northwest-1", cloudwatchhook.PartitionAWSCN, "cn-northwest-1"},
+		{"us-isob-east-1", cloudwatchhook.PartitionAWSISOB, "us-isob-east-1"},
+	}
+	for _, test := range tests {
+		client := chaos.NewClient(chaos.Faults{})
+		client.Partition, client.Region = test.partition, test.arnRegion
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{Region: "us-east-1"}, "group", "stream",
+			cloudwatchhook.WithClient(client), cloudwatchhook.WithRegion(test.region))
+		if err != nil {
+			t.Fatalf("%s: unable to create hook: %v", test.region, err)
+		}
+		child, err := hook.Child("-child")
+		if err != nil {
+			t.Fatalf("%s: unable to create child: %v", test.region, err)
+		}
+		hook.Close()
+
+		config := hook.Config()
+		if config.Region != test.region || config.Partition != test.partition {
+			t.Errorf("%s: expected partition %s, got %s in %s", test.region, test.partition, config.Partition,
+				config.Region)
+		}
+		prefix := "arn:" + test.partition + ":logs:" + test.arnRegion + ":123456789012:log-group:group"
+		if arn := hook.GroupARN(); arn != prefix {
+			t.Errorf("%s: unexpected group ARN %s", test.region, arn)
+		}
+		if arn := child.StreamInfo().StreamARN; arn != prefix+":log-stream:stream-child" {
+			t.Errorf("%s: unexpected child stream ARN %s", test.region, arn)
+		}
+	}
+
+	client := chaos.NewClient(chaos.Faults{})
+	_, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithRegion("eu-west-1"), cloudwatchhook.WithPartition(cloudwatchhook.PartitionAWSCN))
+	if err == nil {
+		t.Errorf("expected an error for a region outside the partition")
+	}
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{Region: "cn-north-1"}, "group", "stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithDestinationARN("arn:aws:logs:us-east-1:123456789012:destination:central"))
+	if err == nil {
+		t.Errorf("expected an error for a destination in another partition")
+	}
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithPartition("aws-moon"))
+	if err == nil {
+		t.Errorf("expected an error for an unknown partition")
+	}
+}
@@ -0,0 +1,70 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PatternKeyField is the name of the field holding the pattern key of a message.
+const PatternKeyField = "pattern_key"
+
+// variableTokens holds the patterns for tokens which vary between otherwise identical messages, in the order in
+// which they are replaced. A match is only replaced if the token has no check or the check accepts it.
+var variableTokens = []struct {
+	pattern     *regexp.Regexp
+	check       func(string) bool
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), nil, "<uuid>"},
+	{regexp.MustCompile(`\b(?:[0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}\b`), isIPv6, "<ip>"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`), nil, "<ip>"},
+	{regexp.MustCompile(`\b0[xX][0-9a-fA-F]+\b`), nil, "<hex>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b`), nil, "<hex>"},
+	{regexp.MustCompile(`\d+(?:\.\d+)?`), nil, "<num>"},
+}
+
+// isIPv6 reports whether colon-separated hex groups look like an IPv6 address rather than a clock time such as
+// 12:30:45, which requires either a compressed group or a group holding a hex letter.
+func isIPv6(token string) bool {
+	return strings.Contains(token, "::") || strings.ContainsAny(token, "abcdefABCDEF")
+}
+
+// messagePattern strips variable tokens such as numbers, UUIDs and IP addresses from the message so that messages
+// produced by the same log statement share the same pattern.
+func messagePattern(msg string) string {
+	for _, token := range variableTokens {
+		if token.check == nil {
+			msg = token.pattern.ReplaceAllString(msg, token.replacement)
+			continue
+		}
+		check, replacement := token.check, token.replacement
+		msg = token.pattern.ReplaceAllStringFunc(msg, func(match string) string {
+			if check(match) {
+				return replacement
+			}
+			return match
+		})
+	}
+	return msg
+}
+
+// patternKey returns a short, stable key identifying the pattern of the message.
+func patternKey(msg string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(messagePattern(msg)))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// PatternKeyEnricher returns an enricher which adds the pattern key of the entry message to the fields.
+func PatternKeyEnricher() Enricher {
+	return EnricherFunc(addPatternKey)
+}
+
+// addPatternKey adds the pattern key of the entry message to the fields.
+func addPatternKey(entry *logrus.Entry, fields logrus.Fields) {
+	fields[PatternKeyField] = patternKey(entry.Message)
+}
@@ -0,0 +1,25 @@
+package cloudwatchhook
+
+import "testing"
+
+func TestMessagePattern(t *testing.T) {
+	tests := map[string]string{
+		"user 42 logged in from 10.0.0.1:8080":                    "user <num> logged in from <ip>",
+		"request 0b6b4a8e-7d5c-4c1e-9f3a-2b1d6e9c8a7f took 1.5ms": "request <uuid> took <num>ms",
+		"pointer 0xdeadbeef":                                      "pointer <hex>",
+		"commit 4f2a9c1e pushed":                                  "commit <hex> pushed",
+		"connected to fe80::1ff:fe23:4567:890a":                   "connected to <ip>",
+		"no variables here":                                       "no variables here",
+		"job started at 12:30:45":                                 "job started at <num>:<num>:<num>",
+		"ran from 09:15 to 10:45:30.5":                            "ran from <num>:<num> to <num>:<num>:<num>",
+		"route via 2001:db8::8a2e:370:7334":                       "route via <ip>",
+	}
+	for msg, expected := range tests {
+		if actual := messagePattern(msg); actual != expected {
+			t.Errorf("messagePattern(%q) = %q, want %q", msg, actual, expected)
+		}
+	}
+	if patternKey("user 1 logged in") != patternKey("user 2 logged in") {
+		t.Errorf("expected messages with the same pattern to share a key")
+	}
+}
@@ -0,0 +1,209 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Event is a single encoded log event flowing through a pipeline.
+type Event struct {
+	Message   string
+	Timestamp time.Time
+}
+
+// Enricher is used to add fields to an entry before it is encoded. The fields are added to a copy of the entry, so
+// they do not appear in the logger's own output.
+type Enricher interface {
+	Enrich(entry *logrus.Entry, fields logrus.Fields)
+}
+
+// EnricherFunc is an adapter allowing an ordinary function to be used as an Enricher.
+type EnricherFunc func(entry *logrus.Entry, fields logrus.Fields)
+
+// Enrich calls f(entry, fields).
+func (f EnricherFunc) Enrich(entry *logrus.Entry, fields logrus.Fields) {
+	f(entry, fields)
+}
+
+// Filter is used to decide whether or not an entry should be sent at all.
+type Filter interface {
+	Allow(entry *logrus.Entry) bool
+}
+
+// FilterFunc is an adapter allowing an ordinary function to be used as a Filter.
+type FilterFunc func(entry *logrus.Entry) bool
+
+// Allow calls f(entry).
+func (f FilterFunc) Allow(entry *logrus.Entry) bool {
+	return f(entry)
+}
+
+// Codec is used to encode an entry into the message of an event.
+type Codec interface {
+	Encode(entry *logrus.Entry) ([]byte, error)
+}
+
+// FormatterCodec encodes entries using a logrus formatter. If Formatter is nil, the formatter of the entry's logger is
+// used, which is how the hook encodes entries by default. Since the formatter is chosen for each entry, a hook shared
+// by several loggers encodes the entries of each logger using that logger's formatter.
+type FormatterCodec struct {
+	Formatter logrus.Formatter
+}
+
+// defaultFormatter is used to encode entries which have neither a formatter nor a logger, matching the default
+// formatter of a logrus logger.
+var defaultFormatter logrus.Formatter = &logrus.TextFormatter{}
+
+// Encode formats the entry.
+func (c FormatterCodec) Encode(entry *logrus.Entry) ([]byte, error) {
+	if c.Formatter != nil {
+		return c.Formatter.Format(entry)
+	}
+	if entry.Logger == nil {
+		return defaultFormatter.Format(entry)
+	}
+	formatter := entry.Logger.Formatter
+	if f, ok := formatter.(discardFormatter); ok {
+		// the logger was attached with AttachExclusive, which moved its formatter aside
+		formatter = f.Formatter
+	}
+	if formatter == nil {
+		return defaultFormatter.Format(entry)
+	}
+	return formatter.Format(entry)
+}
+
+// Batcher is used to group events into batches. It need not be safe for concurrent use.
+type Batcher interface {
+	// Add adds the event to the current batch. If the batch is full, it is returned and a new batch is started.
+	Add(event Event) []Event
+
+	// Flush returns the current batch, which may be empty, and starts a new one.
+	Flush() []Event
+}
+
+// Transport is used to deliver batches of events to their destination. CloudWatchLogsHook is the Transport for
+// Amazon CloudWatch Logs, which is used unless the WithTransport option is given.
+type Transport interface {
+	// Send delivers the events, which are in chronological order. The events are only valid until Send returns.
+	Send(ctx context.Context, events []Event) error
+}
+
+// toEvents converts Amazon CloudWatch log events into pipeline events.
+func toEvents(events []types.InputLogEvent) []Event {
+	converted := make([]Event, len(events))
+	for i, event := range events {
+		converted[i] = Event{
+			Message:   aws.ToString(event.Message),
+			Timestamp: time.Unix(0, aws.ToInt64(event.Timestamp)*int64(time.Millisecond)),
+		}
+	}
+	return converted
+}
+
+// Pipeline is a logrus hook assembled from individual stages. Each entry passes through the filters, is enriched by
+// the enrichers, encoded by the codec and batched by the batcher before being delivered by the transport. This allows
+// custom pipelines, such as one which delivers to a different destination, to be built from the same stages as the
+// hook. Only the transport is required; the codec defaults to the formatter of the logger and, without a batcher,
+// each event is delivered as soon as it is fired.
+type Pipeline struct {
+	Enrichers []Enricher
+	Filters   []Filter
+	Codec     Codec
+	Batcher   Batcher
+	Transport Transport
+
+	mutex sync.Mutex
+}
+
+// Levels returns the valid levels for the pipeline.
+func (p *Pipeline) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire passes the entry through the pipeline.
+func (p *Pipeline) Fire(entry *logrus.Entry) error {
+	for _, filter := range p.Filters {
+		if !filter.Allow(entry) {
+			return nil
+		}
+	}
+	msg, err := encodeEntry(entry, p.Enrichers, p.Codec)
+	if err != nil {
+		return fmt.Errorf("Unable to parse entry: %v", err)
+	}
+	event := Event{
+		Message:   msg,
+		Timestamp: entry.Time,
+	}
+	if p.Batcher == nil {
+		return p.Transport.Send(context.TODO(), []Event{event})
+	}
+
+	p.mutex.Lock()
+	batch := p.Batcher.Add(event)
+	p.mutex.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	return p.Transport.Send(context.TODO(), batch)
+}
+
+// Flush delivers the current batch, if any. Call it periodically to bound how long events wait in a partial batch,
+// and before the application exits.
+func (p *Pipeline) Flush(ctx context.Context) error {
+	if p.Batcher == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	batch := p.Batcher.Flush()
+	p.mutex.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	return p.Transport.Send(ctx, batch)
+}
+
+// encodeEntry enriches a copy of the entry and encodes it using the codec, or the formatter of the logger if no codec
+// is given.
+func encodeEntry(entry *logrus.Entry, enrichers []Enricher, codec Codec) (string, error) {
+	// serialize into a pooled buffer rather than allocating a new one for every entry
+	e := *entry
+	buf := getBuffer()
+	defer putBuffer(buf)
+	e.Buffer = buf
+
+	if len(enrichers) > 0 {
+		fields := getFields()
+		defer putFields(fields)
+		for _, enricher := range enrichers {
+			enricher.Enrich(entry, fields)
+		}
+		e.Data = getFields()
+		defer putFields(e.Data)
+		for k, v := range entry.Data {
+			e.Data[k] = v
+		}
+		for k, v := range fields {
+			e.Data[k] = v
+		}
+		if _, ok := fields[CallerFileField]; ok {
+			// the caller has been added as fields so keep the formatter from reporting it too
+			e.Caller = nil
+		}
+	}
+	if codec == nil {
+		codec = FormatterCodec{}
+	}
+	line, err := codec.Encode(&e)
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
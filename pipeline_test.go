@@ -0,0 +1,162 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// testTransport records the batches it is sent.
+type testTransport struct {
+	batches [][]Event
+}
+
+func (t *testTransport) Send(ctx context.Context, events []Event) error {
+	t.batches = append(t.batches, append([]Event{}, events...))
+	return nil
+}
+
+// testBatcher batches events in pairs.
+type testBatcher struct {
+	batch []Event
+}
+
+func (b *testBatcher) Add(event Event) []Event {
+	b.batch = append(b.batch, event)
+	if len(b.batch) < 2 {
+		return nil
+	}
+	return b.Flush()
+}
+
+func (b *testBatcher) Flush() []Event {
+	batch := b.batch
+	b.batch = nil
+	return batch
+}
+
+func TestPipeline(t *testing.T) {
+	transport := &testTransport{}
+	pipeline := &Pipeline{
+		Enrichers: []Enricher{EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+			fields["service"] = "billing"
+		})},
+		Filters: []Filter{FilterFunc(func(entry *logrus.Entry) bool {
+			return entry.Level <= logrus.InfoLevel
+		})},
+		Codec:     FormatterCodec{Formatter: &logrus.JSONFormatter{}},
+		Batcher:   &testBatcher{},
+		Transport: transport,
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(pipeline)
+
+	logger.Info("first")
+	logger.Debug("filtered")
+	logger.Info("second")
+	logger.Info("third")
+	if err := pipeline.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error flushing: %v", err)
+	}
+
+	if len(transport.batches) != 2 || len(transport.batches[0]) != 2 || len(transport.batches[1]) != 1 {
+		t.Fatalf("expected batches of 2 and 1 events, got %v", transport.batches)
+	}
+	for _, batch := range transport.batches {
+		for _, event := range batch {
+			if !strings.Contains(event.Message, `"service":"billing"`) {
+				t.Errorf("expected the enriched field in %q", event.Message)
+			}
+			if strings.Contains(event.Message, "filtered") {
+				t.Errorf("expected the debug entry to be filtered")
+			}
+		}
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	transport := &testTransport{}
+	h := newTestHook(t, "group", "stream", WithTransport(transport))
+	events := []types.InputLogEvent{
+		{Message: aws.String("first"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("second"), Timestamp: aws.Int64(2500)},
+	}
+	if err := h.sendEvents(events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transport.batches) != 1 || len(transport.batches[0]) != 2 {
+		t.Fatalf("expected a single batch of 2 events, got %v", transport.batches)
+	}
+	event := transport.batches[0][1]
+	if event.Message != "second" || !event.Timestamp.Equal(time.Unix(2, 500*int64(time.Millisecond))) {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestFormatterCodecWithoutLogger(t *testing.T) {
+	line, err := FormatterCodec{}.Encode(&logrus.Entry{Message: "orphan", Data: logrus.Fields{}})
+	if err != nil || !strings.Contains(string(line), "orphan") {
+		t.Errorf("expected the entry to be encoded with the default formatter, got %q (%v)", line, err)
+	}
+}
+
+func TestEntryCodec(t *testing.T) {
+	line, err := EntryCodec{}.Encode(&logrus.Entry{
+		Time:    time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC),
+		Level:   logrus.ErrorLevel,
+		Message: "disk <full>",
+		Data:    logrus.Fields{"error": errors.New("no space"), "msg": "shadowed"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"error":"no space","fields.msg":"shadowed","level":"error","msg":"disk <full>",` +
+		`"time":"2021-03-01T12:30:00Z"}`
+	if string(line) != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+}
+
+// countingFormatter counts the entries it formats.
+type countingFormatter struct {
+	count int
+}
+
+func (f *countingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.count++
+	return []byte("counted " + entry.Message), nil
+}
+
+func TestAttachExclusive(t *testing.T) {
+	formatter := &countingFormatter{}
+	logger := logrus.New()
+	logger.SetFormatter(formatter)
+	h := newTestHook(t, "group", "stream")
+	h.AttachExclusive(logger)
+
+	if logger.Out != ioutil.Discard {
+		t.Errorf("expected the output of the logger to be discarded")
+	}
+	if len(logger.Hooks[logrus.InfoLevel]) != 1 {
+		t.Errorf("expected the hook to be added to the logger")
+	}
+	line, err := FormatterCodec{}.Encode(&logrus.Entry{Logger: logger, Message: "once"})
+	if err != nil || string(line) != "counted once" {
+		t.Errorf("expected the hook to use the original formatter, got %q (%v)", line, err)
+	}
+	if out, _ := logger.Formatter.Format(&logrus.Entry{Logger: logger, Message: "twice"}); len(out) != 0 {
+		t.Errorf("expected the logger not to format its own output, got %q", out)
+	}
+	if formatter.count != 1 {
+		t.Errorf("expected the entry to be formatted once, got %d", formatter.count)
+	}
+}
@@ -0,0 +1,121 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxPooledBufferSize is the largest serialization buffer returned to the pool; larger buffers are left for the
+	// garbage collector so a single huge entry does not pin memory.
+	maxPooledBufferSize = 64 * 1024
+
+	// initialBatchCapacity is the capacity of newly allocated batches.
+	initialBatchCapacity = 64
+)
+
+var (
+	// fieldsPool holds field maps used while formatting entries.
+	fieldsPool = sync.Pool{
+		New: func() interface{} {
+			return logrus.Fields{}
+		},
+	}
+
+	// bufferPool holds buffers used to serialize entries.
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+
+	// batchPool holds slices used to batch events.
+	batchPool = sync.Pool{
+		New: func() interface{} {
+			batch := make([]types.InputLogEvent, 0, initialBatchCapacity)
+			return &batch
+		},
+	}
+
+	// seqPool holds slices used to record the sequence numbers of batched events.
+	seqPool = sync.Pool{
+		New: func() interface{} {
+			seqs := make([]uint64, 0, initialBatchCapacity)
+			return &seqs
+		},
+	}
+)
+
+// getFields returns an empty field map from the pool.
+func getFields() logrus.Fields {
+	return fieldsPool.Get().(logrus.Fields)
+}
+
+// putFields clears the field map and returns it to the pool.
+func putFields(fields logrus.Fields) {
+	for k := range fields {
+		delete(fields, k)
+	}
+	fieldsPool.Put(fields)
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets the buffer and returns it to the pool unless it has grown too large.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// getBatchSlice returns an empty batch from the pool.
+func getBatchSlice() []types.InputLogEvent {
+	return (*batchPool.Get().(*[]types.InputLogEvent))[:0]
+}
+
+// putBatchSlice returns the batch to the pool. The events in the batch must no longer be referenced once it is
+// returned.
+func putBatchSlice(batch []types.InputLogEvent) {
+	if cap(batch) == 0 {
+		return
+	}
+	for i := range batch {
+		batch[i] = types.InputLogEvent{}
+	}
+	batch = batch[:0]
+	batchPool.Put(&batch)
+}
+
+// getSeqSlice returns an empty slice of sequence numbers from the pool.
+func getSeqSlice() []uint64 {
+	return (*seqPool.Get().(*[]uint64))[:0]
+}
+
+// putSeqSlice returns the slice of sequence numbers to the pool.
+func putSeqSlice(seqs []uint64) {
+	if cap(seqs) == 0 {
+		return
+	}
+	seqs = seqs[:0]
+	seqPool.Put(&seqs)
+}
+
+// newEvent creates a log event using a single allocation for both the message and the timestamp.
+func newEvent(message string, timestamp int64) types.InputLogEvent {
+	event := &struct {
+		message   string
+		timestamp int64
+	}{message, timestamp}
+	return types.InputLogEvent{
+		Message:   &event.message,
+		Timestamp: &event.timestamp,
+	}
+}
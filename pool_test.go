@@ -0,0 +1,102 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// benchmarkEvent keeps the compiler from optimizing away events created in benchmarks.
+var benchmarkEvent types.InputLogEvent
+
+// newBenchmarkEntry creates an entry similar to those logged by a typical application.
+func newBenchmarkEntry() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	entry := logger.WithFields(logrus.Fields{
+		"event":   "request",
+		"method":  "GET",
+		"path":    "/api/v1/users/42",
+		"status":  200,
+		"latency": 0.0042,
+	})
+	entry.Level = logrus.InfoLevel
+	entry.Message = "request 0b6b4a8e-7d5c-4c1e-9f3a-2b1d6e9c8a7f completed"
+	return entry
+}
+
+func BenchmarkFormat(b *testing.B) {
+	h := newTestHook(b, "group", "stream")
+	entry := newBenchmarkEntry()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatWithFields(b *testing.B) {
+	h := newTestHook(b, "group", "stream", WithPatternKey())
+	// the enrichers are gathered once the hook starts
+	h.pipelineEnrichers = h.optionEnrichers()
+	entry := newBenchmarkEntry()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// discardTransport drops every batch it is given.
+type discardTransport struct{}
+
+func (discardTransport) Send(ctx context.Context, events []Event) error {
+	return nil
+}
+
+func BenchmarkLoggerInfo(b *testing.B) {
+	h := newTestHook(b, "group", "stream", WithTransport(discardTransport{}))
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(h)
+	entry := newBenchmarkEntry().WithContext(context.Background())
+	entry.Logger = logger
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Info("request 0b6b4a8e-7d5c-4c1e-9f3a-2b1d6e9c8a7f completed")
+	}
+}
+
+func BenchmarkNewEvent(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkEvent = newEvent("message", int64(i))
+	}
+}
+
+func BenchmarkBatchSetAdd(b *testing.B) {
+	b.ReportAllocs()
+	batches := newBatchSet(0)
+	target := streamTarget{group: "group", stream: "stream"}
+	for i := 0; i < b.N; i++ {
+		batch := batches.add(target, 0, time.Time{})
+		for j := 0; j < 1000; j++ {
+			batch.events = append(batch.events, benchmarkEvent)
+			batch.seqs = append(batch.seqs, uint64(j))
+		}
+		batches.remove(batch)
+		putBatchSlice(batch.events)
+		putSeqSlice(batch.seqs)
+	}
+}
@@ -0,0 +1,71 @@
+package cloudwatchhook_test
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	for _, priority := range []bool{false, true} {
+		client := chaos.NewClient(chaos.Faults{})
+		clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+		options := []cloudwatchhook.CloudWatchLogsHookOption{
+			cloudwatchhook.WithClient(client),
+			cloudwatchhook.WithBatchDuration(time.Hour),
+			cloudwatchhook.WithClock(clock),
+		}
+		if priority {
+			options = append(options, cloudwatchhook.WithPriorityQueue())
+		}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream", options...)
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+		logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+		logger.AddHook(hook)
+
+		logger.Info("first")
+		clock.Advance(time.Millisecond)
+		logger.Debug("dropped")
+		logger.Info("second")
+		clock.Advance(time.Millisecond)
+
+		// let the info events be batched, since the error would otherwise jump ahead of them
+		for i := 0; i < 100 && hook.MemoryUsage().Queued > 0; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		logger.Error("failed")
+
+		// the error is uploaded at once with the batch it joined, without waiting for the batch duration
+		for i := 0; i < 100 && len(client.Events("group", "stream")) < 3; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		events := client.Events("group", "stream")
+		if !priority {
+			if len(events) != 0 || client.Calls("PutLogEvents") != 0 {
+				t.Errorf("expected the error to wait for the batch duration without a priority queue, got %d events",
+					len(events))
+			}
+		} else if len(events) != 3 {
+			t.Errorf("expected the error to be uploaded with the queued events at once, got %d events", len(events))
+		} else {
+			for i, expected := range []string{"level=info msg=first\n", "level=info msg=second\n",
+				"level=error msg=failed\n"} {
+				if msg := aws.ToString(events[i].Message); msg != expected {
+					t.Errorf("expected event %d to be %q in timestamp order, got %q", i, expected, msg)
+				}
+			}
+		}
+		if err := hook.Close(); err != nil {
+			t.Fatalf("unable to close hook: %v", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package prometheus provides a Prometheus collector for the delivery metrics of a CloudWatchLogsHook. It is a
+// separate module so that the hook itself does not depend on the Prometheus client.
+package prometheus
+
+import (
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// quantiles are the delivery latency percentiles reported by the collector, matching those in the hook Stats.
+var quantiles = []float64{0.5, 0.95, 0.99}
+
+// Collector exports the delivery latency of a hook as a histogram, along with its estimated percentiles as a summary.
+type Collector struct {
+	hook *cloudwatchhook.CloudWatchLogsHook
+
+	latency     *prometheus.Desc
+	percentiles *prometheus.Desc
+}
+
+// NewCollector creates a collector for the given hook. The labels are added to every metric, which tells apart the
+// hooks of an application which has several, such as a label holding the log group.
+func NewCollector(hook *cloudwatchhook.CloudWatchLogsHook, labels prometheus.Labels) *Collector {
+	return &Collector{
+		hook: hook,
+		latency: prometheus.NewDesc("cloudwatch_hook_delivery_latency_seconds",
+			"Time taken to deliver batches to CloudWatch Logs, from when their oldest event was written.", nil, labels),
+		percentiles: prometheus.NewDesc("cloudwatch_hook_delivery_latency_percentiles_seconds",
+			"Estimated percentiles of the time taken to deliver batches to CloudWatch Logs.", nil, labels),
+	}
+}
+
+// Describe sends the descriptions of the metrics to the channel.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.latency
+	ch <- c.percentiles
+}
+
+// Collect sends a snapshot of the metrics of the hook to the channel.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	latency := c.hook.DeliveryLatency()
+	ch <- prometheus.MustNewConstHistogram(c.latency, latency.Count, latency.Sum.Seconds(), latency.Buckets())
+
+	estimates := make(map[float64]float64, len(quantiles))
+	for _, q := range quantiles {
+		estimates[q] = latency.Quantile(q).Seconds()
+	}
+	ch <- prometheus.MustNewConstSummary(c.percentiles, latency.Count, latency.Sum.Seconds(), estimates)
+}
@@ -0,0 +1,49 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	if _, err := hook.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector(hook, prometheus.Labels{"group": "group"}))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unable to gather metrics: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			if h := metric.GetHistogram(); h != nil {
+				counts[family.GetName()] = h.GetSampleCount()
+			}
+			if s := metric.GetSummary(); s != nil {
+				counts[family.GetName()] = s.GetSampleCount()
+				if n := len(s.GetQuantile()); n != len(quantiles) {
+					t.Errorf("expected %d percentiles, got %d", len(quantiles), n)
+				}
+			}
+		}
+	}
+	for _, name := range []string{"cloudwatch_hook_delivery_latency_seconds",
+		"cloudwatch_hook_delivery_latency_percentiles_seconds"} {
+		if counts[name] != 1 {
+			t.Errorf("expected %s to count the delivered batch, got %v", name, counts)
+		}
+	}
+}
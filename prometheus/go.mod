@@ -0,0 +1,11 @@
+module github.com/josh-hogle/logrus-cloudwatch-hook/prometheus
+
+go 1.16
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.2.0
+	github.com/josh-hogle/logrus-cloudwatch-hook v0.9.0
+	github.com/prometheus/client_golang v1.9.0
+)
+
+replace github.com/josh-hogle/logrus-cloudwatch-hook => ../
@@ -0,0 +1,140 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+)
+
+// ProvisionAPI is the part of the Amazon CloudWatch Logs API used to apply the KMS key and tags of the options to a
+// log group which already exists. It is only required of the client by Provision.
+type ProvisionAPI interface {
+	AssociateKmsKey(ctx context.Context, params *cloudwatchlogs.AssociateKmsKeyInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.AssociateKmsKeyOutput, error)
+	TagLogGroup(ctx context.Context, params *cloudwatchlogs.TagLogGroupInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagLogGroupOutput, error)
+}
+
+// ProvisionReport describes the changes made by Provision.
+type ProvisionReport struct {
+	// Actions describes each change made, in the order it was made. It is empty if everything was already in place.
+	Actions []string
+}
+
+// Provision creates the log group and stream ahead of time so that the application can run with the WithNoCreate
+// option. Unlike the hook, which only applies them to a group it creates, the retention period, KMS key and tags of the
+// options are also applied to a group which already exists. Each step doubles as a check that the credentials are
+// allowed to make the change, and an error names the operation which was denied. Options which only affect how events
+// are sent have no effect.
+func Provision(config aws.Config, group, stream string, options ...CloudWatchLogsHookOption) (ProvisionReport, error) {
+	var report ProvisionReport
+	h, err := newHook(config, group, stream, options...)
+	if err != nil {
+		return report, err
+	}
+	h.createClient()
+
+	existing, err := h.findLogGroup(context.TODO(), h.group)
+	if err != nil {
+		return report, provisionErr("DescribeLogGroups", err)
+	}
+	if existing == nil {
+		if err := h.createLogGroup(); err != nil {
+			return report, provisionErr("CreateLogGroup", err)
+		}
+		report.Actions = append(report.Actions, fmt.Sprintf("Created log group %s", h.group))
+	} else if err := h.updateLogGroup(&report); err != nil {
+		return report, err
+	}
+
+	found, err := h.findLogStream()
+	if err != nil {
+		return report, provisionErr("DescribeLogStreams", err)
+	}
+	if found == nil {
+		_, err := h.client.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(h.group),
+			LogStreamName: aws.String(h.stream),
+		})
+		if err != nil {
+			return report, provisionErr("CreateLogStream", err)
+		}
+		report.Actions = append(report.Actions, fmt.Sprintf("Created log stream %s", h.stream))
+	}
+	return report, nil
+}
+
+// updateLogGroup applies the retention period, KMS key and tags of the options to the existing log group.
+func (h *CloudWatchLogsHook) updateLogGroup(report *ProvisionReport) error {
+	if h.retentionDays > 0 {
+		if err := h.setRetentionPolicy(); err != nil {
+			return provisionErr("PutRetentionPolicy", err)
+		}
+		report.Actions = append(report.Actions, fmt.Sprintf("Set retention of log group %s to %d days", h.group,
+			h.retentionDays))
+	}
+	if h.kmsKeyID == "" && len(h.tags) == 0 {
+		return nil
+	}
+	client, ok := h.client.(ProvisionAPI)
+	if !ok {
+		return fmt.Errorf("Unable to update log group %s: client does not support KMS keys and tags", h.group)
+	}
+	if h.kmsKeyID != "" {
+		_, err := client.AssociateKmsKey(context.TODO(), &cloudwatchlogs.AssociateKmsKeyInput{
+			LogGroupName: aws.String(h.group),
+			KmsKeyId:     aws.String(h.kmsKeyID),
+		})
+		if err != nil {
+			return provisionErr("AssociateKmsKey", err)
+		}
+		report.Actions = append(report.Actions, fmt.Sprintf("Associated KMS key %s with log group %s", h.kmsKeyID,
+			h.group))
+	}
+	if len(h.tags) > 0 {
+		_, err := client.TagLogGroup(context.TODO(), &cloudwatchlogs.TagLogGroupInput{
+			LogGroupName: aws.String(h.group),
+			Tags:         h.tags,
+		})
+		if err != nil {
+			return provisionErr("TagLogGroup", err)
+		}
+		report.Actions = append(report.Actions, fmt.Sprintf("Tagged log group %s with %d tags", h.group,
+			len(h.tags)))
+	}
+	return nil
+}
+
+// provisionErr describes the failure of an operation, pointing out when the credentials were not allowed to call it.
+func provisionErr(operation string, err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException" {
+		return fmt.Errorf("Unable to provision: the IAM policy does not allow logs:%s: %v", operation, err)
+	}
+	return fmt.Errorf("Unable to provision: %s failed: %v", operation, err)
+}
+
+// requireResources makes sure the log group and stream, which the hook does not create, already exist.
+func (h *CloudWatchLogsHook) requireResources() error {
+	if h.groupSelector == nil {
+		group, err := h.findLogGroup(h.lookupContext(), h.group)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return fmt.Errorf("Log group %s does not exist; it must be provisioned before the hook is created", h.group)
+		}
+	}
+	stream, err := h.findLogStream()
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return fmt.Errorf("Log stream %s does not exist; it must be provisioned before the hook is created", h.stream)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestProvision(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+
+	// the hook refuses to start until the group and stream are provisioned
+	_, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/payments", "web-1",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithNoCreate())
+	if err == nil {
+		t.Fatalf("expected an error for a missing log group")
+	}
+
+	report, err := cloudwatchhook.Provision(aws.Config{}, "/app/payments", "web-1", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithGroupRetentionDays(30))
+	if err != nil {
+		t.Fatalf("unable to provision: %v", err)
+	}
+	if len(report.Actions) != 2 {
+		t.Errorf("expected the group and stream to be created, got %v", report.Actions)
+	}
+
+	// provisioning again applies the tags to the existing group
+	report, err = cloudwatchhook.Provision(aws.Config{}, "/app/payments", "web-1", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithGroupTags(map[string]string{"team": "payments"}))
+	if err != nil {
+		t.Fatalf("unable to provision: %v", err)
+	}
+	if len(report.Actions) != 1 || client.Calls("CreateLogGroup") != 1 {
+		t.Errorf("expected only the tags to be applied, got %v", report.Actions)
+	}
+	tags, _ := client.ListTagsLogGroup(context.Background(), &cloudwatchlogs.ListTagsLogGroupInput{
+		LogGroupName: aws.String("/app/payments"),
+	})
+	if tags.Tags["team"] != "payments" {
+		t.Errorf("expected the group to be tagged, got %v", tags.Tags)
+	}
+
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/payments", "web-1",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithNoCreate())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("provisioned")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hook.Close()
+	if n := len(client.Events("/app/payments", "web-1")); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+	if n := client.Calls("CreateLogStream"); n != 1 {
+		t.Errorf("expected the hook not to create the stream, got %d calls", n)
+	}
+}
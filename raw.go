@@ -0,0 +1,40 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// validateRawMessage checks that the message can be sent to Amazon CloudWatch as is.
+func validateRawMessage(msg []byte) error {
+	if strings.TrimSpace(string(msg)) == "" {
+		return fmt.Errorf("Invalid raw message: must not be empty or only whitespace")
+	}
+	if len(msg) > maxMessageSize {
+		return fmt.Errorf("Invalid raw message: %d bytes exceeds the maximum of %d", len(msg), maxMessageSize)
+	}
+	if !utf8.Valid(msg) {
+		return fmt.Errorf("Invalid raw message: not valid UTF-8")
+	}
+	return nil
+}
+
+// writeRaw sends the message without sanitizing it, after checking it would be accepted by Amazon CloudWatch.
+func (h *CloudWatchLogsHook) writeRaw(msg []byte) (int, error) {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return 0, ErrClosed
+	}
+	if err := validateRawMessage(msg); err != nil {
+		return 0, err
+	}
+	if h.filtered(string(msg)) {
+		return len(msg), nil
+	}
+	h.countLogged(len(msg), nil)
+	if err := h.enqueue(string(msg), false, nil); err != nil {
+		return 0, err
+	}
+	return len(msg), nil
+}
@@ -0,0 +1,40 @@
+package cloudwatchhook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRawMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   []byte
+		valid bool
+	}{
+		{"json", []byte(`{"level":"info","msg":"ok"}` + "\n"), true},
+		{"empty", []byte{}, false},
+		{"whitespace", []byte(" \t\r\n"), false},
+		{"too large", []byte(strings.Repeat("a", maxMessageSize+1)), false},
+		{"invalid UTF-8", []byte("bad \xff byte"), false},
+	}
+	for _, test := range tests {
+		if err := validateRawMessage(test.msg); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid=%v, got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestWithRawMessages(t *testing.T) {
+	transport := &testTransport{}
+	h := newTestHook(t, "group", "stream", WithTransport(transport), WithRawMessages(), WithControlCharStripping(""))
+	line := "{\"msg\":\"tab\\there\"}\t\n"
+	if n, err := h.Write([]byte(line)); err != nil || n != len(line) {
+		t.Fatalf("unexpected result writing raw message: %d, %v", n, err)
+	}
+	if n, err := h.Write([]byte("   ")); err == nil || n != 0 {
+		t.Fatalf("expected an error writing whitespace, got %d, %v", n, err)
+	}
+	if len(transport.batches) != 1 || transport.batches[0][0].Message != line {
+		t.Errorf("expected the messages to be sent as is, got %v", transport.batches)
+	}
+}
@@ -0,0 +1,76 @@
+package cloudwatchhook
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// BatchReceipt describes a batch of log events successfully delivered to Amazon CloudWatch.
+type BatchReceipt struct {
+	// Group and Stream identify where the batch was delivered.
+	Group  string
+	Stream string
+
+	// Events is the number of events in the batch, including any rejected events.
+	Events int
+
+	// Rejected is the number of events accepted as part of the batch but not stored by Amazon CloudWatch because they
+	// were too old, too new or expired.
+	Rejected int
+
+	// Bytes is the size of the batch as counted by Amazon CloudWatch, which adds 26 bytes to each message.
+	Bytes int
+
+	// Attempts is the number of PutLogEvents calls made to deliver the batch.
+	Attempts int
+
+	// Latency is the duration of the successful PutLogEvents call.
+	Latency time.Duration
+
+	// Oldest and Newest are the timestamps of the oldest and newest events in the batch.
+	Oldest time.Time
+	Newest time.Time
+
+	// DeliveredAt is the time at which the batch was delivered. The delivery lag of the batch is the time between
+	// Oldest and DeliveredAt.
+	DeliveredAt time.Time
+
+	// RequestID and ExtendedRequestID identify the successful PutLogEvents call, so that it can be referenced in
+	// support cases with AWS. They are empty if the service did not return them.
+	RequestID         string
+	ExtendedRequestID string
+}
+
+// newBatchReceipt creates the receipt for a delivered batch.
+func (h *CloudWatchLogsHook) newBatchReceipt(events []types.InputLogEvent, rejected *types.RejectedLogEventsInfo,
+	attempts int, latency time.Duration) BatchReceipt {
+
+	target := h.boundTarget()
+	receipt := BatchReceipt{
+		Group:             target.group,
+		Stream:            target.stream,
+		Events:            len(events),
+		Rejected:          len(rejectedEvents(events, rejected)),
+		Attempts:          attempts,
+		Latency:           latency,
+		DeliveredAt:       h.clock.Now(),
+		RequestID:         h.lastRequest.id,
+		ExtendedRequestID: h.lastRequest.extended,
+	}
+	var oldest, newest int64
+	for i, event := range events {
+		receipt.Bytes += len(aws.ToString(event.Message)) + 26
+		ts := aws.ToInt64(event.Timestamp)
+		if i == 0 || ts < oldest {
+			oldest = ts
+		}
+		if i == 0 || ts > newest {
+			newest = ts
+		}
+	}
+	receipt.Oldest = time.Unix(0, oldest*int64(time.Millisecond))
+	receipt.Newest = time.Unix(0, newest*int64(time.Millisecond))
+	return receipt
+}
@@ -0,0 +1,29 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestNewBatchReceipt(t *testing.T) {
+	h := newTestHook(t, "group", "stream")
+	events := []types.InputLogEvent{
+		{Message: aws.String("first"), Timestamp: aws.Int64(2000)},
+		{Message: aws.String("second"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("third"), Timestamp: aws.Int64(3000)},
+	}
+	receipt := h.newBatchReceipt(events, &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(1)}, 2,
+		time.Millisecond)
+	if receipt.Events != 3 || receipt.Rejected != 1 || receipt.Attempts != 2 {
+		t.Errorf("unexpected counts in receipt: %+v", receipt)
+	}
+	if receipt.Bytes != len("firstsecondthird")+3*26 {
+		t.Errorf("expected %d bytes, got %d", len("firstsecondthird")+3*26, receipt.Bytes)
+	}
+	if !receipt.Oldest.Equal(time.Unix(1, 0)) || !receipt.Newest.Equal(time.Unix(3, 0)) {
+		t.Errorf("unexpected time range in receipt: %v to %v", receipt.Oldest, receipt.Newest)
+	}
+}
@@ -0,0 +1,50 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PanicField is the name of the field holding the value of a panic logged by RecoverAndFlush.
+	PanicField = "panic"
+
+	// PanicStackField is the name of the field holding the stack trace of a panic logged by RecoverAndFlush.
+	PanicStackField = "panic_stack"
+
+	// DefaultRecoverFlushTimeout is a reasonable time for RecoverAndFlush to wait for queued log events to be sent.
+	DefaultRecoverFlushTimeout = 5 * time.Second
+)
+
+// RecoverAndFlush is used to make sure the evidence of a crash reaches Amazon CloudWatch. Defer it at the top of main
+// and of any goroutine:
+//
+//	defer cloudwatchhook.RecoverAndFlush(hook, log, cloudwatchhook.DefaultRecoverFlushTimeout)
+//
+// If the function panics, the panic is logged along with its stack trace, the events queued by the hook are sent,
+// waiting at most the given timeout, and the panic is resumed. The hook is left open, so logging carries on if the
+// panic is recovered further up the stack, as net/http does for the panics of its handlers.
+func RecoverAndFlush(hook *CloudWatchLogsHook, logger *logrus.Logger, timeout time.Duration) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		PanicField:      fmt.Sprint(r),
+		PanicStackField: string(debug.Stack()),
+	}).Error("Recovered from panic")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hook.drain(ctx)
+	}()
+	hook.sleep(timeout, done)
+	cancel()
+	panic(r)
+}
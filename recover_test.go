@@ -0,0 +1,55 @@
+package cloudwatchhook_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecoverAndFlush(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+
+	var repanicked interface{}
+	func() {
+		defer func() {
+			repanicked = recover()
+		}()
+		defer cloudwatchhook.RecoverAndFlush(hook, logger, cloudwatchhook.DefaultRecoverFlushTimeout)
+		panic("boom")
+	}()
+
+	if repanicked != "boom" {
+		t.Errorf("expected the panic to be resumed, got %v", repanicked)
+	}
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("expected the panic to be flushed, got %d events", len(events))
+	}
+	if msg := aws.ToString(events[0].Message); !strings.Contains(msg, "boom") ||
+		!strings.Contains(msg, cloudwatchhook.PanicStackField) {
+		t.Errorf("expected the panic and its stack in the event, got %s", msg)
+	}
+
+	// the hook stays open in case the panic is recovered further up the stack
+	logger.Info("still logging")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	if n := len(client.Events("group", "stream")); n != 2 {
+		t.Errorf("expected logging to carry on after the panic, got %d events", n)
+	}
+}
@@ -0,0 +1,296 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	// DefaultRelayMaxReceives is the number of times an SQSRelay receives a message which cannot be delivered before
+	// giving up on it.
+	DefaultRelayMaxReceives = 5
+
+	// DefaultRelayMaxHooks is the number of hooks an SQSRelay keeps open, one for each group and stream it has
+	// recently delivered to.
+	DefaultRelayMaxHooks = 100
+)
+
+// SQSMessage is a message received from an Amazon SQS queue.
+type SQSMessage struct {
+	Body          string
+	ReceiptHandle string
+
+	// ReceiveCount is the number of times the message has been received, taken from its ApproximateReceiveCount
+	// attribute, or zero if unknown.
+	ReceiveCount int32
+}
+
+// SQSClient is the subset of Amazon SQS operations needed to relay log events through a queue. It is typically a thin
+// wrapper around an Amazon SQS client so the hook does not need to depend on the SQS SDK.
+type SQSClient interface {
+	// SendMessage sends a message with the given body to the queue.
+	SendMessage(ctx context.Context, queueURL, body string) error
+
+	// ReceiveMessages receives up to max messages from the queue, waiting for messages to arrive if necessary. The
+	// ApproximateReceiveCount attribute should be requested so the receive count of each message is known.
+	ReceiveMessages(ctx context.Context, queueURL string, max int32) ([]SQSMessage, error)
+
+	// DeleteMessage removes a message that has been processed from the queue.
+	DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error
+}
+
+// relayMessage is the body of a message sent to the relay queue.
+type relayMessage struct {
+	Group  string       `json:"group"`
+	Stream string       `json:"stream"`
+	Events []relayEvent `json:"events"`
+}
+
+// relayEvent is a single log event within a relay message.
+type relayEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// relayMessages encodes the events into as many relay message bodies as needed to stay within the SQS message size
+// limit.
+func relayMessages(group, stream string, events []types.InputLogEvent) ([]string, error) {
+	header, err := json.Marshal(relayMessage{Group: group, Stream: stream})
+	if err != nil {
+		return nil, err
+	}
+
+	var bodies []string
+	msg := relayMessage{Group: group, Stream: stream}
+	size := len(header)
+	for _, event := range events {
+		e := relayEvent{
+			Timestamp: aws.ToInt64(event.Timestamp),
+			Message:   aws.ToString(event.Message),
+		}
+		encoded, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		if len(msg.Events) > 0 && size+len(encoded)+1 > maxSQSMessageSize {
+			body, err := json.Marshal(msg)
+			if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, string(body))
+			msg.Events = nil
+			size = len(header)
+		}
+		msg.Events = append(msg.Events, e)
+		size += len(encoded) + 1
+	}
+	if len(msg.Events) > 0 {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, string(body))
+	}
+	return bodies, nil
+}
+
+// relayEvents sends the events to the relay queue instead of Amazon CloudWatch.
+func (h *CloudWatchLogsHook) relayEvents(events []types.InputLogEvent) error {
+	target := h.boundTarget()
+	bodies, err := relayMessages(target.group, target.stream, events)
+	if err != nil {
+		return fmt.Errorf("Unable to encode relay message: %v", err)
+	}
+	for _, body := range bodies {
+		if err := h.sqsClient.SendMessage(h.putContext(), h.relay, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SQSRelay drains log events enqueued by hooks created with the WithSQSRelay option and delivers them to Amazon
+// CloudWatch. It can be run as a goroutine within an application or as a separate sidecar process.
+type SQSRelay struct {
+	config      aws.Config
+	client      SQSClient
+	queueURL    string
+	options     []CloudWatchLogsHookOption
+	maxReceives int32
+	maxHooks    int
+
+	mutex  sync.Mutex
+	hooks  map[string]*CloudWatchLogsHook
+	recent []string
+	closed bool
+}
+
+// NewSQSRelay creates a new relay which reads from the queue with the given URL and writes to Amazon CloudWatch using
+// the given configuration. The options are applied to the hook created for each group and stream found in the queue.
+func NewSQSRelay(config aws.Config, client SQSClient, queueURL string, options ...CloudWatchLogsHookOption) *SQSRelay {
+	return &SQSRelay{
+		config:      config,
+		client:      client,
+		queueURL:    queueURL,
+		options:     options,
+		maxReceives: DefaultRelayMaxReceives,
+		maxHooks:    DefaultRelayMaxHooks,
+		hooks:       map[string]*CloudWatchLogsHook{},
+		recent:      nil,
+		closed:      false,
+	}
+}
+
+// SetMaxReceives sets the number of times a message which cannot be delivered is received before it is deleted from
+// the queue. Its events are handed to the dead letter sink of the hook, if one was given, on every failed attempt.
+// A value of zero never gives up, leaving failed messages to the redrive policy of the queue. It must be called before
+// Run.
+func (r *SQSRelay) SetMaxReceives(n int32) {
+	r.maxReceives = n
+}
+
+// SetMaxHooks sets the number of hooks the relay keeps open. Each group and stream found in the queue is written
+// through its own hook; once the limit is reached, the hook used least recently is closed to make room for a new one.
+// A value of zero or less keeps every hook open. It must be called before Run.
+func (r *SQSRelay) SetMaxHooks(n int) {
+	r.maxHooks = n
+}
+
+// Run receives messages from the queue and delivers them to Amazon CloudWatch until the context is cancelled or the
+// queue cannot be read. Messages are only deleted from the queue once they have been delivered, so messages which
+// fail are received again once their visibility timeout expires, until they have been received the maximum number of
+// times.
+func (r *SQSRelay) Run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		messages, err := r.client.ReceiveMessages(ctx, r.queueURL, 10)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for _, m := range messages {
+			// give up on a message which keeps failing so it is not received forever
+			if err := r.deliver(m.Body); err != nil && (r.maxReceives <= 0 || m.ReceiveCount < r.maxReceives) {
+				continue
+			}
+			if err := r.client.DeleteMessage(ctx, r.queueURL, m.ReceiptHandle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// deliver sends the events in a single relay message to Amazon CloudWatch.
+func (r *SQSRelay) deliver(body string) error {
+	var msg relayMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		return fmt.Errorf("Unable to decode relay message: %v", err)
+	}
+	if len(msg.Events) == 0 {
+		return nil
+	}
+	hook, err := r.hook(msg.Group, msg.Stream)
+	if err != nil {
+		return err
+	}
+
+	// PutLogEvents requires events in chronological order
+	sort.SliceStable(msg.Events, func(i, j int) bool {
+		return msg.Events[i].Timestamp < msg.Events[j].Timestamp
+	})
+	events := make([]types.InputLogEvent, 0, len(msg.Events))
+	for _, e := range msg.Events {
+		events = append(events, types.InputLogEvent{
+			Message:   aws.String(e.Message),
+			Timestamp: aws.Int64(e.Timestamp),
+		})
+	}
+	hook.mutex.Lock()
+	defer hook.mutex.Unlock()
+	return hook.sendEvents(events)
+}
+
+// hook returns the hook used to write to the given group and stream, creating it if necessary and closing the hook
+// used least recently if the relay holds too many.
+func (r *SQSRelay) hook(group, stream string) (*CloudWatchLogsHook, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+	key := group + ":" + stream
+	if hook, ok := r.hooks[key]; ok {
+		r.touch(key)
+		return hook, nil
+	}
+
+	// make sure the hook writes directly to Amazon CloudWatch even if it was given the relay option
+	options := append([]CloudWatchLogsHookOption{}, r.options...)
+	options = append(options, func(o *hookOptions) {
+		o.relay = ""
+		o.sqsClient = nil
+	})
+	hook, err := NewCloudWatchLogsHook(r.config, group, stream, options...)
+	if err != nil {
+		return nil, err
+	}
+	if r.maxHooks > 0 && len(r.hooks) >= r.maxHooks {
+		evicted := r.recent[0]
+		r.recent = r.recent[1:]
+		r.hooks[evicted].Close()
+		delete(r.hooks, evicted)
+	}
+	r.hooks[key] = hook
+	r.recent = append(r.recent, key)
+	return hook, nil
+}
+
+// touch marks the hook for the given key as the one used most recently. The caller must hold the mutex.
+func (r *SQSRelay) touch(key string) {
+	for i, k := range r.recent {
+		if k == key {
+			r.recent = append(append(r.recent[:i:i], r.recent[i+1:]...), key)
+			return
+		}
+	}
+}
+
+// Close closes every hook of the relay, returning the first error encountered. Messages received afterwards are left
+// in the queue. Run should be stopped first.
+func (r *SQSRelay) Close() error {
+	_, err := r.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext closes every hook of the relay like Close, but gives up once the context is done. The result reports
+// how many events were flushed and abandoned across the hooks.
+func (r *SQSRelay) CloseContext(ctx context.Context) (CloseResult, error) {
+	r.mutex.Lock()
+	hooks := r.hooks
+	r.hooks = map[string]*CloudWatchLogsHook{}
+	r.recent = nil
+	r.closed = true
+	r.mutex.Unlock()
+
+	var result CloseResult
+	var firstErr error
+	for _, hook := range hooks {
+		hookResult, err := hook.CloseContext(ctx)
+		result.Flushed += hookResult.Flushed
+		result.Abandoned += hookResult.Abandoned
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return result, firstErr
+}
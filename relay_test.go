@@ -0,0 +1,194 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// errQueueEmpty is returned by a memoryQueue once every message has been deleted, which stops the relay.
+var errQueueEmpty = errors.New("queue is empty")
+
+// memoryQueue is an in-memory SQSClient for a single queue which counts how many times each message is received.
+type memoryQueue struct {
+	url string
+
+	mutex    sync.Mutex
+	messages []cloudwatchhook.SQSMessage
+	handles  int
+}
+
+func (q *memoryQueue) SendMessage(ctx context.Context, queueURL, body string) error {
+	if queueURL != q.url {
+		return fmt.Errorf("unknown queue %s", queueURL)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.handles++
+	q.messages = append(q.messages, cloudwatchhook.SQSMessage{Body: body, ReceiptHandle: strconv.Itoa(q.handles)})
+	return nil
+}
+
+func (q *memoryQueue) ReceiveMessages(ctx context.Context, queueURL string, max int32) ([]cloudwatchhook.SQSMessage,
+	error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.messages) == 0 {
+		return nil, errQueueEmpty
+	}
+	var received []cloudwatchhook.SQSMessage
+	for i := range q.messages {
+		if int32(len(received)) == max {
+			break
+		}
+		q.messages[i].ReceiveCount++
+		received = append(received, q.messages[i])
+	}
+	return received, nil
+}
+
+func (q *memoryQueue) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, m := range q.messages {
+		if m.ReceiptHandle == receiptHandle {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown receipt handle %s", receiptHandle)
+}
+
+// failingTransport fails to send every batch.
+type failingTransport struct{}
+
+func (failingTransport) Send(ctx context.Context, events []cloudwatchhook.Event) error {
+	return errors.New("destination unavailable")
+}
+
+// stuckQueue is an SQSClient whose sends never complete until they are cancelled.
+type stuckQueue struct {
+	*memoryQueue
+}
+
+func (q stuckQueue) SendMessage(ctx context.Context, queueURL, body string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestSQSRelayCancelledOnClose(t *testing.T) {
+	queue := stuckQueue{&memoryQueue{url: "queue"}}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithSQSRelay(queue.url), cloudwatchhook.WithSQSClient(queue),
+		cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	fmt.Fprint(hook, "stuck")
+
+	// the send in progress is cancelled once the deadline for closing the hook passes
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() {
+		_, err := hook.CloseContext(ctx)
+		closed <- err
+	}()
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Errorf("expected the event to be abandoned")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected closing the hook to interrupt the stuck send")
+	}
+}
+
+func TestSQSRelay(t *testing.T) {
+	queue := &memoryQueue{url: "https://sqs.us-east-1.amazonaws.com/123456789012/logs"}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithSQSRelay(queue.url), cloudwatchhook.WithSQSClient(queue))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(hook, "event %d", i)
+	}
+
+	client := chaos.NewClient(chaos.Faults{})
+	relay := cloudwatchhook.NewSQSRelay(aws.Config{}, queue, queue.url, cloudwatchhook.WithClient(client))
+	if err := relay.Run(context.Background()); err != errQueueEmpty {
+		t.Fatalf("expected the relay to drain the queue, got %v", err)
+	}
+	events := client.Events("group", "stream")
+	if len(events) != 3 || aws.ToString(events[0].Message) != "event 0" {
+		t.Errorf("expected the events to be relayed to CloudWatch, got %v", events)
+	}
+}
+
+func TestSQSRelayPoisonMessages(t *testing.T) {
+	for _, maxReceives := range []int32{cloudwatchhook.DefaultRelayMaxReceives, 2} {
+		queue := &memoryQueue{url: "queue"}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+			cloudwatchhook.WithSQSRelay(queue.url), cloudwatchhook.WithSQSClient(queue))
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		fmt.Fprint(hook, "undeliverable")
+		queue.SendMessage(context.Background(), queue.url, "not a relay message")
+
+		recorder := &chaos.DeadLetterRecorder{}
+		relay := cloudwatchhook.NewSQSRelay(aws.Config{}, queue, queue.url,
+			cloudwatchhook.WithTransport(failingTransport{}), cloudwatchhook.WithDeadLetterSink(recorder))
+		relay.SetMaxReceives(maxReceives)
+		if err := relay.Run(context.Background()); err != errQueueEmpty {
+			t.Fatalf("expected messages which keep failing to be deleted, got %v", err)
+		}
+		if n := len(recorder.Messages()); n != int(maxReceives) {
+			t.Errorf("expected the event to be dead lettered on each of %d receives, got %d", maxReceives, n)
+		}
+	}
+}
+
+func TestSQSRelayMaxHooks(t *testing.T) {
+	queue := &memoryQueue{url: "queue"}
+	writers := map[string]*cloudwatchhook.CloudWatchLogsHook{}
+	for _, stream := range []string{"a", "b", "c"} {
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", stream,
+			cloudwatchhook.WithSQSRelay(queue.url), cloudwatchhook.WithSQSClient(queue))
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		writers[stream] = hook
+	}
+	for _, stream := range []string{"a", "b", "c", "a"} {
+		fmt.Fprintf(writers[stream], "event for %s", stream)
+	}
+
+	// the hook for the first stream is closed to make room for the third, and created again for the last message
+	client := chaos.NewClient(chaos.Faults{})
+	relay := cloudwatchhook.NewSQSRelay(aws.Config{}, queue, queue.url, cloudwatchhook.WithClient(client))
+	relay.SetMaxHooks(2)
+	if err := relay.Run(context.Background()); err != errQueueEmpty {
+		t.Fatalf("expected the relay to drain the queue, got %v", err)
+	}
+	if err := relay.Close(); err != nil {
+		t.Fatalf("unable to close relay: %v", err)
+	}
+	if n := client.Calls("DescribeLogGroups"); n != 4 {
+		t.Errorf("expected 4 hooks to be created, got %d", n)
+	}
+	for stream, expected := range map[string]int{"a": 2, "b": 1, "c": 1} {
+		if n := len(client.Events("group", stream)); n != expected {
+			t.Errorf("expected %d events in stream %s, got %d", expected, stream, n)
+		}
+	}
+}
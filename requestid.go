@@ -0,0 +1,44 @@
+package cloudwatchhook
+
+import (
+	"errors"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// extendedRequestIDHeader is the response header holding the extended request ID, which AWS support may ask for
+// along with the request ID.
+const extendedRequestIDHeader = "X-Amz-Id-2"
+
+// requestIDs identifies a call to AWS, so that AWS support can find it.
+type requestIDs struct {
+	id       string
+	extended string
+}
+
+// responseRequestIDs returns the IDs of the successful call which produced the metadata.
+func responseRequestIDs(metadata middleware.Metadata) requestIDs {
+	var ids requestIDs
+	ids.id, _ = awsmiddleware.GetRequestIDMetadata(metadata)
+	if response, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok && response != nil {
+		ids.extended = response.Header.Get(extendedRequestIDHeader)
+	}
+	return ids
+}
+
+// errorRequestIDs returns the IDs of the failed call which returned the error, if it reached AWS.
+func errorRequestIDs(err error) requestIDs {
+	var ids requestIDs
+	var respErr *awshttp.ResponseError
+	if !errors.As(err, &respErr) {
+		return ids
+	}
+	ids.id = respErr.ServiceRequestID()
+	if respErr.ResponseError != nil && respErr.Response != nil && respErr.Response.Response != nil {
+		ids.extended = respErr.Response.Header.Get(extendedRequestIDHeader)
+	}
+	return ids
+}
@@ -0,0 +1,37 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestRequestIDs(t *testing.T) {
+	var metadata middleware.Metadata
+	awsmiddleware.SetRequestIDMetadata(&metadata, "2b3f9a6e-delivered")
+	if ids := responseRequestIDs(metadata); ids.id != "2b3f9a6e-delivered" {
+		t.Errorf("unexpected request IDs: %+v", ids)
+	}
+
+	header := http.Header{}
+	header.Set(extendedRequestIDHeader, "extended")
+	err := fmt.Errorf("PutLogEvents: %w", &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: 400, Header: header}},
+			Err:      &types.InvalidParameterException{},
+		},
+		RequestID: "7c1d-failed",
+	})
+	if ids := errorRequestIDs(err); ids.id != "7c1d-failed" || ids.extended != "extended" {
+		t.Errorf("unexpected request IDs: %+v", ids)
+	}
+	if ids := errorRequestIDs(fmt.Errorf("connection refused")); ids != (requestIDs{}) {
+		t.Errorf("expected no request IDs for an error which never reached AWS, got %+v", ids)
+	}
+}
@@ -0,0 +1,63 @@
+package cloudwatchhook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// StreamInfo holds the identity of the log stream written to by a hook, as reported by Amazon CloudWatch.
+type StreamInfo struct {
+	Group        string    `json:"group"`
+	Stream       string    `json:"stream"`
+	GroupARN     string    `json:"group_arn,omitempty"`
+	StreamARN    string    `json:"stream_arn,omitempty"`
+	CreationTime time.Time `json:"creation_time,omitempty"`
+}
+
+// GroupARN returns the ARN of the log group, without the trailing ":*" wildcard, or an empty string if it is not known
+// because the hook relays events through Amazon SQS.
+func (h *CloudWatchLogsHook) GroupARN() string {
+	h.resourceMutex.RLock()
+	defer h.resourceMutex.RUnlock()
+	return h.groupARN
+}
+
+// StreamInfo returns the identity of the log stream, including the ARNs of the group and stream. The ARNs and creation
+// time are empty if they are not known because the hook relays events through Amazon SQS.
+func (h *CloudWatchLogsHook) StreamInfo() StreamInfo {
+	h.resourceMutex.RLock()
+	defer h.resourceMutex.RUnlock()
+	return StreamInfo{
+		Group:        h.group,
+		Stream:       h.stream,
+		GroupARN:     h.groupARN,
+		StreamARN:    h.streamARN,
+		CreationTime: h.streamCreationTime,
+	}
+}
+
+// setGroupInfo records the ARN of the log group from a Describe result.
+func (h *CloudWatchLogsHook) setGroupInfo(group *types.LogGroup) {
+	h.resourceMutex.Lock()
+	defer h.resourceMutex.Unlock()
+	h.groupARN = strings.TrimSuffix(aws.ToString(group.Arn), ":*")
+	if h.streamARN == "" && h.groupARN != "" {
+		// the stream is not always described, but its ARN is always derived from the ARN of the group
+		h.streamARN = h.groupARN + ":log-stream:" + h.stream
+	}
+}
+
+// setStreamInfo records the ARN and creation time of the log stream from a Describe result.
+func (h *CloudWatchLogsHook) setStreamInfo(stream *types.LogStream) {
+	h.resourceMutex.Lock()
+	defer h.resourceMutex.Unlock()
+	if arn := aws.ToString(stream.Arn); arn != "" {
+		h.streamARN = arn
+	}
+	if stream.CreationTime != nil {
+		h.streamCreationTime = time.Unix(0, aws.ToInt64(stream.CreationTime)*int64(time.Millisecond))
+	}
+}
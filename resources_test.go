@@ -0,0 +1,58 @@
+package cloudwatchhook_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestStreamInfo(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	before := time.Now().Add(-time.Millisecond)
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	// the ARNs are known once the group and stream have been created and described
+	groupARN := "arn:aws:logs:us-east-1:123456789012:log-group:/app/group"
+	if arn := hook.GroupARN(); arn != groupARN {
+		t.Errorf("expected the group ARN %s without the wildcard, got %s", groupARN, arn)
+	}
+	info := hook.StreamInfo()
+	if info.Group != "/app/group" || info.Stream != "stream" || info.GroupARN != groupARN ||
+		info.StreamARN != groupARN+":log-stream:stream" {
+		t.Errorf("unexpected stream info: %+v", info)
+	}
+	if info.CreationTime.Before(before) || info.CreationTime.After(time.Now()) {
+		t.Errorf("expected the stream to have been created during the test, got %v", info.CreationTime)
+	}
+
+	// a second hook on the existing stream reports when it was first created
+	other, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer other.Close()
+	if otherInfo := other.StreamInfo(); otherInfo != info {
+		t.Errorf("expected the same stream info for the existing stream, got %+v and %+v", otherInfo, info)
+	}
+
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("unable to encode stream info: %v", err)
+	}
+	for _, field := range []string{`"group":`, `"stream":`, `"group_arn":`, `"stream_arn":`, `"creation_time":`} {
+		if !strings.Contains(string(encoded), field) {
+			t.Errorf("expected %s in %s", field, encoded)
+		}
+	}
+}
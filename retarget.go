@@ -0,0 +1,85 @@
+package cloudwatchhook
+
+// RetargetPolicy determines which stream events are delivered to when the hook is pointed at a different stream while
+// they are still queued.
+type RetargetPolicy int
+
+const (
+	// RetargetKeepOriginal delivers queued events to the stream which was active when they were emitted. This is the
+	// default policy.
+	RetargetKeepOriginal RetargetPolicy = iota
+
+	// RetargetToCurrent delivers queued events to whichever stream is active when their batch is sent.
+	RetargetToCurrent
+)
+
+// String returns the name of the policy.
+func (p RetargetPolicy) String() string {
+	switch p {
+	case RetargetKeepOriginal:
+		return "keep-original"
+	case RetargetToCurrent:
+		return "to-current"
+	default:
+		return "unknown"
+	}
+}
+
+// streamTarget is the log group and stream to which a batch of events is delivered.
+type streamTarget struct {
+	group  string
+	stream string
+}
+
+// currentTarget returns the stream to which events are currently written. The caller must hold the intake mutex.
+func (h *CloudWatchLogsHook) currentTarget() streamTarget {
+	if h.target == (streamTarget{}) {
+		return streamTarget{group: h.group, stream: h.stream}
+	}
+	return h.target
+}
+
+// retarget points the hook at a different log group and stream, which must already exist. Events which are still
+// queued are delivered according to the retarget policy.
+func (h *CloudWatchLogsHook) retarget(group, stream string) {
+	h.intakeMutex.Lock()
+	defer h.intakeMutex.Unlock()
+	h.target = streamTarget{group: group, stream: stream}
+}
+
+// batchTarget returns the stream the event is batched for, which is empty if the event follows the hook to whichever
+// stream is current when it is sent.
+func (h *CloudWatchLogsHook) batchTarget(queued queuedEvent) streamTarget {
+	if h.retargetPolicy == RetargetToCurrent && !queued.pinned {
+		return streamTarget{}
+	}
+	return queued.target
+}
+
+// bind returns the stream to which a batch of events batched for the given stream is delivered.
+func (h *CloudWatchLogsHook) bind(target streamTarget) streamTarget {
+	if target == (streamTarget{}) {
+		h.intakeMutex.Lock()
+		defer h.intakeMutex.Unlock()
+		return h.currentTarget()
+	}
+	return target
+}
+
+// boundTarget returns the stream to which the batch being sent is delivered. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) boundTarget() streamTarget {
+	if h.sending == (streamTarget{}) {
+		return streamTarget{group: h.group, stream: h.stream}
+	}
+	return h.sending
+}
+
+// bindBatch binds the batch about to be sent to the given stream. The upload sequence token belongs to the previous
+// stream, so it is discarded when the stream changes and picked up again from the service if it is still required.
+// The caller must hold the mutex.
+func (h *CloudWatchLogsHook) bindBatch(target streamTarget) {
+	if target != h.boundTarget() {
+		h.nextSequenceToken = nil
+	}
+	h.sending = target
+}
@@ -0,0 +1,51 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// recordingQueue is an SQSClient which records the streams of the messages sent to it.
+type recordingQueue struct {
+	testQueue
+	streams []string
+}
+
+func (q *recordingQueue) SendMessage(ctx context.Context, queueURL, body string) error {
+	var msg relayMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		return err
+	}
+	q.streams = append(q.streams, msg.Stream)
+	return nil
+}
+
+func TestRetargetPolicy(t *testing.T) {
+	tests := []struct {
+		policy RetargetPolicy
+		want   []string
+	}{
+		{RetargetKeepOriginal, []string{"old", "new"}},
+		{RetargetToCurrent, []string{"new", "new"}},
+	}
+	for _, test := range tests {
+		queue := &recordingQueue{}
+		h := newTestHook(t, "group", "old", WithSQSRelay("queue"), WithSQSClient(queue),
+			WithRetargetPolicy(test.policy))
+		before := h.intake("before")
+		h.retarget("group", "new")
+		after := h.intake("after")
+		for _, queued := range []queuedEvent{before, after} {
+			h.sendBatch([]types.InputLogEvent{queued.event}, []uint64{queued.seq}, h.batchTarget(queued))
+		}
+		if err := h.takeErr(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.policy, err)
+		}
+		if len(queue.streams) != 2 || queue.streams[0] != test.want[0] || queue.streams[1] != test.want[1] {
+			t.Errorf("%s: expected batches sent to %v, got %v", test.policy, test.want, queue.streams)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cloudwatchhook
+
+import (
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RoutingTagField is the name of the field holding the routing tag added by the WithRoutingTag and
+// WithRoutingTagFunc options, following the conventions of Fluentd and Fluent Bit.
+const RoutingTagField = "tag"
+
+// routingTagRegex matches a Fluentd tag, which is made of parts separated by dots, such as app.payments.web.
+var routingTagRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// RoutingTagEnricher returns an enricher which adds the routing tag returned by the function to the fields. An empty
+// tag means the entry has no tag, in which case the field is not added.
+func RoutingTagEnricher(tag func(*logrus.Entry) string) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		if t := tag(entry); t != "" {
+			fields[RoutingTagField] = t
+		}
+	})
+}
+
+// routingTagFunc returns the function giving the routing tag of each entry, or nil if no routing tag is added. The
+// options setting the tag are mutually exclusive.
+func (h *CloudWatchLogsHook) routingTagFunc() func(*logrus.Entry) string {
+	if h.routingTagFn != nil {
+		return h.routingTagFn
+	}
+	if h.routingTag != "" {
+		tag := h.routingTag
+		return func(*logrus.Entry) string {
+			return tag
+		}
+	}
+	return nil
+}
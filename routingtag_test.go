@@ -0,0 +1,38 @@
+package cloudwatchhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRoutingTag(t *testing.T) {
+	h := newTestHook(t, "group", "stream", WithRoutingTag("app.payments.web"))
+	fields := logrus.Fields{}
+	RoutingTagEnricher(h.routingTagFunc()).Enrich(&logrus.Entry{}, fields)
+	if fields[RoutingTagField] != "app.payments.web" {
+		t.Errorf("expected the static tag, got %v", fields)
+	}
+
+	h = newTestHook(t, "group", "stream", WithRoutingTagFunc(func(entry *logrus.Entry) string {
+		if tenant, ok := entry.Data["tenant"].(string); ok {
+			return "tenant." + tenant
+		}
+		return ""
+	}))
+	enricher := RoutingTagEnricher(h.routingTagFunc())
+	fields = logrus.Fields{}
+	enricher.Enrich(&logrus.Entry{Data: logrus.Fields{"tenant": "acme"}}, fields)
+	if fields[RoutingTagField] != "tenant.acme" {
+		t.Errorf("expected the tag of the entry, got %v", fields)
+	}
+	fields = logrus.Fields{}
+	enricher.Enrich(&logrus.Entry{Data: logrus.Fields{}}, fields)
+	if _, ok := fields[RoutingTagField]; ok {
+		t.Errorf("expected no tag for an empty result, got %v", fields)
+	}
+
+	if f := newTestHook(t, "group", "stream").routingTagFunc(); f != nil {
+		t.Errorf("expected no routing tag by default")
+	}
+}
@@ -0,0 +1,106 @@
+package cloudwatchhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// samplingWindow is the period over which the ingestion budget of adaptive sampling applies.
+const samplingWindow = time.Minute
+
+// sampledLevels holds the levels subject to adaptive sampling, most severe first, which is the order in which they
+// are given a share of the budget. Errors, fatal errors and panics are never sampled.
+var sampledLevels = []logrus.Level{logrus.WarnLevel, logrus.InfoLevel, logrus.DebugLevel, logrus.TraceLevel}
+
+// adaptiveSampler is used to keep the volume of events sent to Amazon CloudWatch under a budget by sampling the less
+// severe levels. The sampling rates for each window are computed from the volume offered during the previous one,
+// giving the more severe levels the first share of the budget left over after errors.
+type adaptiveSampler struct {
+	target int64
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	offered     map[logrus.Level]int64
+	kept        int64
+	rates       map[logrus.Level]float64
+}
+
+// newAdaptiveSampler creates a sampler which keeps every event until the volume of the first window is known.
+func newAdaptiveSampler(targetBytesPerMinute int64) *adaptiveSampler {
+	return &adaptiveSampler{
+		target:  targetBytesPerMinute,
+		offered: map[logrus.Level]int64{},
+		rates:   map[logrus.Level]float64{},
+	}
+}
+
+// sample determines whether or not an event of the given level and size should be kept.
+func (s *adaptiveSampler) sample(level logrus.Level, size int, now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if now.Sub(s.windowStart) >= samplingWindow {
+		s.adjust()
+		s.windowStart = now
+	}
+	s.offered[level] += int64(size)
+	if level <= logrus.ErrorLevel {
+		s.kept += int64(size)
+		return true
+	}
+
+	// never exceed the budget within a window, whatever the rates
+	if s.kept+int64(size) > s.target {
+		return false
+	}
+	rate, ok := s.rates[level]
+	if ok && rate < 1 && randFloat() >= rate {
+		return false
+	}
+	s.kept += int64(size)
+	return true
+}
+
+// adjust computes the sampling rates for the next window from the volume offered during the one just finished. The
+// caller must hold the mutex.
+func (s *adaptiveSampler) adjust() {
+	remaining := s.target
+	for level, offered := range s.offered {
+		if level <= logrus.ErrorLevel {
+			remaining -= offered
+		}
+	}
+	for _, level := range sampledLevels {
+		offered := s.offered[level]
+		switch {
+		case offered == 0:
+			delete(s.rates, level)
+		case remaining <= 0:
+			s.rates[level] = 0
+		case offered <= remaining:
+			s.rates[level] = 1
+			remaining -= offered
+		default:
+			s.rates[level] = float64(remaining) / float64(offered)
+			remaining = 0
+		}
+	}
+	s.offered = map[logrus.Level]int64{}
+	s.kept = 0
+}
+
+// effectiveRates returns the current sampling rate of each sampled level by name. Levels without a rate are kept.
+func (s *adaptiveSampler) effectiveRates() map[string]float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rates := make(map[string]float64, len(sampledLevels))
+	for _, level := range sampledLevels {
+		rate, ok := s.rates[level]
+		if !ok {
+			rate = 1
+		}
+		rates[level.String()] = rate
+	}
+	return rates
+}
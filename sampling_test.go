@@ -0,0 +1,36 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAdaptiveSampler(t *testing.T) {
+	s := newAdaptiveSampler(1000)
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// the first window keeps everything up to the budget
+	for i := 0; i < 10; i++ {
+		s.sample(logrus.ErrorLevel, 40, now)
+		s.sample(logrus.WarnLevel, 20, now)
+		s.sample(logrus.InfoLevel, 100, now)
+	}
+	if s.sample(logrus.InfoLevel, 100, now) {
+		t.Errorf("expected the budget to be enforced within a window")
+	}
+	if !s.sample(logrus.ErrorLevel, 500, now) {
+		t.Errorf("expected errors never to be sampled")
+	}
+
+	// errors and warnings leave 400 of the 1000 bytes for the 1100 bytes of info entries offered in the last window
+	s.offered[logrus.ErrorLevel] = 400
+	s.offered[logrus.WarnLevel] = 200
+	s.offered[logrus.InfoLevel] = 1100
+	s.sample(logrus.DebugLevel, 1, now.Add(time.Minute))
+	rates := s.effectiveRates()
+	if rates["warning"] != 1 || rates["info"] != 4.0/11 || rates["debug"] != 1 {
+		t.Errorf("unexpected rates: %v", rates)
+	}
+}
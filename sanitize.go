@@ -0,0 +1,127 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sanitize replaces invalid UTF-8 sequences in the message, which Amazon CloudWatch would reject along with the rest
+// of the batch, and compacts multi-line JSON and strips ANSI escape sequences and control characters if the hook is
+// configured to do so.
+func (h *CloudWatchLogsHook) sanitize(msg string) string {
+	if !utf8.ValidString(msg) {
+		msg = strings.ToValidUTF8(msg, string(utf8.RuneError))
+	}
+	if h.compactJSON {
+		msg = compactJSON(msg)
+	}
+	if h.stripANSI {
+		msg = stripANSI(msg)
+	}
+	if h.stripControlChars {
+		msg = stripControlChars(msg, h.allowedControlChars)
+	}
+	return msg
+}
+
+// compactJSON rewrites a JSON message spanning several lines, such as the output of an indenting encoder, as a single
+// line. Messages which are not JSON, or which are already on a single line, are returned unchanged.
+func compactJSON(msg string) string {
+	trimmed := strings.TrimSpace(msg)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') || !strings.Contains(trimmed, "\n") {
+		return msg
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(trimmed)); err != nil {
+		return msg
+	}
+	return buf.String()
+}
+
+// stripControlChars removes control characters, other than those in allowed, from the message.
+func stripControlChars(msg, allowed string) string {
+	strip := func(r rune) bool {
+		return unicode.IsControl(r) && !strings.ContainsRune(allowed, r)
+	}
+	if strings.IndexFunc(msg, strip) < 0 {
+		return msg
+	}
+	return strings.Map(func(r rune) rune {
+		if strip(r) {
+			return -1
+		}
+		return r
+	}, msg)
+}
+
+// stripANSI removes ANSI escape sequences from the message: control sequences, such as the "\x1b[31m" used to color
+// text, operating system commands, which end with a BEL or string terminator, and two character escapes.
+func stripANSI(msg string) string {
+	i := strings.IndexByte(msg, '\x1b')
+	if i < 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.Grow(len(msg))
+	for i >= 0 {
+		b.WriteString(msg[:i])
+		msg = msg[i+1:]
+		switch {
+		case strings.HasPrefix(msg, "["):
+			// parameter and intermediate bytes followed by a single final byte
+			end := strings.IndexFunc(msg[1:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+			if end < 0 {
+				msg = ""
+			} else {
+				msg = msg[end+2:]
+			}
+		case strings.HasPrefix(msg, "]"):
+			if end := strings.IndexAny(msg, "\a\x1b"); end < 0 {
+				msg = ""
+			} else if msg[end] == '\a' {
+				msg = msg[end+1:]
+			} else {
+				msg = strings.TrimPrefix(msg[end+1:], "\\")
+			}
+		case msg != "":
+			msg = msg[1:]
+		}
+		i = strings.IndexByte(msg, '\x1b')
+	}
+	b.WriteString(msg)
+	return b.String()
+}
+
+// prefixLevel prefixes the message with the level token of the entry when the WithLevelPrefix option is given. It is
+// applied once the empty message policy and the client-side filter pattern have seen the message as formatted.
+func (h *CloudWatchLogsHook) prefixLevel(msg string, entry *logrus.Entry) string {
+	if !h.levelPrefix || entry == nil {
+		return msg
+	}
+	return levelToken(entry.Level) + " " + msg
+}
+
+// levelToken returns the normalized token prefixed to messages for the level.
+func levelToken(level logrus.Level) string {
+	switch level {
+	case logrus.PanicLevel:
+		return "[PANIC]"
+	case logrus.FatalLevel:
+		return "[FATAL]"
+	case logrus.ErrorLevel:
+		return "[ERROR]"
+	case logrus.WarnLevel:
+		return "[WARN]"
+	case logrus.InfoLevel:
+		return "[INFO]"
+	case logrus.DebugLevel:
+		return "[DEBUG]"
+	default:
+		return "[TRACE]"
+	}
+}
@@ -0,0 +1,129 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		msg      string
+		strip    bool
+		allowed  string
+		expected string
+	}{
+		{"valid message\n", false, "", "valid message\n"},
+		{"bad \xff\xfe bytes", false, "", "bad � bytes"},
+		{"bell\a and\x00 nul\n", false, "", "bell\a and\x00 nul\n"},
+		{"bell\a and\x00 nul\n", true, "", "bell and nul"},
+		{"tab\tbell\a\n", true, "\t\n", "tab\tbell\n"},
+		{"bad \xff and\x1b[0m escape", true, "", "bad � and[0m escape"},
+	}
+	for _, test := range tests {
+		var options []CloudWatchLogsHookOption
+		if test.strip {
+			options = append(options, WithControlCharStripping(test.allowed))
+		}
+		h := newTestHook(t, "group", "stream", options...)
+		if actual := h.sanitize(test.msg); actual != test.expected {
+			t.Errorf("sanitize(%q) = %q, want %q", test.msg, actual, test.expected)
+		}
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{"{\n  \"level\": \"info\",\n  \"msg\": \"a b\"\n}\n", `{"level":"info","msg":"a b"}`},
+		{"[\n  1,\n  2\n]", "[1,2]"},
+		{"{\"msg\":\"single line\"}\n", "{\"msg\":\"single line\"}\n"},
+		{"{\n  not json\n}", "{\n  not json\n}"},
+		{"plain\ntext", "plain\ntext"},
+	}
+	for _, test := range tests {
+		if actual := compactJSON(test.msg); actual != test.expected {
+			t.Errorf("compactJSON(%q) = %q, want %q", test.msg, actual, test.expected)
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"\x1b[31mERRO\x1b[0m[0000] failed \x1b[31merror\x1b[0m=boom", "ERRO[0000] failed error=boom"},
+		{"\x1b[1;38;5;208mbold\x1b[m", "bold"},
+		{"\x1b]0;title\alink \x1b]8;;http://x\x1b\\text", "link text"},
+		{"reset\x1bc done", "reset done"},
+		{"truncated \x1b[31", "truncated "},
+	}
+	for _, test := range tests {
+		if actual := stripANSI(test.msg); actual != test.expected {
+			t.Errorf("stripANSI(%q) = %q, want %q", test.msg, actual, test.expected)
+		}
+	}
+}
+
+// colorFormatter formats entries the way a text formatter writing to a terminal does.
+type colorFormatter struct{}
+
+func (colorFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return []byte(fmt.Sprintf("\x1b[33m%.4s\x1b[0m %s\n", strings.ToUpper(entry.Level.String()), entry.Message)), nil
+}
+
+func TestLevelPrefix(t *testing.T) {
+	transport := &testTransport{}
+	h := newTestHook(t, "group", "stream", WithTransport(transport),
+		WithCodec(FormatterCodec{Formatter: colorFormatter{}}), WithANSIStripping(), WithLevelPrefix())
+	if err := h.Fire(&logrus.Entry{Level: logrus.WarnLevel, Message: "disk low"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transport.batches) != 1 || transport.batches[0][0].Message != "[WARN] WARN disk low\n" {
+		t.Errorf("expected a prefixed message without colors, got %v", transport.batches)
+	}
+}
+
+func TestLevelPrefixAfterPolicies(t *testing.T) {
+	transport := &testTransport{}
+	h := newTestHook(t, "group", "stream", WithTransport(transport),
+		WithCodec(FormatterCodec{Formatter: &logrus.JSONFormatter{}}), WithLevelPrefix())
+	pattern, err := parseFilterPattern(`{ $.msg = "disk*" }`)
+	if err != nil {
+		t.Fatalf("unable to parse pattern: %v", err)
+	}
+	h.patternFilter = pattern
+
+	// the filter pattern sees the JSON message before it is prefixed
+	for _, msg := range []string{"disk low", "cache cold"} {
+		if err := h.Fire(&logrus.Entry{Level: logrus.WarnLevel, Message: msg, Data: logrus.Fields{}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(transport.batches) != 1 || !strings.HasPrefix(transport.batches[0][0].Message, `[WARN] {"level":"warning"`) {
+		t.Errorf("expected only the matching message to be prefixed and sent, got %v", transport.batches)
+	}
+
+	// an empty message is dropped by the empty message policy rather than sent as a bare prefix
+	h.patternFilter = nil
+	h.codec = FormatterCodec{Formatter: emptyFormatter{}}
+	if err := h.Fire(&logrus.Entry{Level: logrus.InfoLevel}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(transport.batches) != 1 || h.Stats().EmptyDropped != 1 {
+		t.Errorf("expected the empty message to be dropped, got %v", transport.batches)
+	}
+}
+
+// emptyFormatter formats every entry as an empty message.
+type emptyFormatter struct{}
+
+func (emptyFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,109 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SchemaVersionField is the name of the field holding the schema version added by the WithSchemaVersion option.
+const SchemaVersionField = "schema_version"
+
+// SchemaField describes a single field of a payload schema.
+type SchemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema describes the layout of the fields in the payloads written under a given schema version. Schemas are
+// registered so that downstream consumers, such as CloudWatch Logs Insights queries and ETL jobs, can look up the
+// layout of the payloads they read and evolve safely as it changes.
+type Schema struct {
+	Version     string        `json:"version"`
+	Description string        `json:"description,omitempty"`
+	Fields      []SchemaField `json:"fields"`
+}
+
+// Check is used to verify that a decoded payload contains every required field of the schema.
+func (s Schema) Check(payload map[string]interface{}) error {
+	var missing []string
+	for _, f := range s.Fields {
+		if _, ok := payload[f.Name]; f.Required && !ok {
+			missing = append(missing, f.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Payload is missing required fields for schema version %s: %v", s.Version, missing)
+	}
+	return nil
+}
+
+// schemas holds the registered schemas by version.
+var schemas = struct {
+	sync.RWMutex
+	versions map[string]Schema
+}{versions: map[string]Schema{}}
+
+// RegisterSchema is used to add a schema to the registry. A version can only be registered once, so that the layout
+// of a published version never changes underneath its consumers.
+func RegisterSchema(schema Schema) error {
+	if schema.Version == "" {
+		return fmt.Errorf("Schema version must not be empty")
+	}
+	names := map[string]bool{}
+	for _, f := range schema.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("Schema version %s has a field without a name", schema.Version)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("Schema version %s has more than one field named %s", schema.Version, f.Name)
+		}
+		names[f.Name] = true
+	}
+
+	schemas.Lock()
+	defer schemas.Unlock()
+	if _, ok := schemas.versions[schema.Version]; ok {
+		return fmt.Errorf("Schema version %s is already registered", schema.Version)
+	}
+	schema.Fields = append([]SchemaField{}, schema.Fields...)
+	schemas.versions[schema.Version] = schema
+	return nil
+}
+
+// LookupSchema is used to find the registered schema with the given version.
+func LookupSchema(version string) (Schema, bool) {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	schema, ok := schemas.versions[version]
+	if ok {
+		schema.Fields = append([]SchemaField{}, schema.Fields...)
+	}
+	return schema, ok
+}
+
+// Schemas returns all registered schemas sorted by version.
+func Schemas() []Schema {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	list := make([]Schema, 0, len(schemas.versions))
+	for _, schema := range schemas.versions {
+		schema.Fields = append([]SchemaField{}, schema.Fields...)
+		list = append(list, schema)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
+
+// SchemaVersionEnricher returns an enricher which stamps the schema version on every entry.
+func SchemaVersionEnricher(version string) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		fields[SchemaVersionField] = version
+	})
+}
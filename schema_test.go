@@ -0,0 +1,34 @@
+package cloudwatchhook
+
+import "testing"
+
+func TestSchemaRegistry(t *testing.T) {
+	schema := Schema{
+		Version: "test-1",
+		Fields: []SchemaField{
+			{Name: "msg", Type: "string", Required: true},
+			{Name: "order_id", Type: "string", Required: true},
+			{Name: "duration_ms", Type: "number"},
+		},
+	}
+	if err := RegisterSchema(schema); err != nil {
+		t.Fatalf("unexpected error registering schema: %v", err)
+	}
+	if err := RegisterSchema(schema); err == nil {
+		t.Errorf("expected an error registering the same version twice")
+	}
+	if err := RegisterSchema(Schema{Version: "test-2", Fields: []SchemaField{{Name: "a"}, {Name: "a"}}}); err == nil {
+		t.Errorf("expected an error registering a schema with duplicate fields")
+	}
+
+	found, ok := LookupSchema("test-1")
+	if !ok {
+		t.Fatalf("expected schema test-1 to be registered")
+	}
+	if err := found.Check(map[string]interface{}{"msg": "paid", "order_id": "42"}); err != nil {
+		t.Errorf("unexpected error checking a complete payload: %v", err)
+	}
+	if err := found.Check(map[string]interface{}{"msg": "paid"}); err == nil {
+		t.Errorf("expected an error checking a payload without a required field")
+	}
+}
@@ -0,0 +1,204 @@
+package cloudwatchhook_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// fakeLogsService is an HTTP client which answers the Amazon CloudWatch Logs calls of the SDK client created by the
+// hook as if the group and stream already existed, recording the requests it is sent.
+type fakeLogsService struct {
+	mutex    sync.Mutex
+	requests []*http.Request
+}
+
+func (s *fakeLogsService) Do(request *http.Request) (*http.Response, error) {
+	s.mutex.Lock()
+	s.requests = append(s.requests, request)
+	s.mutex.Unlock()
+
+	body := `{}`
+	switch strings.TrimPrefix(request.Header.Get("X-Amz-Target"), "Logs_20140328.") {
+	case "DescribeLogGroups":
+		body = `{"logGroups":[{"logGroupName":"group",` +
+			`"arn":"arn:aws:logs:us-east-1:123456789012:log-group:group:*"}]}`
+	case "DescribeLogStreams":
+		body = `{"logStreams":[{"logStreamName":"stream"}]}`
+	case "PutLogEvents":
+		body = `{"nextSequenceToken":"1"}`
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-amz-json-1.1")
+	header.Set("X-Amzn-Requestid", "fake")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		Request:    request,
+	}, nil
+}
+
+// Requests returns the requests sent so far.
+func (s *fakeLogsService) Requests() []*http.Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*http.Request{}, s.requests...)
+}
+
+// fakeConfig returns an AWS configuration whose clients send their requests to the service.
+func fakeConfig(service *fakeLogsService) aws.Config {
+	return aws.Config{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+		HTTPClient: service,
+	}
+}
+
+func TestAPIOptions(t *testing.T) {
+	service := &fakeLogsService{}
+	addHeader := func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("AuditHeader", func(ctx context.Context,
+			in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata,
+			error) {
+
+			if request, ok := in.Request.(*smithyhttp.Request); ok {
+				request.Header.Set("X-Audit", "billing")
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+	}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(fakeConfig(service), "group", "stream",
+		cloudwatchhook.WithAPIOptions(addHeader))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("audited")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+
+	// the middleware applies to every call, from finding the group to uploading the events
+	requests := service.Requests()
+	put := false
+	for _, request := range requests {
+		target := request.Header.Get("X-Amz-Target")
+		put = put || strings.HasSuffix(target, ".PutLogEvents")
+		if request.Header.Get("X-Audit") != "billing" {
+			t.Errorf("expected the header added by the middleware in the %s call", target)
+		}
+	}
+	if !put {
+		t.Errorf("expected the events to be uploaded, got %d requests", len(requests))
+	}
+
+	// a client given to the hook is not created by it, so the middleware could never be added
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})), cloudwatchhook.WithAPIOptions(addHeader))
+	if err == nil || !strings.Contains(err.Error(), "WithAPIOptions cannot be used with WithClient") {
+		t.Errorf("expected WithAPIOptions to be rejected with WithClient, got %v", err)
+	}
+}
+
+func TestAppID(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{"billing-api", "1.4.2", "billing-api/1.4.2"},
+		{"billing-api", "", "billing-api"},
+	}
+	for _, test := range tests {
+		service := &fakeLogsService{}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(fakeConfig(service), "group", "stream",
+			cloudwatchhook.WithAppID(test.name, test.version))
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		if _, err := hook.Write([]byte("attributed")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := hook.Close(); err != nil {
+			t.Fatalf("unable to close hook: %v", err)
+		}
+
+		requests := service.Requests()
+		if len(requests) == 0 {
+			t.Fatalf("expected the hook to call the service")
+		}
+		for _, request := range requests {
+			agent := request.Header.Get("User-Agent")
+			if !strings.HasSuffix(agent, " "+test.expected) {
+				t.Errorf("expected the user agent of the %s call to end with %s, got %q",
+					request.Header.Get("X-Amz-Target"), test.expected, agent)
+			}
+		}
+	}
+
+	// the user agent of a client given to the hook cannot be changed, and names must be a single token
+	_, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})), cloudwatchhook.WithAppID("billing-api", "1.4.2"))
+	if err == nil || !strings.Contains(err.Error(), "WithAppID cannot be used with WithClient") {
+		t.Errorf("expected WithAppID to be rejected with WithClient, got %v", err)
+	}
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithAppID("billing api", "1.4.2"))
+	if err == nil || !strings.Contains(err.Error(), "Invalid app ID") {
+		t.Errorf("expected a name with a space to be rejected, got %v", err)
+	}
+}
+
+func TestFIPSRegionEndpoints(t *testing.T) {
+	tests := map[string]struct {
+		host   string
+		region string
+	}{
+		"fips-us-east-1":     {"logs-fips.us-east-1.amazonaws.com", "us-east-1"},
+		"us-gov-west-1-fips": {"logs.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+		"eu-west-1":          {"logs.eu-west-1.amazonaws.com", "eu-west-1"},
+	}
+	for region, expected := range tests {
+		service := &fakeLogsService{}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(fakeConfig(service), "group", "stream",
+			cloudwatchhook.WithRegion(region))
+		if err != nil {
+			t.Fatalf("%s: unable to create hook: %v", region, err)
+		}
+		if _, err := hook.Write([]byte("compliant")); err != nil {
+			t.Fatalf("%s: unexpected error: %v", region, err)
+		}
+		if err := hook.Close(); err != nil {
+			t.Fatalf("%s: unable to close hook: %v", region, err)
+		}
+
+		requests := service.Requests()
+		if len(requests) == 0 {
+			t.Fatalf("%s: expected the hook to call the service", region)
+		}
+		for _, request := range requests {
+			if request.URL.Host != expected.host {
+				t.Errorf("%s: expected the %s call to be sent to %s, got %s", region,
+					request.Header.Get("X-Amz-Target"), expected.host, request.URL.Host)
+			}
+			if scope := "/" + expected.region + "/logs/"; !strings.Contains(request.Header.Get("Authorization"), scope) {
+				t.Errorf("%s: expected the %s call to be signed for %s", region, request.Header.Get("X-Amz-Target"),
+					expected.region)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// GroupTagsAPI is the part of the Amazon CloudWatch Logs API used to read the tags of a log group. It is only required
+// of the client when the WithGroupSelector option is used.
+type GroupTagsAPI interface {
+	ListTagsLogGroup(ctx context.Context, params *cloudwatchlogs.ListTagsLogGroupInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsLogGroupOutput, error)
+}
+
+// groupSelector identifies an existing log group by one of its tags.
+type groupSelector struct {
+	key   string
+	value string
+}
+
+// String returns the tag in the form key=value.
+func (s *groupSelector) String() string {
+	return s.key + "=" + s.value
+}
+
+// selectGroup finds the single existing log group whose name starts with the group name given to the hook and which
+// has the tag of the group selector, and uses it as the log group of the hook.
+func (h *CloudWatchLogsHook) selectGroup() error {
+	client, ok := h.client.(GroupTagsAPI)
+	if !ok {
+		return fmt.Errorf("Unable to select log group tagged %s: client does not support listing tags", h.groupSelector)
+	}
+
+	var matches []types.LogGroup
+	var nextToken *string = nil
+	for {
+		input := &cloudwatchlogs.DescribeLogGroupsInput{
+			NextToken: nextToken,
+		}
+		if h.group != "" {
+			input.LogGroupNamePrefix = aws.String(h.group)
+		}
+		result, err := h.client.DescribeLogGroups(context.TODO(), input)
+		if err != nil {
+			return fmt.Errorf("Unable to select log group tagged %s: %v", h.groupSelector, err)
+		}
+
+		for _, group := range result.LogGroups {
+			tags, err := client.ListTagsLogGroup(context.TODO(), &cloudwatchlogs.ListTagsLogGroupInput{
+				LogGroupName: group.LogGroupName,
+			})
+			if err != nil {
+				return fmt.Errorf("Unable to list tags of log group %s: %v", aws.ToString(group.LogGroupName), err)
+			}
+			if value, ok := tags.Tags[h.groupSelector.key]; ok && value == h.groupSelector.value {
+				matches = append(matches, group)
+			}
+		}
+
+		nextToken = result.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("No log group starting with %q is tagged %s", h.group, h.groupSelector)
+	case 1:
+		h.group = aws.ToString(matches[0].LogGroupName)
+		h.setGroupInfo(&matches[0])
+		return nil
+	default:
+		names := make([]string, len(matches))
+		for i, group := range matches {
+			names[i] = aws.ToString(group.LogGroupName)
+		}
+		return fmt.Errorf("Log group selector %s is ambiguous: it matches %s", h.groupSelector,
+			strings.Join(names, ", "))
+	}
+}
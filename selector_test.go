@@ -0,0 +1,58 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestWithGroupSelector(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	for name, service := range map[string]string{"/app/billing-3f9a": "billing", "/app/orders-77c1": "orders",
+		"/other/billing-0b2e": "billing"} {
+
+		_, err := client.CreateLogGroup(context.Background(), &cloudwatchlogs.CreateLogGroupInput{
+			LogGroupName: aws.String(name),
+			Tags:         map[string]string{"service": service},
+		})
+		if err != nil {
+			t.Fatalf("unable to create log group: %v", err)
+		}
+	}
+
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithGroupSelector("service", "billing"))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("selected")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hook.Close()
+	if group := hook.Config().Group; group != "/app/billing-3f9a" {
+		t.Errorf("expected the tagged group to be selected, got %s", group)
+	}
+	if n := len(client.Events("/app/billing-3f9a", "stream")); n != 1 {
+		t.Errorf("expected 1 event in the selected group, got %d", n)
+	}
+	if n := client.Calls("CreateLogGroup"); n != 3 {
+		t.Errorf("expected the hook not to create a log group, got %d calls", n)
+	}
+
+	// without a prefix, two groups match
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithGroupSelector("service", "billing"))
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguous selector error, got %v", err)
+	}
+	_, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithGroupSelector("service", "payments"))
+	if err == nil {
+		t.Errorf("expected an error when no group is tagged")
+	}
+}
@@ -0,0 +1,56 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestSendSplitsAtLimits(t *testing.T) {
+	now := time.Now()
+	events := func(n, size int) []cloudwatchhook.Event {
+		events := make([]cloudwatchhook.Event, n)
+		for i := range events {
+			events[i] = cloudwatchhook.Event{Message: strings.Repeat("x", size), Timestamp: now}
+		}
+		return events
+	}
+	perMiB := cloudwatchhook.MaxBatchBytes/4 - cloudwatchhook.EventOverhead
+	tests := []struct {
+		name   string
+		events []cloudwatchhook.Event
+		calls  int
+	}{
+		{"at the event limit", events(cloudwatchhook.MaxBatchEvents, 1), 1},
+		{"over the event limit", events(cloudwatchhook.MaxBatchEvents+1, 1), 2},
+		{"at the size limit", events(4, perMiB), 1},
+		{"over the size limit", append(events(4, perMiB), events(1, 1)...), 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := chaos.NewClient(chaos.Faults{})
+			hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+				cloudwatchhook.WithClient(client),
+			)
+			if err != nil {
+				t.Fatalf("unable to create hook: %v", err)
+			}
+			defer hook.Close()
+
+			if err := hook.Send(context.Background(), test.events); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := client.Calls("PutLogEvents"); n != test.calls {
+				t.Errorf("expected %d PutLogEvents calls, got %d", test.calls, n)
+			}
+			if n := len(client.Events("group", "stream")); n != len(test.events) {
+				t.Errorf("expected %d events to be delivered, got %d", len(test.events), n)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cloudwatchhook
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EventIDField is the name of the field holding the event ID added by the WithEventIDExtractor option.
+	EventIDField = "event_id"
+
+	// SeverityField is the name of the field holding the severity name added by the WithSeverityMapping option.
+	SeverityField = "severity"
+
+	// SeverityCodeField is the name of the field holding the severity code added by the WithSeverityMapping option.
+	SeverityCodeField = "severity_code"
+)
+
+// Severity is the name and numeric code of a severity level in another logging system.
+type Severity struct {
+	Name string
+	Code int
+}
+
+// WindowsEventSeverity maps logrus levels to the levels of the Windows Event Log, so that SIEM rules written for
+// Windows logs keep working.
+var WindowsEventSeverity = map[logrus.Level]Severity{
+	logrus.PanicLevel: {Name: "Critical", Code: 1},
+	logrus.FatalLevel: {Name: "Critical", Code: 1},
+	logrus.ErrorLevel: {Name: "Error", Code: 2},
+	logrus.WarnLevel:  {Name: "Warning", Code: 3},
+	logrus.InfoLevel:  {Name: "Information", Code: 4},
+	logrus.DebugLevel: {Name: "Verbose", Code: 5},
+	logrus.TraceLevel: {Name: "Verbose", Code: 5},
+}
+
+// EventIDEnricher returns an enricher which adds the event ID returned by the extractor to the fields. An ID of 0 means
+// the entry has no event ID, in which case the field is not added.
+func EventIDEnricher(extractor func(*logrus.Entry) int) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		if id := extractor(entry); id != 0 {
+			fields[EventIDField] = id
+		}
+	})
+}
+
+// SeverityEnricher returns an enricher which adds the name and code of the severity the level of the entry maps to.
+// Levels missing from the mapping are left without a severity.
+func SeverityEnricher(mapping map[logrus.Level]Severity) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		if severity, ok := mapping[entry.Level]; ok {
+			fields[SeverityField] = severity.Name
+			fields[SeverityCodeField] = severity.Code
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package cloudwatchhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSeverityEnrichers(t *testing.T) {
+	extractor := func(entry *logrus.Entry) int {
+		id, _ := entry.Data["id"].(int)
+		return id
+	}
+	enrichers := []Enricher{EventIDEnricher(extractor), SeverityEnricher(WindowsEventSeverity)}
+
+	fields := logrus.Fields{}
+	entry := &logrus.Entry{Level: logrus.WarnLevel, Data: logrus.Fields{"id": 4625}}
+	for _, enricher := range enrichers {
+		enricher.Enrich(entry, fields)
+	}
+	if fields[EventIDField] != 4625 || fields[SeverityField] != "Warning" || fields[SeverityCodeField] != 3 {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+
+	fields = logrus.Fields{}
+	entry = &logrus.Entry{Level: logrus.Level(42), Data: logrus.Fields{}}
+	for _, enricher := range enrichers {
+		enricher.Enrich(entry, fields)
+	}
+	if len(fields) != 0 {
+		t.Errorf("expected no fields for an entry without an event ID or severity, got %v", fields)
+	}
+}
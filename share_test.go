@@ -0,0 +1,92 @@
+package cloudwatchhook_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSharedHookConcurrentFire(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+
+	formatters := []logrus.Formatter{&logrus.JSONFormatter{}, &logrus.TextFormatter{DisableColors: true}}
+	const perLogger = 200
+	var wg sync.WaitGroup
+	for i, formatter := range formatters {
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+		logger.SetFormatter(formatter)
+		logger.AddHook(hook)
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(logger *logrus.Logger, i int) {
+				defer wg.Done()
+				for n := 0; n < perLogger/4; n++ {
+					logger.WithField("logger", i).Info("shared")
+				}
+			}(logger, i)
+		}
+	}
+	wg.Wait()
+	hook.Close()
+
+	var json, text int
+	for _, event := range client.Events("group", "stream") {
+		switch msg := aws.ToString(event.Message); {
+		case strings.HasPrefix(msg, "{") && strings.Contains(msg, `"logger":0`):
+			json++
+		case strings.Contains(msg, "logger=1"):
+			text++
+		default:
+			t.Errorf("event encoded with the wrong formatter: %s", msg)
+		}
+	}
+	if json != perLogger || text != perLogger {
+		t.Errorf("expected %d events from each logger, got %d JSON and %d text", perLogger, json, text)
+	}
+}
+
+func TestSharedHookFireAndWriteOrder(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+
+	const count = 300
+	for n := 0; n < count; n++ {
+		if n%2 == 0 {
+			logger.Infof("event-%04d", n)
+		} else {
+			fmt.Fprintf(hook, "event-%04d\n", n)
+		}
+	}
+	hook.Close()
+
+	events := client.Events("group", "stream")
+	if len(events) != count {
+		t.Fatalf("expected %d events, got %d", count, len(events))
+	}
+	for n, event := range events {
+		if want := fmt.Sprintf("event-%04d", n); !strings.Contains(aws.ToString(event.Message), want) {
+			t.Fatalf("expected event %d to contain %s, got %s", n, want, aws.ToString(event.Message))
+		}
+	}
+}
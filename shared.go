@@ -0,0 +1,55 @@
+package cloudwatchhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	// sharedStreamRetries is the number of times an upload rejected because another writer changed the sequence
+	// token is retried with the refreshed token in shared stream mode.
+	sharedStreamRetries = 10
+
+	// sharedStreamBackoff is the base of the advisory jittered delay between retries in shared stream mode.
+	sharedStreamBackoff = 10 * time.Millisecond
+
+	// maxSharedStreamBackoff is the longest advisory delay between retries in shared stream mode.
+	maxSharedStreamBackoff = time.Second
+)
+
+// sharedStreamDelay returns a random delay before the given retry attempt (starting at 1) of an upload which lost the
+// sequence token to another writer. The range grows with each attempt and the delay is random within it, so that
+// writers contending for the stream do not keep retrying in lockstep and invalidating each other's token.
+func sharedStreamDelay(attempt int) time.Duration {
+	ceiling := sharedStreamBackoff
+	for i := 1; i < attempt && ceiling < maxSharedStreamBackoff; i++ {
+		ceiling *= 2
+	}
+	if ceiling > maxSharedStreamBackoff {
+		ceiling = maxSharedStreamBackoff
+	}
+	return randDuration(ceiling)
+}
+
+// putSharedLogEvents sends the events like putLogEvents, but cooperates with other writers of the stream: uploads
+// rejected because another writer changed the sequence token are retried with the refreshed token after an advisory
+// jittered delay. Each conflict is reported like those found by WithTokenRefresh. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) putSharedLogEvents(events []types.InputLogEvent) (*types.RejectedLogEventsInfo, error) {
+	for attempt := 1; ; attempt++ {
+		rejected, err := h.putLogEvents(events)
+		var tokenErr *types.InvalidSequenceTokenException
+		if err == nil || !errors.As(err, &tokenErr) || attempt > sharedStreamRetries {
+			return rejected, err
+		}
+
+		// the service does not always say which token it expected, in which case the stream is described instead
+		if tokenErr.ExpectedSequenceToken == nil {
+			h.findLogStream()
+		}
+		if !h.sleep(sharedStreamDelay(attempt), h.abandon) {
+			return nil, err
+		}
+	}
+}
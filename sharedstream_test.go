@@ -0,0 +1,62 @@
+package cloudwatchhook_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// TestWithSharedStream simulates two replicas writing to the same stream, as described in the Shared Streams section
+// of the README: every event of both replicas is delivered even though each write invalidates the other's token.
+func TestWithSharedStream(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	client.RequireSequenceTokens = true
+	var hooks []*cloudwatchhook.CloudWatchLogsHook
+	for i := 0; i < 2; i++ {
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "shared",
+			cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(5*time.Millisecond),
+			cloudwatchhook.WithSharedStream())
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	const perReplica = 200
+	var wg sync.WaitGroup
+	for i, hook := range hooks {
+		wg.Add(1)
+		go func(i int, hook *cloudwatchhook.CloudWatchLogsHook) {
+			defer wg.Done()
+			for n := 0; n < perReplica; n++ {
+				if _, err := fmt.Fprintf(hook, "replica %d event %d", i, n); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if n%20 == 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(i, hook)
+	}
+	wg.Wait()
+
+	var conflicts int64
+	for _, hook := range hooks {
+		if err := hook.Close(); err != nil {
+			t.Errorf("unexpected error closing hook: %v", err)
+		}
+		conflicts += hook.Stats().TokenConflicts
+	}
+	if n := len(client.Events("group", "shared")); n != 2*perReplica {
+		t.Errorf("expected %d events from both replicas, got %d", 2*perReplica, n)
+	}
+	if conflicts == 0 {
+		t.Errorf("expected the replicas to detect token conflicts")
+	}
+}
@@ -0,0 +1,86 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ErrAbandoned is the error given to the dead letter sink for events abandoned because the deadline for closing the
+// hook passed before they could be sent.
+var ErrAbandoned = errors.New("Event abandoned when closing the hook")
+
+// CloseResult reports what happened to the events still queued or being sent when a hook was closed.
+type CloseResult struct {
+	// Flushed is the number of events delivered to Amazon CloudWatch while closing the hook.
+	Flushed int64 `json:"flushed"`
+
+	// Abandoned is the number of events which were not delivered because the deadline passed. They were handed to the
+	// dead letter sink, if one is configured.
+	Abandoned int64 `json:"abandoned"`
+}
+
+// CloseContext stops any background workers and sends any queued log events to Amazon CloudWatch, like Close, but
+// gives up once the context is done. Events which have not been sent by then, including those waiting to be retried,
+// are abandoned and handed to the dead letter sink, and any call in progress is cancelled. The result reports how
+// many events were flushed and abandoned, including those of any children created with Child, which are closed first.
+// Closing a hook which is already closed returns an empty result.
+func (h *CloudWatchLogsHook) CloseContext(ctx context.Context) (CloseResult, error) {
+	result, childErr := h.closeChildren(ctx)
+	h.closeOnce.Do(func() {
+		// wait for events being queued so that none is sent after the batching worker has stopped
+		h.queueMutex.Lock()
+		delivered := atomic.LoadInt64(&h.stats.delivered)
+		atomic.StoreInt32(&h.closed, 1)
+		close(h.done)
+		h.queueMutex.Unlock()
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			h.workers.Wait()
+			h.inflight.Wait()
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			close(h.abandon)
+			h.cancelSend()
+			<-stopped
+		}
+		result.Flushed += atomic.LoadInt64(&h.stats.delivered) - delivered
+		result.Abandoned += atomic.LoadInt64(&h.stats.abandoned)
+	})
+
+	if err := h.takeErr(); err != nil {
+		return result, err
+	}
+	return result, childErr
+}
+
+// sendContext returns the context for calls which send events, which is cancelled when events are abandoned.
+func (h *CloudWatchLogsHook) sendContext() context.Context {
+	if h.sendCtx == nil {
+		return context.TODO()
+	}
+	return h.sendCtx
+}
+
+// abandoning determines whether or not the deadline for closing the hook has passed.
+func (h *CloudWatchLogsHook) abandoning() bool {
+	select {
+	case <-h.abandon:
+		return true
+	default:
+		return false
+	}
+}
+
+// abandonEvents hands events which will not be sent to the dead letter sink and returns ErrAbandoned, or the error of
+// the sink if it failed. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) abandonEvents(events []types.InputLogEvent) error {
+	atomic.AddInt64(&h.stats.abandoned, int64(len(events)))
+	return h.deadLetter(events, ErrAbandoned)
+}
@@ -0,0 +1,129 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCloseContext(t *testing.T) {
+	for _, outage := range []bool{false, true} {
+		client := chaos.NewClient(chaos.Faults{})
+		recorder := &chaos.DeadLetterRecorder{}
+		hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+			cloudwatchhook.WithClient(client),
+			cloudwatchhook.WithBatchDuration(time.Hour),
+			cloudwatchhook.WithBackoff(cloudwatchhook.ConstantBackoff{Interval: 10 * time.Millisecond,
+				MaxRetries: 1000}),
+			cloudwatchhook.WithDeadLetterSink(recorder))
+		if err != nil {
+			t.Fatalf("unable to create hook: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(hook, "event %d", i)
+		}
+		if outage {
+			client.SetFaults(chaos.Faults{ThrottleRate: 1})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		result, err := hook.CloseContext(ctx)
+		cancel()
+		if !outage {
+			if err != nil || result.Flushed != 5 || result.Abandoned != 0 {
+				t.Errorf("expected all events to be flushed, got %+v (%v)", result, err)
+			}
+			continue
+		}
+		if err != cloudwatchhook.ErrAbandoned || result.Flushed != 0 || result.Abandoned != 5 {
+			t.Errorf("expected all events to be abandoned, got %+v (%v)", result, err)
+		}
+		if n := len(recorder.Messages()); n != 5 {
+			t.Errorf("expected the abandoned events to be dead lettered, got %d", n)
+		}
+		if stats := hook.Stats(); stats.Abandoned != 5 {
+			t.Errorf("expected 5 abandoned events in the stats, got %d", stats.Abandoned)
+		}
+	}
+}
+
+func TestCloseWhileWriting(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Hour),
+		cloudwatchhook.WithQueueCapacity(1))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+
+	// every write either fails because the hook is closed or is delivered
+	var accepted int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := fmt.Fprintf(hook, "event %d-%d", i, j); err == nil {
+					atomic.AddInt64(&accepted, 1)
+				} else if err != cloudwatchhook.ErrClosed {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}(i)
+	}
+	time.Sleep(time.Millisecond)
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+	wg.Wait()
+
+	if n := int64(len(client.Events("group", "stream"))); n != atomic.LoadInt64(&accepted) {
+		t.Errorf("expected the %d accepted events to be delivered, got %d", accepted, n)
+	}
+}
+
+// streamlessClient fails to create any log stream.
+type streamlessClient struct {
+	*chaos.Client
+}
+
+func (c streamlessClient) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+
+	return nil, errors.New("access denied")
+}
+
+func TestFailedStartStopsWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+	_, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(streamlessClient{chaos.NewClient(chaos.Faults{})}),
+		cloudwatchhook.WithBatchDuration(time.Second),
+		cloudwatchhook.WithTiering([]cloudwatchhook.TierRule{
+			{Levels: []logrus.Level{logrus.DebugLevel}, Bucket: &testBucket{objects: map[string][]byte{}}},
+		}))
+	if err == nil {
+		t.Fatalf("expected the hook to fail to start")
+	}
+
+	// the batching and archiving workers started before the stream was created are stopped
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected no goroutines to be left behind, got %d more", after-before)
+	}
+}
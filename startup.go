@@ -0,0 +1,91 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// modulePath is the import path of this module, used to find its version in the build information.
+const modulePath = "github.com/josh-hogle/logrus-cloudwatch-hook"
+
+// startupEvent is the structured event emitted when the hook is created with the WithStartupEvent option.
+type startupEvent struct {
+	Message string           `json:"msg"`
+	Level   string           `json:"level"`
+	Time    string           `json:"time"`
+	Build   startupBuildInfo `json:"build"`
+	Host    startupHostInfo  `json:"host"`
+	Hook    ConfigSnapshot   `json:"hook"`
+}
+
+// startupBuildInfo holds information about the binary running the hook.
+type startupBuildInfo struct {
+	GoVersion   string            `json:"go_version"`
+	Path        string            `json:"path,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	HookVersion string            `json:"hook_version,omitempty"`
+	Settings    map[string]string `json:"settings,omitempty"`
+}
+
+// startupHostInfo holds information about the host running the hook.
+type startupHostInfo struct {
+	Hostname string            `json:"hostname,omitempty"`
+	PID      int               `json:"pid"`
+	OS       string            `json:"os"`
+	Arch     string            `json:"arch"`
+	CPUs     int               `json:"cpus"`
+	Instance *instanceMetadata `json:"instance,omitempty"`
+}
+
+// newStartupEvent builds the startup event for the hook.
+func (h *CloudWatchLogsHook) newStartupEvent() startupEvent {
+	event := startupEvent{
+		Message: "logger started",
+		Level:   "info",
+		Time:    h.clock.Now().Format(time.RFC3339),
+		Build: startupBuildInfo{
+			GoVersion: runtime.Version(),
+		},
+		Host: startupHostInfo{
+			PID:  os.Getpid(),
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+			CPUs: runtime.NumCPU(),
+		},
+		Hook: h.Config(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		event.Host.Hostname = hostname
+	}
+	if !h.noInstanceMetadata {
+		event.Host.Instance = instanceMetadataLookup.wait(h.clock, h.metadataTimeout)
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		event.Build.Path = info.Main.Path
+		event.Build.Version = info.Main.Version
+		if info.Main.Path == modulePath {
+			event.Build.HookVersion = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				event.Build.HookVersion = dep.Version
+			}
+		}
+		event.Build.Settings = buildSettings(info)
+	}
+	return event
+}
+
+// sendStartupEvent writes the startup event to Amazon CloudWatch.
+func (h *CloudWatchLogsHook) sendStartupEvent() error {
+	line, err := json.Marshal(h.newStartupEvent())
+	if err != nil {
+		return fmt.Errorf("Unable to encode startup event: %v", err)
+	}
+	_, err = h.Write(line)
+	return err
+}
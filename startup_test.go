@@ -0,0 +1,59 @@
+package cloudwatchhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithStartupEvent(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithBatchDuration(time.Minute),
+		cloudwatchhook.WithSchemaVersion("2"), cloudwatchhook.WithStartupEvent(),
+		cloudwatchhook.WithoutInstanceMetadata())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	logger.Info("first entry")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unable to close hook: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 2 {
+		t.Fatalf("expected the startup event and the entry, got %d events", len(events))
+	}
+	var startup struct {
+		Message string `json:"msg"`
+		Host    struct {
+			PID int `json:"pid"`
+		} `json:"host"`
+		Hook struct {
+			Group         string `json:"group"`
+			Stream        string `json:"stream"`
+			BatchDuration string `json:"batch_duration"`
+			SchemaVersion string `json:"schema_version"`
+		} `json:"hook"`
+	}
+	if err := json.Unmarshal([]byte(aws.ToString(events[0].Message)), &startup); err != nil {
+		t.Fatalf("expected the first event to be the startup event, got %s", aws.ToString(events[0].Message))
+	}
+	if startup.Message != "logger started" || startup.Host.PID != os.Getpid() {
+		t.Errorf("unexpected startup event: %s", aws.ToString(events[0].Message))
+	}
+	if hook := startup.Hook; hook.Group != "group" || hook.Stream != "stream" || hook.BatchDuration != "1m0s" ||
+		hook.SchemaVersion != "2" {
+		t.Errorf("expected the configuration in the startup event, got %+v", hook)
+	}
+}
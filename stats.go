@@ -0,0 +1,163 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds counters describing the activity of a hook since it was created.
+type Stats struct {
+	// EmptyDropped is the number of empty or whitespace-only messages dropped by the empty message policy.
+	EmptyDropped int64 `json:"empty_dropped"`
+
+	// EmptyReplaced is the number of empty or whitespace-only messages padded or replaced with a placeholder by the
+	// empty message policy.
+	EmptyReplaced int64 `json:"empty_replaced"`
+
+	// Archived is the number of entries archived to Amazon S3 by the tiering rules rather than sent to Amazon
+	// CloudWatch.
+	Archived int64 `json:"archived"`
+
+	// ArchiveFailed is the number of archived entries which could not be written to Amazon S3. They are handed to the
+	// dead letter sink, if any.
+	ArchiveFailed int64 `json:"archive_failed"`
+
+	// SampledOut is the number of entries dropped by adaptive sampling.
+	SampledOut int64 `json:"sampled_out"`
+
+	// FilteredOut is the number of messages dropped because they did not match the client-side filter pattern.
+	FilteredOut int64 `json:"filtered_out"`
+
+	// BackpressureDropped is the number of entries dropped by the backpressure gate while the batch queue was backed
+	// up.
+	BackpressureDropped int64 `json:"backpressure_dropped"`
+
+	// Delivered is the number of events accepted by Amazon CloudWatch.
+	Delivered int64 `json:"delivered"`
+
+	// Abandoned is the number of events abandoned because the deadline for closing the hook passed before they could
+	// be sent.
+	Abandoned int64 `json:"abandoned"`
+
+	// TokenConflicts is the number of times the upload sequence token of the stream was found to have been changed by
+	// another writer.
+	TokenConflicts int64 `json:"token_conflicts"`
+
+	// BurstBuffered is the number of events put in the burst buffer while the batch queue was full, and
+	// BurstOverflows the number which found the burst buffer full too.
+	BurstBuffered  int64 `json:"burst_buffered"`
+	BurstOverflows int64 `json:"burst_overflows"`
+
+	// FormatErrors is the number of entries dropped because they could not be encoded.
+	FormatErrors int64 `json:"format_errors"`
+
+	// LoggedBytes is the number of bytes logged by the application: the messages and fields of the entries fired and
+	// the bytes written or sent, excluding those dropped before being queued. ShippedBytes is the number of bytes of
+	// the events accepted by Amazon CloudWatch once formatted, enriched and sealed in envelopes, counting
+	// EventOverhead bytes per event.
+	LoggedBytes  int64 `json:"logged_bytes"`
+	ShippedBytes int64 `json:"shipped_bytes"`
+
+	// WriteAmplification is the ratio of ShippedBytes to LoggedBytes, which shows how much the formatting, fields and
+	// envelopes added by the hook inflate the volume ingested. It is zero if nothing has been logged.
+	WriteAmplification float64 `json:"write_amplification,omitempty"`
+
+	// ErrorsSuppressed is the number of errors from sending batches which were not returned because they repeated the
+	// previous error, when errors are deduplicated.
+	ErrorsSuppressed int64 `json:"errors_suppressed"`
+
+	// BatchDuration is the current batch duration, which adaptive batching shortens while events are queued faster
+	// than they are sent. It is zero if the hook does not batch events.
+	BatchDuration time.Duration `json:"batch_duration,omitempty"`
+
+	// BatchAdaptations is the number of times adaptive batching has changed the batch duration.
+	BatchAdaptations int64 `json:"batch_adaptations"`
+
+	// Verified and VerificationMissing are the numbers of events sampled by WithVerification which were found in
+	// Amazon CloudWatch after being accepted and which were never found, respectively.
+	Verified            int64 `json:"verified"`
+	VerificationMissing int64 `json:"verification_missing"`
+
+	// VerificationRate is the fraction of the settled samples which were found, or 1 if none have been settled. It is
+	// zero if verification is not enabled.
+	VerificationRate float64 `json:"verification_rate,omitempty"`
+
+	// LatencyP50, LatencyP95 and LatencyP99 are estimates of the percentiles of the time taken to deliver batches,
+	// from when their oldest event was written until they were accepted. DeliveryLatency returns the full histogram.
+	LatencyP50 time.Duration `json:"latency_p50,omitempty"`
+	LatencyP95 time.Duration `json:"latency_p95,omitempty"`
+	LatencyP99 time.Duration `json:"latency_p99,omitempty"`
+
+	// SamplingRates holds the current fraction of the entries of each level kept by adaptive sampling, if enabled.
+	SamplingRates map[string]float64 `json:"sampling_rates,omitempty"`
+}
+
+// statsCounters holds the counters behind Stats. It is allocated separately from the hook so that the counters are
+// 64-bit aligned for atomic access on 32-bit platforms.
+type statsCounters struct {
+	emptyDropped        int64
+	emptyReplaced       int64
+	archived            int64
+	archiveFailed       int64
+	sampledOut          int64
+	filteredOut         int64
+	backpressureDropped int64
+	delivered           int64
+	abandoned           int64
+	tokenConflicts      int64
+	burstBuffered       int64
+	burstOverflows      int64
+	formatErrors        int64
+	errorsSuppressed    int64
+	loggedBytes         int64
+	shippedBytes        int64
+
+	// queuedBytes, batchedBytes and inFlightBytes are the sizes reported by MemoryUsage
+	queuedBytes   int64
+	batchedBytes  int64
+	inFlightBytes int64
+}
+
+// Stats returns a snapshot of the counters describing the activity of the hook.
+func (h *CloudWatchLogsHook) Stats() Stats {
+	stats := Stats{
+		EmptyDropped:        atomic.LoadInt64(&h.stats.emptyDropped),
+		EmptyReplaced:       atomic.LoadInt64(&h.stats.emptyReplaced),
+		Archived:            atomic.LoadInt64(&h.stats.archived),
+		ArchiveFailed:       atomic.LoadInt64(&h.stats.archiveFailed),
+		SampledOut:          atomic.LoadInt64(&h.stats.sampledOut),
+		FilteredOut:         atomic.LoadInt64(&h.stats.filteredOut),
+		BackpressureDropped: atomic.LoadInt64(&h.stats.backpressureDropped),
+		Delivered:           atomic.LoadInt64(&h.stats.delivered),
+		Abandoned:           atomic.LoadInt64(&h.stats.abandoned),
+		TokenConflicts:      atomic.LoadInt64(&h.stats.tokenConflicts),
+		BurstBuffered:       atomic.LoadInt64(&h.stats.burstBuffered),
+		BurstOverflows:      atomic.LoadInt64(&h.stats.burstOverflows),
+		FormatErrors:        atomic.LoadInt64(&h.stats.formatErrors),
+		ErrorsSuppressed:    atomic.LoadInt64(&h.stats.errorsSuppressed),
+		LoggedBytes:         atomic.LoadInt64(&h.stats.loggedBytes),
+		ShippedBytes:        atomic.LoadInt64(&h.stats.shippedBytes),
+	}
+	stats.WriteAmplification = writeAmplification(stats.LoggedBytes, stats.ShippedBytes)
+	if h.latency != nil {
+		latency := h.latency.snapshot()
+		stats.LatencyP50 = latency.Quantile(0.5)
+		stats.LatencyP95 = latency.Quantile(0.95)
+		stats.LatencyP99 = latency.Quantile(0.99)
+	}
+	if h.ch != nil {
+		stats.BatchDuration = h.batchDuration()
+	}
+	if h.adapter != nil {
+		stats.BatchAdaptations = atomic.LoadInt64(&h.adapter.adaptations)
+	}
+	if h.verifier != nil {
+		stats.Verified = atomic.LoadInt64(&h.verifier.verified)
+		stats.VerificationMissing = atomic.LoadInt64(&h.verifier.missing)
+		stats.VerificationRate = h.verifier.successRate()
+	}
+	if h.sampler != nil {
+		stats.SamplingRates = h.sampler.effectiveRates()
+	}
+	return stats
+}
@@ -0,0 +1,118 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// maxConcurrentStreamCreations is the number of streams EnsureStreams creates at the same time, which keeps a long
+// list of streams from being throttled.
+const maxConcurrentStreamCreations = 8
+
+// EnsureStreams creates the named streams in the log group the hook currently writes to, ignoring those which already
+// exist, so that pointing the hook at one of them later with Update does not have to wait for the stream to be
+// created. The names are passed through the naming policies of the hook, just as Update does. The streams are created
+// concurrently and calling EnsureStreams again with the same names is harmless. If the hook was created with the
+// WithNoCreate option, the streams are only checked to exist.
+func (h *CloudWatchLogsHook) EnsureStreams(ctx context.Context, names ...string) error {
+	if h.relay != "" || h.transport != nil {
+		return fmt.Errorf("Unable to create log streams: the hook does not write to Amazon CloudWatch directly")
+	}
+	h.intakeMutex.Lock()
+	group := h.currentTarget().group
+	h.intakeMutex.Unlock()
+	targets := make([]streamTarget, 0, len(names))
+	for _, name := range names {
+		target, err := h.resolveTarget(streamTarget{group: group, stream: name})
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var failures []string
+	limit := make(chan struct{}, maxConcurrentStreamCreations)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target streamTarget) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			if err := h.ensureStream(ctx, target); err != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				failures = append(failures, fmt.Sprintf("%s: %v", target.stream, err))
+			}
+		}(target)
+	}
+	wg.Wait()
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("Unable to create log streams in %s: %s", group, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// ensureStream creates the stream, or checks that it exists if the hook may not create it, and remembers it so that
+// it is not created again.
+func (h *CloudWatchLogsHook) ensureStream(ctx context.Context, target streamTarget) error {
+	if h.knownStream(target) {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if h.noCreate {
+		if err := h.requireTarget(target); err != nil {
+			return err
+		}
+	} else {
+		_, err := h.client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(target.group),
+			LogStreamName: aws.String(target.stream),
+		})
+		var existsErr *types.ResourceAlreadyExistsException
+		if err != nil && !errors.As(err, &existsErr) {
+			return err
+		}
+	}
+	if err := h.tagStream(ctx, target); err != nil {
+		return err
+	}
+	h.resourceMutex.Lock()
+	defer h.resourceMutex.Unlock()
+	if h.knownStreams == nil {
+		h.knownStreams = map[streamTarget]bool{}
+	}
+	h.knownStreams[target] = true
+	return nil
+}
+
+// knownStream reports whether the stream is known to exist because it was created by EnsureStreams.
+func (h *CloudWatchLogsHook) knownStream(target streamTarget) bool {
+	h.resourceMutex.RLock()
+	defer h.resourceMutex.RUnlock()
+	return h.knownStreams[target]
+}
+
+// forgetStreams removes the streams matching the group, and the stream if one is given, from those known to exist, so
+// that EnsureStreams and Update create them again after they were deleted.
+func (h *CloudWatchLogsHook) forgetStreams(group, stream string) {
+	h.resourceMutex.Lock()
+	defer h.resourceMutex.Unlock()
+	for target := range h.knownStreams {
+		if target.group == group && (stream == "" || target.stream == stream) {
+			delete(h.knownStreams, target)
+		}
+	}
+}
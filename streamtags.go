@@ -0,0 +1,61 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// ResourceTagsAPI is the part of the Amazon CloudWatch Logs API used to tag resources by their ARN. TagResource is
+// newer than the version of the SDK the hook is built with, so it is typically provided by wrapping the client with a
+// method which calls TagResource on a newer client. It is only used by the WithStreamTags option.
+type ResourceTagsAPI interface {
+	TagResource(ctx context.Context, resourceARN string, tags map[string]string) error
+}
+
+// tagStream applies the stream tags of the hook to the stream. Streams which cannot be tagged, because the client does
+// not support tagging resources, the ARN of the stream is not known or the service does not support tagging streams,
+// are reported to the debug logger and otherwise left alone.
+func (h *CloudWatchLogsHook) tagStream(ctx context.Context, target streamTarget) error {
+	if len(h.streamTags) == 0 {
+		return nil
+	}
+	client, ok := h.client.(ResourceTagsAPI)
+	if !ok {
+		h.debugf("not tagging log stream %s: client does not support tagging resources", target.stream)
+		return nil
+	}
+	arn := h.streamARNFor(target)
+	if arn == "" {
+		h.debugf("not tagging log stream %s: its ARN is not known", target.stream)
+		return nil
+	}
+	err := client.TagResource(ctx, arn, h.streamTags)
+	var apiErr smithy.APIError
+	if isInvalidParameter(err) || (errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException") {
+		h.debugf("not tagging log stream %s: %v", target.stream, err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to tag log stream %s: %v", target.stream, err)
+	}
+	return nil
+}
+
+// streamARNFor returns the ARN of the stream, derived from the ARN of the log group or stream of the hook, or an empty
+// string if neither is known.
+func (h *CloudWatchLogsHook) streamARNFor(target streamTarget) string {
+	h.resourceMutex.RLock()
+	arn := h.groupARN
+	if arn == "" {
+		arn = h.streamARN
+	}
+	h.resourceMutex.RUnlock()
+	i := strings.Index(arn, ":log-group:")
+	if i < 0 {
+		return ""
+	}
+	return arn[:i] + ":log-group:" + target.group + ":log-stream:" + target.stream
+}
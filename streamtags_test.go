@@ -0,0 +1,48 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+// untaggableClient hides the TagResource method of the client it wraps.
+type untaggableClient struct {
+	cloudwatchhook.CloudWatchLogsAPI
+}
+
+func TestStreamTags(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "tenant-1",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithStreamTags(map[string]string{"cost-center": "42"}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	if err := hook.EnsureStreams(context.Background(), "tenant-2"); err != nil {
+		t.Fatalf("unable to create streams: %v", err)
+	}
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/app/jobs", "tenant-3")); err != nil {
+		t.Fatalf("unable to update hook: %v", err)
+	}
+	for _, target := range [][2]string{{"/app/web", "tenant-1"}, {"/app/web", "tenant-2"}, {"/app/jobs", "tenant-3"}} {
+		if tags := client.StreamTags(target[0], target[1]); tags["cost-center"] != "42" {
+			t.Errorf("expected stream %s/%s to be tagged, got %v", target[0], target[1], tags)
+		}
+	}
+
+	// streams are only tagged where supported
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "tenant-4",
+		cloudwatchhook.WithClient(untaggableClient{client}),
+		cloudwatchhook.WithStreamTags(map[string]string{"cost-center": "42"}))
+	if err != nil {
+		t.Fatalf("expected the hook to be created without tagging the stream, got %v", err)
+	}
+	defer hook.Close()
+	if tags := client.StreamTags("/app/web", "tenant-4"); len(tags) != 0 {
+		t.Errorf("expected the stream not to be tagged, got %v", tags)
+	}
+}
@@ -0,0 +1,76 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TagStandard describes the tags an organization requires on every log group, such as the owner, cost center and
+// environment. The value of each tag is looked up when the hook is created, and creating the hook fails if any of them
+// is missing.
+type TagStandard struct {
+	// Tags maps each required tag key to the environment variable holding its value.
+	Tags map[string]string
+
+	// Provider, if not nil, is used to look up the value of each tag instead of the environment. It returns an empty
+	// value if the tag is not set.
+	Provider func(key string) (string, error)
+}
+
+// DefaultTagStandard requires the owner, cost-center and environment tags, read from the TAG_OWNER, TAG_COST_CENTER
+// and TAG_ENVIRONMENT environment variables.
+var DefaultTagStandard = TagStandard{
+	Tags: map[string]string{
+		"owner":       "TAG_OWNER",
+		"cost-center": "TAG_COST_CENTER",
+		"environment": "TAG_ENVIRONMENT",
+	},
+}
+
+// resolve looks up the value of each tag required by the standard.
+func (s TagStandard) resolve() (map[string]string, error) {
+	tags := map[string]string{}
+	var missing []string
+	for key, env := range s.Tags {
+		value := os.Getenv(env)
+		if s.Provider != nil {
+			var err error
+			if value, err = s.Provider(key); err != nil {
+				return nil, fmt.Errorf("Unable to look up value for tag %q: %v", key, err)
+			}
+		}
+		if value == "" {
+			missing = append(missing, key)
+			continue
+		}
+		tags[key] = value
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("Missing values for required tags: %s", strings.Join(missing, ", "))
+	}
+	return tags, nil
+}
+
+// applyDefaultTags adds the tags required by the tag standard to the log group tags. Tags given explicitly with the
+// WithGroupTags option take precedence.
+func (h *CloudWatchLogsHook) applyDefaultTags() error {
+	if h.tagStandard == nil {
+		return nil
+	}
+	tags, err := h.tagStandard.resolve()
+	if err != nil {
+		return err
+	}
+	merged := make(map[string]string, len(tags)+len(h.tags))
+	for k, v := range tags {
+		merged[k] = v
+	}
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	h.tags = merged
+	return nil
+}
@@ -0,0 +1,41 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestApplyDefaultTags(t *testing.T) {
+	values := map[string]string{"owner": "payments", "cost-center": "cc-42"}
+	standard := TagStandard{
+		Tags: map[string]string{"owner": "", "cost-center": ""},
+		Provider: func(key string) (string, error) {
+			return values[key], nil
+		},
+	}
+	h := newTestHook(t, "group", "stream", WithGroupTags(map[string]string{"owner": "billing", "team": "core"}),
+		WithDefaultTags(standard))
+	expected := map[string]string{"owner": "billing", "cost-center": "cc-42", "team": "core"}
+	if fmt.Sprint(h.tags) != fmt.Sprint(expected) {
+		t.Errorf("expected tags %v, got %v", expected, h.tags)
+	}
+
+	standard.Tags["environment"] = ""
+	if _, err := newHook(aws.Config{}, "group", "stream", WithDefaultTags(standard)); err == nil {
+		t.Errorf("expected an error for a missing tag")
+	}
+
+	os.Setenv("TEST_TAG_OWNER", "payments")
+	defer os.Unsetenv("TEST_TAG_OWNER")
+	h, err := newHook(aws.Config{}, "group", "stream",
+		WithDefaultTags(TagStandard{Tags: map[string]string{"owner": "TEST_TAG_OWNER"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.tags["owner"] != "payments" {
+		t.Errorf("expected the owner tag from the environment, got %v", h.tags)
+	}
+}
@@ -0,0 +1,169 @@
+package cloudwatchhook
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// targetBatch is the batch of events being collected for a single stream and, when the WithTimestampBuckets option is
+// used, a single timestamp bucket.
+type targetBatch struct {
+	batchExtent
+	target   streamTarget
+	bucket   int64
+	events   []types.InputLogEvent
+	seqs     []uint64
+	lagID    uint64
+	deadline time.Time
+}
+
+// fits reports whether an event of the given size and timestamp can be added without exceeding the PutLogEvents
+// limits.
+func (b *targetBatch) fits(eventSize int, timestamp int64) bool {
+	return b.batchExtent.fits(putLogEventsLimits, len(b.events), eventSize, timestamp)
+}
+
+// batchSet collects a batch for each stream which events are queued for, so that events routed to several streams are
+// batched independently instead of each change of stream cutting the current batch short. Each batch is sent when it
+// is full or when its own deadline passes. It is only used by the batching worker, so it need not be safe for
+// concurrent use.
+type batchSet struct {
+	batches map[batchKey]*targetBatch
+
+	// closed holds the start of the latest timestamp bucket which had ended when its batch was sent for each stream,
+	// and bucketWidth the width of the buckets, or zero if events are not batched by timestamp bucket
+	closed      map[streamTarget]int64
+	bucketWidth time.Duration
+}
+
+// batchKey identifies the batch being collected for a stream and timestamp bucket. The bucket is always zero unless
+// the WithTimestampBuckets option is used.
+type batchKey struct {
+	target streamTarget
+	bucket int64
+}
+
+// newBatchSet creates an empty set of batches for timestamp buckets of the given width, which is zero if events are
+// not batched by timestamp bucket.
+func newBatchSet(bucketWidth time.Duration) *batchSet {
+	return &batchSet{batches: map[batchKey]*targetBatch{}, closed: map[streamTarget]int64{}, bucketWidth: bucketWidth}
+}
+
+// get returns the batch being collected for the stream and bucket, or nil if there is none.
+func (s *batchSet) get(target streamTarget, bucket int64) *targetBatch {
+	return s.batches[batchKey{target: target, bucket: bucket}]
+}
+
+// add starts a new batch for the stream and bucket which is due at the deadline.
+func (s *batchSet) add(target streamTarget, bucket int64, deadline time.Time) *targetBatch {
+	b := &targetBatch{
+		batchExtent: batchExtent{},
+		target:      target,
+		bucket:      bucket,
+		events:      getBatchSlice(),
+		seqs:        getSeqSlice(),
+		lagID:       0,
+		deadline:    deadline,
+	}
+	s.batches[batchKey{target: target, bucket: bucket}] = b
+	return b
+}
+
+// remove takes the batch out of the set.
+func (s *batchSet) remove(b *targetBatch) {
+	delete(s.batches, batchKey{target: b.target, bucket: b.bucket})
+}
+
+// late reports whether the batch for the stream and bucket was already sent at its deadline, or that of a later
+// bucket was.
+func (s *batchSet) late(target streamTarget, bucket int64) bool {
+	closed, ok := s.closed[target]
+	return ok && bucket <= closed
+}
+
+// expired removes and returns the batches whose deadline has passed, and all batches if now is the zero time. They
+// are ordered by deadline, so the stream which has waited longest is sent first and a busy stream cannot starve the
+// others. The buckets of the batches which have ended by now are recorded as closed; those sent early, when the hook
+// is drained or closed, are left open so that later events for them are not flagged as late.
+func (s *batchSet) expired(now time.Time) []*targetBatch {
+	var due []*targetBatch
+	for _, b := range s.batches {
+		if now.IsZero() || !b.deadline.After(now) {
+			due = append(due, b)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].deadline.Equal(due[j].deadline) {
+			return due[i].deadline.Before(due[j].deadline)
+		}
+		if due[i].target.group != due[j].target.group {
+			return due[i].target.group < due[j].target.group
+		}
+		if due[i].target.stream != due[j].target.stream {
+			return due[i].target.stream < due[j].target.stream
+		}
+		return due[i].bucket < due[j].bucket
+	})
+	for _, b := range due {
+		s.remove(b)
+		if s.bucketWidth <= 0 || now.IsZero() {
+			continue
+		}
+		end := time.Unix(0, b.bucket*int64(time.Millisecond)).Add(s.bucketWidth)
+		if closed, ok := s.closed[b.target]; now.Before(end) || (ok && b.bucket <= closed) {
+			continue
+		}
+		s.closed[b.target] = b.bucket
+	}
+	return due
+}
+
+// next returns the earliest deadline of the batches, if there are any.
+func (s *batchSet) next() (time.Time, bool) {
+	var next time.Time
+	for _, b := range s.batches {
+		if next.IsZero() || b.deadline.Before(next) {
+			next = b.deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// spanSlices splits events in chronological order into consecutive slices which each span less than 24 hours, as
+// required by PutLogEvents. A replayed backlog can easily span more. The slices share the backing array of the events.
+func spanSlices(events []types.InputLogEvent) [][]types.InputLogEvent {
+	var slices [][]types.InputLogEvent
+	span := int64(MaxBatchSpan / time.Millisecond)
+	start := 0
+	for i := 1; i < len(events); i++ {
+		if aws.ToInt64(events[i].Timestamp)-aws.ToInt64(events[start].Timestamp) >= span {
+			slices = append(slices, events[start:i])
+			start = i
+		}
+	}
+	return append(slices, events[start:])
+}
+
+// limitSlices splits events which are in chronological order into slices within the size and count limits of a single
+// PutLogEvents call.
+func limitSlices(events []types.InputLogEvent) [][]types.InputLogEvent {
+	var slices [][]types.InputLogEvent
+	var extent batchExtent
+	start := 0
+	for i, event := range events {
+		eventSize := len(aws.ToString(event.Message)) + EventOverhead
+		timestamp := aws.ToInt64(event.Timestamp)
+		if i > start && !extent.fits(putLogEventsLimits, i-start, eventSize, timestamp) {
+			slices = append(slices, events[start:i])
+			start, extent = i, batchExtent{}
+		}
+		extent.extend(i-start, eventSize, timestamp)
+	}
+	if start < len(events) {
+		slices = append(slices, events[start:])
+	}
+	return slices
+}
@@ -0,0 +1,66 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TemplateData holds the values available to a message template.
+type TemplateData struct {
+	Time     time.Time
+	Level    string
+	Message  string
+	Fields   logrus.Fields
+	Caller   *runtime.Frame
+	Metadata map[string]string
+}
+
+// templateFuncs holds the functions available to message templates in addition to the standard ones.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// TemplateCodec encodes entries using a text/template, which is given a TemplateData for each entry. The json function
+// is available to templates for rendering values, such as all of the fields, as JSON.
+type TemplateCodec struct {
+	template *template.Template
+	metadata map[string]string
+}
+
+// NewTemplateCodec parses the template and creates a codec which renders it with the given metadata, such as the log
+// group and stream.
+func NewTemplateCodec(text string, metadata map[string]string) (*TemplateCodec, error) {
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateCodec{
+		template: tmpl,
+		metadata: metadata,
+	}, nil
+}
+
+// Encode renders the template for the entry.
+func (c *TemplateCodec) Encode(entry *logrus.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.template.Execute(&buf, TemplateData{
+		Time:     entry.Time,
+		Level:    entry.Level.String(),
+		Message:  entry.Message,
+		Fields:   entry.Data,
+		Caller:   entry.Caller,
+		Metadata: c.metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,32 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTemplateCodec(t *testing.T) {
+	codec, err := NewTemplateCodec(`{{.Time.Format "15:04:05"}} {{.Metadata.stream}} [{{.Level}}] {{.Message}} `+
+		`{{json .Fields}}`, map[string]string{"stream": "web-1"})
+	if err != nil {
+		t.Fatalf("unable to parse template: %v", err)
+	}
+	line, err := codec.Encode(&logrus.Entry{
+		Time:    time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "disk low",
+		Data:    logrus.Fields{"free": 5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `12:30:00 web-1 [warning] disk low {"free":5}`; string(line) != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+
+	if _, err := NewTemplateCodec("{{.Message", nil); err == nil {
+		t.Errorf("expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,238 @@
+package cloudwatchhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// archiveInterval is how often archived events are written to Amazon S3.
+	archiveInterval = time.Minute
+
+	// maxArchiveBytes is the size of the archived messages at which they are written to Amazon S3 without waiting
+	// for the archive interval.
+	maxArchiveBytes = 8 * 1024 * 1024
+)
+
+// S3Bucket is the subset of Amazon S3 operations needed to archive log events. It is typically a thin wrapper around
+// the PutObject operation of an Amazon S3 client bound to a single bucket, so the hook does not need to depend on the
+// S3 SDK.
+type S3Bucket interface {
+	// PutObject stores the body under the given key.
+	PutObject(ctx context.Context, key string, body []byte) error
+}
+
+// TierRule is used to send entries of the given levels to a cheaper storage tier than Amazon CloudWatch. If Bucket
+// is nil, matching entries are sent to Amazon CloudWatch; otherwise they are archived to the bucket under the prefix.
+type TierRule struct {
+	Levels []logrus.Level
+	Bucket S3Bucket
+	Prefix string
+}
+
+// archiveRecord is a single archived event, written as a line of JSON so that the archive can be queried with Amazon
+// Athena.
+type archiveRecord struct {
+	Timestamp int64  `json:"timestamp"`
+	Level     string `json:"level"`
+	Group     string `json:"group"`
+	Stream    string `json:"stream"`
+	Message   string `json:"message"`
+}
+
+// tierArchive holds the events waiting to be archived to a bucket.
+type tierArchive struct {
+	bucket  S3Bucket
+	prefix  string
+	mutex   sync.Mutex
+	records []archiveRecord
+	size    int
+	closed  bool
+}
+
+// take removes and returns the waiting events.
+func (a *tierArchive) take() []archiveRecord {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	records := a.records
+	a.records = nil
+	a.size = 0
+	return records
+}
+
+// close removes and returns the waiting events and rejects any events added afterwards, so that none are added after
+// the final write.
+func (a *tierArchive) close() []archiveRecord {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	records := a.records
+	a.records = nil
+	a.size = 0
+	a.closed = true
+	return records
+}
+
+// archiveKey returns the key under which events starting at the given time are archived. Keys are partitioned by the
+// group, stream and hour in the Hive style understood by Amazon Athena.
+func archiveKey(prefix, group, stream string, oldest time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	oldest = oldest.UTC()
+	return fmt.Sprintf("%s%s/%s/year=%04d/month=%02d/day=%02d/hour=%02d/%d-%s.json.gz", prefix,
+		strings.TrimPrefix(group, "/"), stream, oldest.Year(), oldest.Month(), oldest.Day(), oldest.Hour(),
+		oldest.UnixNano()/int64(time.Millisecond), hex.EncodeToString(suffix))
+}
+
+// encodeArchive writes the records as gzip-compressed lines of JSON in chronological order.
+func encodeArchive(records []archiveRecord) ([]byte, error) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp < records[j].Timestamp
+	})
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tierRuleFor returns the index of the first tier rule covering the given level, or -1 if there is none.
+func (h *CloudWatchLogsHook) tierRuleFor(level logrus.Level) int {
+	for i, rule := range h.tierRules {
+		for _, l := range rule.Levels {
+			if l == level {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// tierFor returns the archive for entries of the given level, or nil if they are sent to Amazon CloudWatch.
+func (h *CloudWatchLogsHook) tierFor(level logrus.Level) *tierArchive {
+	if i := h.tierRuleFor(level); i >= 0 && h.tiers != nil {
+		return h.tiers[i]
+	}
+	return nil
+}
+
+// archive adds the message to the archive, writing the archive to Amazon S3 in the background once it is large
+// enough.
+func (h *CloudWatchLogsHook) archive(a *tierArchive, level logrus.Level, msg string, ts time.Time) error {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return ErrClosed
+	}
+	a.mutex.Lock()
+	if a.closed {
+		a.mutex.Unlock()
+		return ErrClosed
+	}
+	a.records = append(a.records, archiveRecord{
+		Timestamp: ts.UnixNano() / int64(time.Millisecond),
+		Level:     level.String(),
+		Group:     h.group,
+		Stream:    h.stream,
+		Message:   h.sanitize(msg),
+	})
+	a.size += len(msg)
+	full := a.size >= maxArchiveBytes
+	a.mutex.Unlock()
+	atomic.AddInt64(&h.stats.archived, 1)
+
+	if full {
+		h.inflight.Add(1)
+		go func() {
+			defer h.inflight.Done()
+			h.flushArchive(a)
+		}()
+	}
+	return h.takeErr()
+}
+
+// flushArchive writes the waiting events of the archive to Amazon S3.
+func (h *CloudWatchLogsHook) flushArchive(a *tierArchive) {
+	h.writeArchive(a, a.take())
+}
+
+// writeArchive writes the events to the bucket of the archive. Events which cannot be written are counted and handed
+// to the dead letter sink.
+func (h *CloudWatchLogsHook) writeArchive(a *tierArchive, records []archiveRecord) {
+	if len(records) == 0 {
+		return
+	}
+	body, err := encodeArchive(records)
+	if err != nil {
+		h.archiveFailed(records, fmt.Errorf("Unable to encode archive: %w", err))
+		return
+	}
+	key := archiveKey(a.prefix, h.group, h.stream, time.Unix(0, records[0].Timestamp*int64(time.Millisecond)))
+	if err := a.bucket.PutObject(h.sendContext(), key, body); err != nil {
+		h.archiveFailed(records, fmt.Errorf("Unable to archive %d events to %s: %w", len(records), key, err))
+	}
+}
+
+// archiveFailed counts the events which could not be archived and hands them to the dead letter sink.
+func (h *CloudWatchLogsHook) archiveFailed(records []archiveRecord, err error) {
+	atomic.AddInt64(&h.stats.archiveFailed, int64(len(records)))
+	if h.deadLetters != nil {
+		letter := DeadLetter{
+			Group:  h.group,
+			Stream: h.stream,
+			Events: make([]types.InputLogEvent, len(records)),
+			Err:    err,
+			Time:   h.clock.Now(),
+		}
+		for i, record := range records {
+			letter.Events[i] = newEvent(record.Message, record.Timestamp)
+		}
+		if sinkErr := h.deadLetters.Send(h.sendContext(), letter); sinkErr != nil {
+			err = fmt.Errorf("%w (dead letter sink failed: %v)", err, sinkErr)
+		}
+	}
+	h.setErr(err)
+}
+
+// archiveTiers periodically writes the archived events to Amazon S3 until the hook is closed, when any remaining
+// events are written.
+func (h *CloudWatchLogsHook) archiveTiers() {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(archiveInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.done:
+			for _, a := range h.tiers {
+				if a != nil {
+					h.writeArchive(a, a.close())
+				}
+			}
+			return
+		case <-timer.C():
+			timer.Reset(archiveInterval)
+			for _, a := range h.tiers {
+				if a != nil {
+					h.flushArchive(a)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cloudwatchhook_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+// testBucket is an S3Bucket which keeps the objects in memory.
+type testBucket struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *testBucket) PutObject(ctx context.Context, key string, body []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.objects[key] = body
+	return nil
+}
+
+func TestWithTiering(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	bucket := &testBucket{objects: map[string][]byte{}}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithTiering([]cloudwatchhook.TierRule{
+			{Levels: []logrus.Level{logrus.DebugLevel, logrus.TraceLevel}, Bucket: bucket, Prefix: "logs/"},
+		}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(hook)
+	logger.Trace("trace detail")
+	logger.Debug("debug detail")
+	logger.Warn("disk low")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := client.Events("/app/group", "stream")
+	if len(events) != 1 || !strings.Contains(aws.ToString(events[0].Message), "disk low") {
+		t.Errorf("expected only the warning in CloudWatch, got %d events", len(events))
+	}
+	if len(bucket.objects) != 1 {
+		t.Fatalf("expected 1 archive object, got %d", len(bucket.objects))
+	}
+	for key, body := range bucket.objects {
+		if !strings.HasPrefix(key, "logs/app/group/stream/year=") || !strings.HasSuffix(key, ".json.gz") {
+			t.Errorf("unexpected archive key %s", key)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("unable to read archive: %v", err)
+		}
+		lines, _ := ioutil.ReadAll(zr)
+		if n := bytes.Count(lines, []byte("\n")); n != 2 || !bytes.Contains(lines, []byte(`"level":"trace"`)) {
+			t.Errorf("expected the debug and trace entries in the archive, got %s", lines)
+		}
+	}
+	if n := hook.Stats().Archived; n != 2 {
+		t.Errorf("expected 2 archived entries, got %d", n)
+	}
+}
+
+// failingBucket is an S3Bucket which fails to store every object.
+type failingBucket struct{}
+
+func (failingBucket) PutObject(ctx context.Context, key string, body []byte) error {
+	return errors.New("access denied")
+}
+
+func TestTieringArchiveFailure(t *testing.T) {
+	recorder := &chaos.DeadLetterRecorder{}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})), cloudwatchhook.WithDeadLetterSink(recorder),
+		cloudwatchhook.WithTiering([]cloudwatchhook.TierRule{
+			{Levels: []logrus.Level{logrus.DebugLevel}, Bucket: failingBucket{}, Prefix: "logs/"},
+		}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(hook)
+	logger.Debug("debug detail")
+	hook.Close()
+
+	if n := hook.Stats().ArchiveFailed; n != 1 {
+		t.Errorf("expected 1 entry to fail to be archived, got %d", n)
+	}
+	if messages := recorder.Messages(); len(messages) != 1 || !strings.Contains(messages[0], "debug detail") {
+		t.Errorf("expected the entry to be dead lettered, got %v", messages)
+	}
+	entry := logrus.NewEntry(logger)
+	entry.Level = logrus.DebugLevel
+	entry.Message = "too late"
+	if err := hook.Fire(entry); err != cloudwatchhook.ErrClosed {
+		t.Errorf("expected entries archived after the hook is closed to be rejected, got %v", err)
+	}
+}
+
+// stuckBucket is an S3Bucket whose writes never complete until they are cancelled.
+type stuckBucket struct{}
+
+func (stuckBucket) PutObject(ctx context.Context, key string, body []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTieringCancelledOnClose(t *testing.T) {
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/group", "stream",
+		cloudwatchhook.WithClient(chaos.NewClient(chaos.Faults{})),
+		cloudwatchhook.WithTiering([]cloudwatchhook.TierRule{
+			{Levels: []logrus.Level{logrus.DebugLevel}, Bucket: stuckBucket{}, Prefix: "logs/"},
+		}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(hook)
+	logger.Debug("debug detail")
+
+	// the archive being written is cancelled once the deadline for closing the hook passes
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		hook.CloseContext(ctx)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected closing the hook to interrupt the stuck archive")
+	}
+	if n := hook.Stats().ArchiveFailed; n != 1 {
+		t.Errorf("expected 1 entry to fail to be archived, got %d", n)
+	}
+}
@@ -0,0 +1,37 @@
+package cloudwatchhook
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TimestampField is the name of the field holding the human readable timestamp added by the WithTimestampFormat
+	// and WithTimestampLocation options.
+	TimestampField = "timestamp"
+
+	// TimestampEpochMillis renders the timestamp field as the number of milliseconds since the Unix epoch rather than
+	// using a time layout.
+	TimestampEpochMillis = "epoch_millis"
+)
+
+// formatTimestamp renders the time using the layout in the given location. A nil location leaves the time in the
+// location it was recorded in.
+func formatTimestamp(t time.Time, layout string, loc *time.Location) interface{} {
+	if layout == TimestampEpochMillis {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(layout)
+}
+
+// TimestampEnricher returns an enricher which adds the time of the entry, rendered using the layout in the given
+// location, to the fields. A nil location leaves the time in the location it was recorded in.
+func TimestampEnricher(layout string, loc *time.Location) Enricher {
+	return EnricherFunc(func(entry *logrus.Entry, fields logrus.Fields) {
+		fields[TimestampField] = formatTimestamp(entry.Time, layout, loc)
+	})
+}
@@ -0,0 +1,25 @@
+package cloudwatchhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.FixedZone("EST", -5*60*60))
+	tests := []struct {
+		layout   string
+		loc      *time.Location
+		expected interface{}
+	}{
+		{time.RFC3339Nano, nil, "2021-03-04T05:06:07.008-05:00"},
+		{time.RFC3339Nano, time.UTC, "2021-03-04T10:06:07.008Z"},
+		{"2006-01-02 15:04:05", time.UTC, "2021-03-04 10:06:07"},
+		{TimestampEpochMillis, nil, int64(1614852367008)},
+	}
+	for _, test := range tests {
+		if actual := formatTimestamp(ts, test.layout, test.loc); actual != test.expected {
+			t.Errorf("formatTimestamp(%q, %v) = %v, want %v", test.layout, test.loc, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cloudwatchhook
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// TokenConflict describes a sign that another writer is sharing the log stream of the hook: the upload sequence token
+// of the stream changed without the hook writing to it. Sharing a stream between writers is not recommended, since
+// each write invalidates the token held by the others.
+type TokenConflict struct {
+	Group    string
+	Stream   string
+	Expected string
+	Found    string
+	Time     time.Time
+}
+
+// refreshTokens periodically refreshes the upload sequence token of the stream until the hook is closed, so that a
+// token held by a quiet hook does not go stale while another writer uses the stream.
+func (h *CloudWatchLogsHook) refreshTokens(interval time.Duration) {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-timer.C():
+			timer.Reset(interval)
+			h.refreshToken()
+		}
+	}
+}
+
+// refreshToken describes the stream to pick up its current upload sequence token, reporting a conflict if it is not
+// the token the hook expected. Failures are ignored, since the token is refreshed again at the next interval and
+// recovered from the service when an upload fails.
+func (h *CloudWatchLogsHook) refreshToken() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	expected := h.nextSequenceToken
+	stream, err := h.findLogStream()
+	if err != nil || stream == nil {
+		h.nextSequenceToken = expected
+		return
+	}
+	if found := aws.ToString(stream.UploadSequenceToken); found != aws.ToString(expected) {
+		h.tokenConflict(aws.ToString(expected), found)
+	}
+}
+
+// tokenConflict records a conflict over the upload sequence token of the stream and reports it to the callback, if
+// one is set. The caller must hold the mutex.
+func (h *CloudWatchLogsHook) tokenConflict(expected, found string) {
+	atomic.AddInt64(&h.stats.tokenConflicts, 1)
+	if h.tokenCallback == nil {
+		return
+	}
+	target := h.boundTarget()
+	h.tokenCallback(TokenConflict{
+		Group:    target.group,
+		Stream:   target.stream,
+		Expected: expected,
+		Found:    found,
+		Time:     h.clock.Now(),
+	})
+}
@@ -0,0 +1,167 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestWithTokenRefresh(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	client.RequireSequenceTokens = true
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	var mutex sync.Mutex
+	var conflicts []cloudwatchhook.TokenConflict
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock),
+		cloudwatchhook.WithTokenRefresh(time.Minute, func(conflict cloudwatchhook.TokenConflict) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			conflicts = append(conflicts, conflict)
+		}))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	if _, err := hook.Write([]byte("first")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// another writer shares the stream, leaving the token of the hook stale
+	other, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := other.Write([]byte("other")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other.Close()
+
+	for i := 0; i < 100 && hook.Stats().TokenConflicts == 0; i++ {
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	mutex.Lock()
+	if len(conflicts) != 1 || conflicts[0].Stream != "stream" || conflicts[0].Expected == conflicts[0].Found {
+		t.Errorf("expected a single conflict to be reported, got %+v", conflicts)
+	}
+	mutex.Unlock()
+
+	// the refreshed token is accepted without a failed upload
+	calls := client.Calls("PutLogEvents")
+	if _, err := hook.Write([]byte("second")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := client.Calls("PutLogEvents") - calls; n != 1 {
+		t.Errorf("expected a single upload with the refreshed token, got %d", n)
+	}
+}
+
+// tokenRecordingClient records the sequence token sent with each PutLogEvents call.
+type tokenRecordingClient struct {
+	*chaos.Client
+	mutex  sync.Mutex
+	tokens []*string
+}
+
+func (c *tokenRecordingClient) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	c.mutex.Lock()
+	c.tokens = append(c.tokens, params.SequenceToken)
+	c.mutex.Unlock()
+	return c.Client.PutLogEvents(ctx, params, optFns...)
+}
+
+func (c *tokenRecordingClient) sent() []*string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	tokens := c.tokens
+	c.tokens = nil
+	return tokens
+}
+
+func TestWithoutSequenceTokens(t *testing.T) {
+	client := &tokenRecordingClient{Client: chaos.NewClient(chaos.Faults{})}
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithoutSequenceTokens())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	for _, msg := range []string{"first", "second"} {
+		if _, err := hook.Write([]byte(msg)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if n := client.Calls("DescribeLogStreams"); n != 0 {
+		t.Errorf("expected the stream not to be described, got %d calls", n)
+	}
+	tokens := client.sent()
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 uploads, got %d", len(tokens))
+	}
+	for i, token := range tokens {
+		if token != nil {
+			t.Errorf("expected upload %d to be sent without a token, got %s", i, aws.ToString(token))
+		}
+	}
+}
+
+func TestWithoutSequenceTokensFallback(t *testing.T) {
+	client := &tokenRecordingClient{Client: chaos.NewClient(chaos.Faults{})}
+	client.RequireSequenceTokens = true
+
+	// another writer leaves the stream with a token which the service still requires
+	other, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := other.Write([]byte("other")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other.Close()
+
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithoutSequenceTokens())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	client.sent()
+	if hook.Config().SequenceTokens {
+		t.Errorf("expected the configuration to report sequence tokens as disabled")
+	}
+
+	// the rejected upload switches the hook to managing the token and is retried once with the expected token
+	if _, err := hook.Write([]byte("first")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tokens := client.sent()
+	if len(tokens) != 2 || tokens[0] != nil || tokens[1] == nil {
+		t.Fatalf("expected an upload without a token and a single retry with one, got %d uploads", len(tokens))
+	}
+	if !hook.Config().SequenceTokens {
+		t.Errorf("expected the configuration to report the fallback to sequence tokens")
+	}
+
+	// later uploads carry the token from the start
+	if _, err := hook.Write([]byte("second")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens := client.sent(); len(tokens) != 1 || tokens[0] == nil {
+		t.Errorf("expected a single upload with a token, got %d uploads", len(tokens))
+	}
+	if n := len(client.Events("group", "stream")); n != 3 {
+		t.Errorf("expected 3 events, got %d", n)
+	}
+}
@@ -0,0 +1,108 @@
+package cloudwatchhook
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Unit is the unit of a numeric field renamed by the WithUnitSuffixes option. Its name is the suffix added to the
+// field.
+type Unit int
+
+const (
+	// UnitMilliseconds renders durations as a number of milliseconds with a _ms suffix. This is the unit of
+	// durations which are not given one.
+	UnitMilliseconds Unit = iota
+
+	// UnitSeconds renders durations as a number of seconds with a _s suffix.
+	UnitSeconds
+
+	// UnitBytes marks a size with a _bytes suffix.
+	UnitBytes
+
+	// UnitCount marks a count with a _count suffix.
+	UnitCount
+)
+
+// String returns the name of the unit.
+func (u Unit) String() string {
+	switch u {
+	case UnitMilliseconds:
+		return "ms"
+	case UnitSeconds:
+		return "s"
+	case UnitBytes:
+		return "bytes"
+	case UnitCount:
+		return "count"
+	default:
+		return "unknown"
+	}
+}
+
+// UnitSuffixCodec renames numeric fields with a suffix naming their unit before encoding each entry with Codec, which
+// defaults to FormatterCodec. Every time.Duration field is rendered as a number in its unit, milliseconds unless Units
+// gives it another, such as latency as latency_ms. Integer fields are only renamed if Units gives them a unit, such
+// as size as size_bytes, and keep their value. Fields which already end with the suffix are not renamed again, and a
+// field is left as it is if its new name is already taken.
+type UnitSuffixCodec struct {
+	Units map[string]Unit
+	Codec Codec
+}
+
+// Encode renames the fields of a copy of the entry and encodes it.
+func (c UnitSuffixCodec) Encode(entry *logrus.Entry) ([]byte, error) {
+	e := *entry
+	e.Data = make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		e.Data[k] = v
+	}
+	for k, v := range entry.Data {
+		name, value, ok := c.rename(k, v)
+		if !ok {
+			continue
+		}
+		if _, taken := entry.Data[name]; taken && name != k {
+			continue
+		}
+		delete(e.Data, k)
+		e.Data[name] = value
+	}
+	codec := c.Codec
+	if codec == nil {
+		codec = FormatterCodec{}
+	}
+	return codec.Encode(&e)
+}
+
+// rename returns the name and value of the field with its unit, or false if it has none.
+func (c UnitSuffixCodec) rename(k string, v interface{}) (string, interface{}, bool) {
+	unit, ok := c.Units[k]
+	if d, isDuration := v.(time.Duration); isDuration {
+		if ok && unit == UnitSeconds {
+			v = d.Seconds()
+		} else {
+			unit, v = UnitMilliseconds, float64(d)/float64(time.Millisecond)
+		}
+	} else if !ok || !isInteger(v) {
+		return "", nil, false
+	}
+	suffix := "_" + unit.String()
+	if strings.HasSuffix(k, suffix) {
+		return k, v, true
+	}
+	return k + suffix, v, true
+}
+
+// isInteger determines whether or not the value is an integer.
+func isInteger(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
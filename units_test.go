@@ -0,0 +1,58 @@
+package cloudwatchhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestUnitSuffixes(t *testing.T) {
+	entry := &logrus.Entry{
+		Message: "request",
+		Data: logrus.Fields{
+			"latency":    1500 * time.Millisecond,
+			"timeout":    2 * time.Second,
+			"elapsed_ms": 250 * time.Millisecond,
+			"size":       int64(2048),
+			"retries":    3,
+			"items":      "many",
+			"wait":       time.Second,
+			"wait_ms":    7,
+		},
+	}
+	codec := UnitSuffixCodec{
+		Units: map[string]Unit{"timeout": UnitSeconds, "size": UnitBytes, "retries": UnitCount, "items": UnitCount},
+		Codec: EntryCodec{},
+	}
+	line, err := codec.Encode(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"latency_ms":    1500.0,
+		"timeout_s":     2.0,
+		"elapsed_ms":    250.0,
+		"size_bytes":    2048.0,
+		"retries_count": 3.0,
+		"items":         "many",
+		"wait":          float64(time.Second),
+		"wait_ms":       7.0,
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, fields[k])
+		}
+	}
+	if _, ok := fields["latency"]; ok {
+		t.Errorf("expected the original field to be renamed")
+	}
+	if entry.Data["latency"] != 1500*time.Millisecond {
+		t.Errorf("expected the entry to be left alone")
+	}
+}
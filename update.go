@@ -0,0 +1,177 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// HookUpdate is a change made to a running hook with Update.
+type HookUpdate func(*hookUpdate)
+
+// hookUpdate collects the changes requested by a call to Update.
+type hookUpdate struct {
+	target *streamTarget
+}
+
+// UpdateTarget points the hook at a different log group and stream. The names are passed through the naming policies
+// of the hook and the group and stream are created if they do not exist, unless the hook was created with the
+// WithNoCreate option, in which case they must already exist. Events which are still queued are delivered according
+// to the retarget policy.
+func UpdateTarget(group, stream string) HookUpdate {
+	return func(u *hookUpdate) {
+		u.target = &streamTarget{group: group, stream: stream}
+	}
+}
+
+// Update applies the changes to the running hook. The options given when the hook was created are fixed for its
+// lifetime; Update is the only way to change a hook once it has been created and is safe to call while entries are
+// being logged.
+func (h *CloudWatchLogsHook) Update(updates ...HookUpdate) error {
+	if atomic.LoadInt32(&h.closed) != 0 {
+		return ErrClosed
+	}
+	var update hookUpdate
+	for _, fn := range updates {
+		fn(&update)
+	}
+	if update.target != nil {
+		if err := h.updateTarget(*update.target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateTarget makes sure the log group and stream exist and then points the hook at them.
+func (h *CloudWatchLogsHook) updateTarget(target streamTarget) error {
+	target, err := h.prepareTarget(target)
+	if err != nil {
+		return err
+	}
+	h.retarget(target.group, target.stream)
+	return nil
+}
+
+// prepareTarget passes the names of the log group and stream through the naming policies of the hook and makes sure
+// the group and stream exist, returning the names the hook is pointed at.
+func (h *CloudWatchLogsHook) prepareTarget(target streamTarget) (streamTarget, error) {
+	if h.destinationARN != "" {
+		return target, fmt.Errorf("Unable to change the stream of a hook publishing to a destination")
+	}
+	target, err := h.resolveTarget(target)
+	if err != nil {
+		return target, err
+	}
+
+	// the relay worker creates the group and stream, there is nothing to create when using a different transport and
+	// streams created by EnsureStreams are known to exist
+	if h.relay == "" && h.transport == nil && !h.knownStream(target) {
+		if h.noCreate {
+			err = h.requireTarget(target)
+		} else {
+			err = h.createTarget(target)
+		}
+		if err == nil {
+			err = h.tagStream(context.TODO(), target)
+		}
+		if err != nil {
+			return target, err
+		}
+	}
+	return target, nil
+}
+
+// resolveTarget passes the names of the log group and stream through the Kubernetes placeholders and the naming
+// policies of the hook, as was done for the names the hook was created with, and validates the result.
+func (h *CloudWatchLogsHook) resolveTarget(target streamTarget) (streamTarget, error) {
+	target, err := h.expandKubernetesNames(target)
+	if err != nil {
+		return target, err
+	}
+	for _, policy := range h.namingPolicies {
+		group, stream, err := policy(target.group, target.stream)
+		if err != nil {
+			return target, fmt.Errorf("Naming policy rejected log group %s and stream %s: %v", target.group,
+				target.stream, err)
+		}
+		target = streamTarget{group: group, stream: stream}
+	}
+	if err := validateGroupName(target.group); err != nil {
+		return target, err
+	}
+	if err := validateStreamName(target.stream); err != nil {
+		return target, err
+	}
+	return target, nil
+}
+
+// requireTarget makes sure the stream, which the hook does not create, already exists.
+func (h *CloudWatchLogsHook) requireTarget(target streamTarget) error {
+	output, err := h.client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(target.group),
+		LogStreamNamePrefix: aws.String(target.stream),
+	})
+	var notFoundErr *types.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return fmt.Errorf("Log group %s does not exist; it must be provisioned before the hook uses it", target.group)
+	} else if err != nil {
+		return err
+	}
+	for _, stream := range output.LogStreams {
+		if aws.ToString(stream.LogStreamName) == target.stream {
+			return nil
+		}
+	}
+	return fmt.Errorf("Log stream %s does not exist; it must be provisioned before the hook uses it", target.stream)
+}
+
+// createTarget creates the log group, with the retention, KMS key and tags of the hook, and the stream, ignoring the
+// errors returned if they already exist.
+func (h *CloudWatchLogsHook) createTarget(target streamTarget) error {
+	h.intakeMutex.Lock()
+	current := h.currentTarget()
+	h.intakeMutex.Unlock()
+
+	var existsErr *types.ResourceAlreadyExistsException
+	if target.group != current.group {
+		input := &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(target.group)}
+		if h.kmsKeyID != "" {
+			input.KmsKeyId = aws.String(h.kmsKeyID)
+		}
+		if len(h.tags) > 0 {
+			input.Tags = h.tags
+		}
+		_, err := h.client.CreateLogGroup(context.TODO(), input)
+		if err != nil && !errors.As(err, &existsErr) {
+			return err
+		}
+		if err == nil && h.retentionDays > 0 {
+			_, err := h.client.PutRetentionPolicy(context.TODO(), &cloudwatchlogs.PutRetentionPolicyInput{
+				LogGroupName:    aws.String(target.group),
+				RetentionInDays: aws.Int32(h.retentionDays),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// the group may not be visible yet if it was just created
+	_, err := h.retryCreation(func() (bool, error) {
+		_, err := h.client.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
+			LogGroupName:  aws.String(target.group),
+			LogStreamName: aws.String(target.stream),
+		})
+		if err != nil && errors.As(err, &existsErr) {
+			return true, nil
+		}
+		return err == nil, err
+	})
+	return err
+}
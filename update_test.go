@@ -0,0 +1,163 @@
+package cloudwatchhook_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestUpdateTarget(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "old",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithNamingPolicy(cloudwatchhook.RequireGroupPrefix("/app/")))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("before")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/other/web", "new")); err == nil {
+		t.Errorf("expected the naming policy to reject the update")
+	}
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/app/jobs", "new")); err != nil {
+		t.Fatalf("unable to update hook: %v", err)
+	}
+	if _, err := hook.Write([]byte("after")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config := hook.Config(); config.Group != "/app/jobs" || config.Stream != "new" {
+		t.Errorf("expected the configuration to report the new stream, got %s %s", config.Group, config.Stream)
+	}
+	hook.Close()
+	if n := len(client.Events("/app/web", "old")); n != 1 {
+		t.Errorf("expected 1 event in the old stream, got %d", n)
+	}
+	if n := len(client.Events("/app/jobs", "new")); n != 1 {
+		t.Errorf("expected 1 event in the new stream, got %d", n)
+	}
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/app/jobs", "newer")); err != cloudwatchhook.ErrClosed {
+		t.Errorf("expected ErrClosed updating a closed hook, got %v", err)
+	}
+
+	// without permission to create resources, the new stream must already exist
+	hook, err = cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "/app/web", "old", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithNoCreate())
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/app/web", "missing")); err == nil {
+		t.Errorf("expected an error for a missing stream")
+	}
+	if err := hook.Update(cloudwatchhook.UpdateTarget("/app/jobs", "new")); err != nil {
+		t.Errorf("unable to update hook: %v", err)
+	}
+}
+
+func TestUpdateTargetBatching(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "a", cloudwatchhook.WithClient(client),
+		cloudwatchhook.WithBatchDuration(time.Minute), cloudwatchhook.WithClock(clock))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	// events routed back and forth between two streams are batched by stream rather than sent on each change
+	for i, stream := range []string{"a", "b", "a", "b", "a"} {
+		if i > 0 {
+			if err := hook.Update(cloudwatchhook.UpdateTarget("group", stream)); err != nil {
+				t.Fatalf("unable to update hook: %v", err)
+			}
+		}
+		if _, err := hook.Write([]byte("routed")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 100 && len(client.Events("group", "a"))+len(client.Events("group", "b")) < 5; i++ {
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if a, b := len(client.Events("group", "a")), len(client.Events("group", "b")); a != 3 || b != 2 {
+		t.Errorf("expected 3 and 2 events in the streams, got %d and %d", a, b)
+	}
+	if n := client.Calls("PutLogEvents"); n != 2 {
+		t.Errorf("expected a single upload for each stream, got %d", n)
+	}
+}
+
+func TestEnsureStreams(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	shards := []string{"shard-0", "shard-1", "shard-2", "shard-3", "stream"}
+	if err := hook.EnsureStreams(context.Background(), shards...); err != nil {
+		t.Fatalf("unable to create streams: %v", err)
+	}
+	if err := hook.EnsureStreams(context.Background(), shards...); err != nil {
+		t.Fatalf("expected creating the streams again to be harmless: %v", err)
+	}
+	if n := client.Calls("CreateLogStream"); n != 6 {
+		t.Errorf("expected each stream to be created once after the hook's own stream, got %d calls", n)
+	}
+
+	// pointing the hook at a stream created up front does not create it again
+	if err := hook.Update(cloudwatchhook.UpdateTarget("group", "shard-2")); err != nil {
+		t.Fatalf("unable to update hook: %v", err)
+	}
+	if n := client.Calls("CreateLogStream"); n != 6 {
+		t.Errorf("expected no further streams to be created, got %d calls", n)
+	}
+	if err := hook.EnsureStreams(context.Background(), "bad:name"); err == nil {
+		t.Errorf("expected an error for an invalid stream name")
+	}
+}
+
+func TestEnsureStreamsNamingPolicy(t *testing.T) {
+	// a policy which prefixes every stream with the name of the application
+	prefix := func(group, stream string) (string, string, error) {
+		if !strings.HasPrefix(stream, "web-") {
+			stream = "web-" + stream
+		}
+		return group, stream, nil
+	}
+	client := chaos.NewClient(chaos.Faults{})
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithNamingPolicy(prefix))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	if err := hook.EnsureStreams(context.Background(), "shard-0"); err != nil {
+		t.Fatalf("unable to create streams: %v", err)
+	}
+	if n := client.Calls("CreateLogStream"); n != 2 {
+		t.Fatalf("expected the stream to be created, got %d calls", n)
+	}
+
+	// the stream is known by its rewritten name, so pointing the hook at it does not create it again
+	if err := hook.Update(cloudwatchhook.UpdateTarget("group", "shard-0")); err != nil {
+		t.Fatalf("unable to update hook: %v", err)
+	}
+	if n := client.Calls("CreateLogStream"); n != 2 {
+		t.Errorf("expected no further streams to be created, got %d calls", n)
+	}
+	if _, err := hook.Write([]byte("event")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(client.Events("group", "web-shard-0")); n != 1 {
+		t.Errorf("expected the event in the rewritten stream, got %d", n)
+	}
+}
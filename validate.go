@@ -0,0 +1,399 @@
+package cloudwatchhook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxGroupNameLength  = 512
+	maxStreamNameLength = 512
+	maxTags             = 50
+	maxTagKeyLength     = 128
+	maxTagValueLength   = 256
+	maxKmsKeyIDLength   = 256
+)
+
+var (
+	// groupNamePattern matches the characters allowed in a log group name.
+	groupNamePattern = regexp.MustCompile(`^[\.\-_/#A-Za-z0-9]+$`)
+
+	// tagPattern matches the characters allowed in a tag key or value.
+	tagPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+	// validRetentionDays holds the retention periods accepted by Amazon CloudWatch, plus 0 to never expire events.
+	validRetentionDays = []int32{0, 1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192,
+		2557, 2922, 3288, 3653}
+)
+
+// validate checks the hook configuration against the Amazon CloudWatch naming rules and limits so that problems are
+// reported at construction rather than as API failures later on.
+func (h *CloudWatchLogsHook) validate() error {
+	if h.groupSelector != nil {
+		if h.groupSelector.key == "" {
+			return fmt.Errorf("Invalid log group selector: tag key must not be empty")
+		}
+	} else if err := validateGroupName(h.group); err != nil {
+		return err
+	}
+	if err := validateStreamName(h.stream); err != nil {
+		return err
+	}
+	if err := validateRetentionDays(h.retentionDays); err != nil {
+		return err
+	}
+	if h.backpressure != nil && (h.backpressure.lowWaterMark < 0 ||
+		h.backpressure.highWaterMark <= h.backpressure.lowWaterMark) {
+		return fmt.Errorf("Invalid backpressure water marks: high-water mark (%d) must be greater than low-water "+
+			"mark (%d), which must not be negative", h.backpressure.highWaterMark, h.backpressure.lowWaterMark)
+	}
+	if capacity := h.resolvedQueueCapacity() + h.burstSize; h.backpressure != nil &&
+		h.backpressure.highWaterMark > capacity {
+		return fmt.Errorf("Invalid backpressure high-water mark (%d): must not exceed the capacity of the queue (%d)",
+			h.backpressure.highWaterMark, capacity)
+	}
+	if h.startupEvent && !h.noInstanceMetadata && h.metadataTimeout <= 0 {
+		return fmt.Errorf("Invalid metadata timeout: must be greater than 0")
+	}
+	if h.emptyPolicy == EmptyMessagePlaceholder && strings.TrimSpace(h.emptyPlaceholder) == "" {
+		return fmt.Errorf("Invalid empty message placeholder: must contain non-whitespace characters")
+	}
+	if h.emptyPolicy < EmptyMessageDrop || h.emptyPolicy > EmptyMessagePlaceholder {
+		return fmt.Errorf("Invalid empty message policy: %d", h.emptyPolicy)
+	}
+	if h.callerPolicy < CallerKeep || h.callerPolicy > CallerDrop {
+		return fmt.Errorf("Invalid caller policy: %d", h.callerPolicy)
+	}
+	if h.callerPolicy == CallerTrim && len(h.callerPolicyTrims) == 0 {
+		return fmt.Errorf("Invalid caller policy: CallerTrim requires the prefixes to trim")
+	}
+	if h.tokenRefresh < 0 {
+		return fmt.Errorf("Invalid token refresh interval: must not be negative")
+	}
+	if h.writeTimeout < 0 {
+		return fmt.Errorf("Invalid write timeout: must not be negative")
+	}
+	if h.queueCapacity < 0 || h.queueCapacity > maxQueueCapacity {
+		return fmt.Errorf("Invalid queue capacity %d: must be between 0, for the default, and %d", h.queueCapacity,
+			maxQueueCapacity)
+	}
+	if h.burstSize < 0 {
+		return fmt.Errorf("Invalid burst buffer size: must not be negative")
+	}
+	if h.timestampBuckets < 0 || h.timestampBuckets%time.Minute != 0 {
+		return fmt.Errorf("Invalid timestamp bucket width %v: must be a whole number of minutes", h.timestampBuckets)
+	}
+	if h.batchJitter < 0 {
+		return fmt.Errorf("Invalid batch jitter: must not be negative")
+	}
+	if h.verificationRate < 0 || h.verificationRate > 1 {
+		return fmt.Errorf("Invalid verification sample rate %v: must be between 0 and 1", h.verificationRate)
+	}
+	if h.dataKeys != nil && !batchEncryptionAvailable {
+		return fmt.Errorf("Unable to use WithBatchEncryption: the hook was built with the cloudwatchhook_nocrypto tag")
+	}
+	if h.opsStream != "" {
+		if err := validateStreamName(h.opsStream); err != nil {
+			return err
+		}
+		if h.opsStream == h.stream {
+			return fmt.Errorf("Invalid ops stream %s: must differ from the stream of the hook", h.opsStream)
+		}
+	}
+	if h.filterPattern != "" {
+		filter, err := parseFilterPattern(h.filterPattern)
+		if err != nil {
+			return fmt.Errorf("Invalid filter pattern %q: %v", h.filterPattern, err)
+		}
+		h.patternFilter = filter
+	}
+	for name, t := range h.fieldTypes {
+		if t < FieldString || t > FieldBool {
+			return fmt.Errorf("Invalid type %d for field %s", t, name)
+		}
+	}
+	for name, unit := range h.unitSuffixes {
+		if unit < UnitMilliseconds || unit > UnitCount {
+			return fmt.Errorf("Invalid unit %d for field %s", unit, name)
+		}
+	}
+	if h.flattener != nil && (h.flattener.MaxDepth < 0 || h.flattener.MaxKeys < 0) {
+		return fmt.Errorf("Invalid flattener: maximum depth and keys must not be negative")
+	}
+	if h.fieldBudget < 0 || (h.fieldBudget > 0 && h.fieldBudget <= len(truncationMarker)) {
+		return fmt.Errorf("Invalid field budget of %d bytes: must be greater than %d", h.fieldBudget,
+			len(truncationMarker))
+	}
+	if h.adaptIntervals < 0 {
+		return fmt.Errorf("Invalid adaptive batching intervals: must not be negative")
+	}
+	if h.adaptIntervals > 0 && h.logFrequency > 0 && (h.adaptMin <= 0 || h.adaptMin >= h.logFrequency) {
+		return fmt.Errorf("Invalid adaptive batching minimum %v: must be greater than 0 and less than the batch "+
+			"duration (%v)", h.adaptMin, h.logFrequency)
+	}
+	if h.sampler != nil && h.sampler.target <= 0 {
+		return fmt.Errorf("Invalid adaptive sampling target: must be greater than 0 bytes per minute")
+	}
+	for i, rule := range h.tierRules {
+		if len(rule.Levels) == 0 {
+			return fmt.Errorf("Invalid tier rule %d: must apply to at least one level", i)
+		}
+	}
+	if h.destinationARN != "" {
+		if err := validateDestinationARN(h.destinationARN); err != nil {
+			return err
+		}
+	}
+	if err := h.validatePartition(); err != nil {
+		return err
+	}
+	if h.appName == "" && h.appVersion != "" {
+		return fmt.Errorf("Invalid app ID: name must not be empty")
+	}
+	if strings.ContainsAny(h.appName+h.appVersion, " /") {
+		return fmt.Errorf("Invalid app ID %q: name and version must not contain spaces or slashes",
+			h.appName+"/"+h.appVersion)
+	}
+	if h.lagCallback != nil && h.lagThreshold <= 0 {
+		return fmt.Errorf("Invalid delivery lag threshold: must be greater than 0")
+	}
+	if h.routingTag != "" && !routingTagRegex.MatchString(h.routingTag) {
+		return fmt.Errorf("Invalid routing tag %q: must be parts of letters, digits, underscores and hyphens "+
+			"separated by dots", h.routingTag)
+	}
+	if len(h.kmsKeyID) > maxKmsKeyIDLength {
+		return fmt.Errorf("Invalid KMS key ID: must be at most %d characters", maxKmsKeyIDLength)
+	}
+	if err := validateTags(h.tags); err != nil {
+		return err
+	}
+	if err := validateTags(h.streamTags); err != nil {
+		return err
+	}
+	return h.validateOptions()
+}
+
+// validateOptions detects combinations of options which conflict or which would otherwise be silently ignored, so
+// that the resulting configuration does not depend on what the caller assumed.
+func (h *CloudWatchLogsHook) validateOptions() error {
+	var conflicts []string
+	if h.logFrequency <= 0 {
+		if h.priority {
+			conflicts = append(conflicts, "WithPriorityQueue requires WithBatchDuration")
+		}
+		if h.backpressure != nil {
+			conflicts = append(conflicts, "WithBackpressureLevel requires WithBatchDuration")
+		}
+		if h.batchJitter > 0 {
+			conflicts = append(conflicts, "WithBatchJitter requires WithBatchDuration")
+		}
+		if h.burstSize > 0 {
+			conflicts = append(conflicts, "WithBurstBuffer requires WithBatchDuration")
+		}
+		if h.dedupErrors {
+			conflicts = append(conflicts, "WithErrorDeduplication requires WithBatchDuration")
+		}
+		if h.queueCapacity > 0 {
+			conflicts = append(conflicts, "WithQueueCapacity requires WithBatchDuration")
+		}
+		if h.timestampBuckets > 0 {
+			conflicts = append(conflicts, "WithTimestampBuckets requires WithBatchDuration")
+		}
+		if h.alignedFlush {
+			conflicts = append(conflicts, "WithAlignedFlush requires WithBatchDuration")
+		}
+		if h.adaptIntervals > 0 {
+			conflicts = append(conflicts, "WithAdaptiveBatching requires WithBatchDuration")
+		}
+	}
+	if h.transport != nil {
+		if h.relay != "" {
+			conflicts = append(conflicts, "WithTransport cannot be used with WithSQSRelay")
+		}
+		if h.retentionDays > 0 || h.kmsKeyID != "" || len(h.tags) > 0 {
+			conflicts = append(conflicts, "log group options cannot be used with WithTransport")
+		}
+		if h.noSeqTokens {
+			conflicts = append(conflicts, "WithoutSequenceTokens cannot be used with WithTransport")
+		}
+		if h.tokenRefresh > 0 {
+			conflicts = append(conflicts, "WithTokenRefresh cannot be used with WithTransport")
+		}
+		if h.verificationRate > 0 {
+			conflicts = append(conflicts, "WithVerification cannot be used with WithTransport")
+		}
+	}
+	if h.destinationARN != "" {
+		if h.relay != "" || h.transport != nil {
+			conflicts = append(conflicts, "WithDestinationARN cannot be used with WithSQSRelay or WithTransport")
+		}
+		if h.retentionDays > 0 || h.kmsKeyID != "" || len(h.tags) > 0 {
+			conflicts = append(conflicts, "log group options cannot be used with WithDestinationARN")
+		}
+	}
+	if h.groupSelector != nil {
+		if h.relay != "" || h.transport != nil {
+			conflicts = append(conflicts, "WithGroupSelector cannot be used with WithSQSRelay or WithTransport")
+		}
+		if h.retentionDays > 0 || h.kmsKeyID != "" || len(h.tags) > 0 {
+			conflicts = append(conflicts, "log group options cannot be used with WithGroupSelector")
+		}
+	}
+	if h.writeTimeout > 0 {
+		if h.logFrequency > 0 {
+			conflicts = append(conflicts, "WithWriteTimeout cannot be used with WithBatchDuration")
+		}
+		if h.relay != "" {
+			conflicts = append(conflicts, "WithWriteTimeout cannot be used with WithSQSRelay")
+		}
+	}
+	if h.tokenRefresh > 0 && h.noSeqTokens {
+		conflicts = append(conflicts, "WithTokenRefresh cannot be used with WithoutSequenceTokens")
+	}
+	if h.noCreate && (h.retentionDays > 0 || h.kmsKeyID != "" || len(h.tags) > 0) {
+		conflicts = append(conflicts, "log group options cannot be used with WithNoCreate")
+	}
+	if len(h.streamTags) > 0 && (h.relay != "" || h.transport != nil || h.destinationARN != "") {
+		conflicts = append(conflicts,
+			"WithStreamTags cannot be used with WithSQSRelay, WithTransport or WithDestinationARN")
+	}
+	if h.opsStream != "" && (h.relay != "" || h.transport != nil || h.destinationARN != "") {
+		conflicts = append(conflicts,
+			"WithOpsStream cannot be used with WithSQSRelay, WithTransport or WithDestinationARN")
+	}
+	if h.sharedStream && (h.relay != "" || h.transport != nil) {
+		conflicts = append(conflicts, "WithSharedStream cannot be used with WithSQSRelay or WithTransport")
+	}
+	if h.relay != "" && h.sqsClient == nil {
+		conflicts = append(conflicts, "WithSQSRelay requires WithSQSClient")
+	}
+	if h.relay == "" && h.sqsClient != nil {
+		conflicts = append(conflicts, "WithSQSClient requires WithSQSRelay")
+	}
+	if h.relay != "" {
+		// the relay creates the group, so settings only applied when creating it would be lost
+		if h.retentionDays > 0 {
+			conflicts = append(conflicts, "WithGroupRetentionDays cannot be used with WithSQSRelay")
+		}
+		if h.kmsKeyID != "" {
+			conflicts = append(conflicts, "WithGroupKmsKeyID cannot be used with WithSQSRelay")
+		}
+		if len(h.tags) > 0 {
+			conflicts = append(conflicts, "WithGroupTags cannot be used with WithSQSRelay")
+		}
+		if h.tokenRefresh > 0 {
+			conflicts = append(conflicts, "WithTokenRefresh cannot be used with WithSQSRelay")
+		}
+		if h.deliveryCallback != nil {
+			conflicts = append(conflicts, "WithDeliveryCallback cannot be used with WithSQSRelay")
+		}
+		if h.verificationRate > 0 {
+			conflicts = append(conflicts, "WithVerification cannot be used with WithSQSRelay")
+		}
+	}
+	if h.eventDecorator != nil && (h.dataKeys != nil || h.relay != "" || h.transport != nil) {
+		conflicts = append(conflicts,
+			"WithEventDecorator cannot be used with WithBatchEncryption, WithSQSRelay or WithTransport")
+	}
+	if h.caller && h.callerPolicy != CallerKeep {
+		conflicts = append(conflicts, "WithCallerPolicy cannot be used with WithCaller")
+	}
+	if h.client != nil && len(h.apiOptions) > 0 {
+		conflicts = append(conflicts, "WithAPIOptions cannot be used with WithClient")
+	}
+	if h.client != nil && h.appName != "" {
+		conflicts = append(conflicts, "WithAppID cannot be used with WithClient")
+	}
+	if h.messageTemplate != "" && !isDefaultCodec(h.codec) {
+		conflicts = append(conflicts, "WithMessageTemplate cannot be used with WithCodec")
+	}
+	if h.directEncoding && !isDefaultCodec(h.codec) {
+		conflicts = append(conflicts, "WithDirectEncoding cannot be used with WithCodec")
+	}
+	if h.directEncoding && h.messageTemplate != "" {
+		conflicts = append(conflicts, "WithMessageTemplate cannot be used with WithDirectEncoding")
+	}
+	if h.timestampLayout == TimestampEpochMillis && h.timestampLocation != nil {
+		conflicts = append(conflicts, "WithTimestampLocation cannot be used with TimestampEpochMillis")
+	}
+	if h.routingTag != "" && h.routingTagFn != nil {
+		conflicts = append(conflicts, "WithRoutingTag cannot be used with WithRoutingTagFunc")
+	}
+	if h.emptyPolicy != EmptyMessagePlaceholder && h.emptyPlaceholder != "" {
+		conflicts = append(conflicts, "an empty message placeholder requires EmptyMessagePlaceholder")
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("Conflicting options: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// validateGroupName ensures the log group name meets the Amazon CloudWatch naming rules.
+func validateGroupName(name string) error {
+	if name == "" || len(name) > maxGroupNameLength {
+		return fmt.Errorf("Invalid log group name %q: must be between 1 and %d characters", name,
+			maxGroupNameLength)
+	}
+	if !groupNamePattern.MatchString(name) {
+		return fmt.Errorf("Invalid log group name %q: may only contain a-z, A-Z, 0-9, '_', '-', '/', '.' and '#'",
+			name)
+	}
+	return nil
+}
+
+// validateStreamName ensures the log stream name meets the Amazon CloudWatch naming rules.
+func validateStreamName(name string) error {
+	if name == "" || len(name) > maxStreamNameLength {
+		return fmt.Errorf("Invalid log stream name %q: must be between 1 and %d characters", name,
+			maxStreamNameLength)
+	}
+	if strings.ContainsAny(name, ":*") {
+		return fmt.Errorf("Invalid log stream name %q: may not contain ':' or '*'", name)
+	}
+	return nil
+}
+
+// validateRetentionDays ensures the retention period is one accepted by Amazon CloudWatch.
+func validateRetentionDays(days int32) error {
+	for _, valid := range validRetentionDays {
+		if days == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid retention period of %d days: must be one of %v", days, validRetentionDays)
+}
+
+// validateTags ensures the tags meet the Amazon CloudWatch tagging limits.
+func validateTags(tags map[string]string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("Invalid tags: at most %d tags may be specified, got %d", maxTags, len(tags))
+	}
+	for k, v := range tags {
+		if k == "" || utf8.RuneCountInString(k) > maxTagKeyLength {
+			return fmt.Errorf("Invalid tag key %q: must be between 1 and %d characters", k, maxTagKeyLength)
+		}
+		if strings.HasPrefix(strings.ToLower(k), "aws:") {
+			return fmt.Errorf("Invalid tag key %q: the 'aws:' prefix is reserved", k)
+		}
+		if !tagPattern.MatchString(k) {
+			return fmt.Errorf("Invalid tag key %q: may only contain letters, numbers, spaces and _.:/=+-@", k)
+		}
+		if utf8.RuneCountInString(v) > maxTagValueLength {
+			return fmt.Errorf("Invalid value for tag %q: must be at most %d characters", k, maxTagValueLength)
+		}
+		if !tagPattern.MatchString(v) {
+			return fmt.Errorf("Invalid value for tag %q: may only contain letters, numbers, spaces and _.:/=+-@", k)
+		}
+	}
+	return nil
+}
+
+// isDefaultCodec reports whether the codec is the default, which encodes entries with the formatter of the logger. The
+// codec is not compared directly since the formatter of a FormatterCodec may not be comparable.
+func isDefaultCodec(codec Codec) bool {
+	c, ok := codec.(FormatterCodec)
+	return ok && c.Formatter == nil
+}
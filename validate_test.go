@@ -0,0 +1,103 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/sirupsen/logrus"
+)
+
+// prefixFormatter is a formatter which cannot be compared, since it is a slice.
+type prefixFormatter []string
+
+func (f prefixFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return []byte(strings.Join(f, " ") + " " + entry.Message), nil
+}
+
+// testQueue is an SQSClient which does nothing.
+type testQueue struct{}
+
+func (q *testQueue) SendMessage(ctx context.Context, queueURL, body string) error { return nil }
+func (q *testQueue) ReceiveMessages(ctx context.Context, queueURL string, max int32) ([]SQSMessage, error) {
+	return nil, nil
+}
+func (q *testQueue) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	partner := "arn:aws:logs:us-east-1:123456789012:destination:partner"
+	tests := []struct {
+		name    string
+		group   string
+		stream  string
+		options []CloudWatchLogsHookOption
+		valid   bool
+	}{
+		{"valid", "/app/my-group_1.#", "stream-1", nil, true},
+		{"empty group", "", "stream", nil, false},
+		{"long group", strings.Repeat("a", 513), "stream", nil, false},
+		{"group charset", "my group", "stream", nil, false},
+		{"empty stream", "group", "", nil, false},
+		{"stream colon", "group", "a:b", nil, false},
+		{"stream asterisk", "group", "a*", nil, false},
+		{"retention", "group", "stream", []CloudWatchLogsHookOption{WithGroupRetentionDays(2)}, false},
+		{"tag key", "group", "stream", []CloudWatchLogsHookOption{WithGroupTags(map[string]string{"": "v"})}, false},
+		{"tag prefix", "group", "stream",
+			[]CloudWatchLogsHookOption{WithGroupTags(map[string]string{"aws:owner": "v"})}, false},
+		{"tag value", "group", "stream",
+			[]CloudWatchLogsHookOption{WithGroupTags(map[string]string{"owner": "a#b"})}, false},
+		{"priority without batching", "group", "stream", []CloudWatchLogsHookOption{WithPriorityQueue()}, false},
+		{"priority with batching", "group", "stream",
+			[]CloudWatchLogsHookOption{WithPriorityQueue(), WithBatchDuration(time.Second)}, true},
+		{"relay with tags", "group", "stream", []CloudWatchLogsHookOption{WithSQSRelay("queue"),
+			WithSQSClient(&testQueue{}), WithGroupTags(map[string]string{"owner": "me"})}, false},
+		{"relay", "group", "stream",
+			[]CloudWatchLogsHookOption{WithSQSRelay("queue"), WithSQSClient(&testQueue{})}, true},
+		{"relay without client", "group", "stream", []CloudWatchLogsHookOption{WithSQSRelay("queue")}, false},
+		{"client without relay", "group", "stream", []CloudWatchLogsHookOption{WithSQSClient(&testQueue{})}, false},
+		{"destination ARN", "group", "stream", []CloudWatchLogsHookOption{WithDestinationARN(partner)}, true},
+		{"destination role ARN", "group", "stream",
+			[]CloudWatchLogsHookOption{WithDestinationARN("arn:aws:iam::123456789012:role/partner")}, false},
+		{"destination with tags", "group", "stream", []CloudWatchLogsHookOption{WithDestinationARN(partner),
+			WithGroupTags(map[string]string{"owner": "me"})}, false},
+		{"template with codec", "group", "stream", []CloudWatchLogsHookOption{WithMessageTemplate("{{.Message}}"),
+			WithCodec(FormatterCodec{Formatter: &logrus.JSONFormatter{}})}, false},
+		{"template with uncomparable formatter", "group", "stream", []CloudWatchLogsHookOption{
+			WithMessageTemplate("{{.Message}}"), WithCodec(FormatterCodec{Formatter: prefixFormatter{"app"}})}, false},
+		{"direct encoding with codec", "group", "stream", []CloudWatchLogsHookOption{WithDirectEncoding(),
+			WithCodec(FormatterCodec{Formatter: &logrus.JSONFormatter{}})}, false},
+		{"direct encoding with template", "group", "stream",
+			[]CloudWatchLogsHookOption{WithDirectEncoding(), WithMessageTemplate("{{.Message}}")}, false},
+		{"direct encoding", "group", "stream", []CloudWatchLogsHookOption{WithDirectEncoding()}, true},
+		{"transport with relay", "group", "stream", []CloudWatchLogsHookOption{WithSQSRelay("queue"),
+			WithSQSClient(&testQueue{}), WithTransport(&testTransport{})}, false},
+		{"transport with retention", "group", "stream",
+			[]CloudWatchLogsHookOption{WithGroupRetentionDays(7), WithTransport(&testTransport{})}, false},
+		{"decorator with transport", "group", "stream", []CloudWatchLogsHookOption{
+			WithEventDecorator(func(*logrus.Entry, *types.InputLogEvent) {}), WithTransport(&testTransport{})}, false},
+		{"write timeout with batching", "group", "stream",
+			[]CloudWatchLogsHookOption{WithWriteTimeout(time.Second), WithBatchDuration(time.Second)}, false},
+		{"queue capacity without batching", "group", "stream", []CloudWatchLogsHookOption{WithQueueCapacity(100)},
+			false},
+		{"high-water mark above queue capacity", "group", "stream", []CloudWatchLogsHookOption{WithQueueCapacity(100),
+			WithBatchDuration(time.Second), WithBackpressureLevel(logrus.WarnLevel, 200, 50)}, false},
+		{"partial minute buckets", "group", "stream",
+			[]CloudWatchLogsHookOption{WithTimestampBuckets(90 * time.Second), WithBatchDuration(time.Second)}, false},
+		{"routing tag", "group", "stream", []CloudWatchLogsHookOption{WithRoutingTag("app..web")}, false},
+		{"epoch millis location", "group", "stream", []CloudWatchLogsHookOption{
+			WithTimestampFormat(TimestampEpochMillis), WithTimestampLocation(time.UTC)}, false},
+	}
+	for _, test := range tests {
+		_, err := newHook(aws.Config{}, test.group, test.stream, test.options...)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cloudwatchhook
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// FilterLogEventsAPI is the part of the Amazon CloudWatch Logs API used to search a log group for events. It is only
+// required of the client when the WithVerification option is used.
+type FilterLogEventsAPI interface {
+	FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+}
+
+const (
+	// verificationDelay is how long after an event was accepted it is first looked for, allowing for the time it
+	// takes to become searchable, and how often the events still missing are looked for again.
+	verificationDelay = 10 * time.Second
+
+	// verificationTimeout is how long after an event was accepted it is given up on and counted as lost.
+	verificationTimeout = 5 * time.Minute
+
+	// maxPendingVerifications bounds the number of sampled events waiting to be verified, so that a hook which cannot
+	// search its log group does not accumulate them without limit.
+	maxPendingVerifications = 100
+)
+
+// pendingVerification is a sampled event which has been accepted by Amazon CloudWatch but not yet found again.
+type pendingVerification struct {
+	target    streamTarget
+	message   string
+	timestamp int64
+	accepted  time.Time
+}
+
+// verifier samples events accepted by Amazon CloudWatch and later searches for them, counting the events found and
+// those which never appear.
+type verifier struct {
+	rate     float64
+	mutex    sync.Mutex
+	pending  []pendingVerification
+	verified int64 // accessed atomically
+	missing  int64 // accessed atomically
+}
+
+// newVerifier creates a verifier which samples the given fraction of events.
+func newVerifier(rate float64) *verifier {
+	return &verifier{
+		rate:     rate,
+		pending:  nil,
+		verified: 0,
+		missing:  0,
+	}
+}
+
+// sample selects accepted events for verification according to the sample rate.
+func (v *verifier) sample(target streamTarget, events []types.InputLogEvent, accepted time.Time) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for _, event := range events {
+		if len(v.pending) >= maxPendingVerifications {
+			return
+		}
+		if randFloat() < v.rate {
+			v.pending = append(v.pending, pendingVerification{
+				target:    target,
+				message:   aws.ToString(event.Message),
+				timestamp: aws.ToInt64(event.Timestamp),
+				accepted:  accepted,
+			})
+		}
+	}
+}
+
+// due removes and returns the sampled events which should be searchable at the given time.
+func (v *verifier) due(now time.Time) []pendingVerification {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	var due, waiting []pendingVerification
+	for _, p := range v.pending {
+		if now.Sub(p.accepted) >= verificationDelay {
+			due = append(due, p)
+		} else {
+			waiting = append(waiting, p)
+		}
+	}
+	v.pending = waiting
+	return due
+}
+
+// retry puts a sampled event which was not found back for another search.
+func (v *verifier) retry(p pendingVerification) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.pending = append(v.pending, p)
+}
+
+// successRate returns the fraction of the sampled events which were found, or 1 if none have been settled yet.
+func (v *verifier) successRate() float64 {
+	verified, missing := atomic.LoadInt64(&v.verified), atomic.LoadInt64(&v.missing)
+	if verified+missing == 0 {
+		return 1
+	}
+	return float64(verified) / float64(verified+missing)
+}
+
+// verifyDeliveries periodically searches for the sampled events until the hook is closed.
+func (h *CloudWatchLogsHook) verifyDeliveries(client FilterLogEventsAPI) {
+	defer h.workers.Done()
+	timer := h.clock.NewTimer(verificationDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-timer.C():
+			now := h.clock.Now()
+			for _, p := range h.verifier.due(now) {
+				found, err := findEvent(h.sendContext(), client, p)
+				switch {
+				case found:
+					atomic.AddInt64(&h.verifier.verified, 1)
+				case now.Sub(p.accepted) >= verificationTimeout:
+					atomic.AddInt64(&h.verifier.missing, 1)
+					h.debugf("event accepted at %v was not found in %s/%s (last error: %v)", p.accepted,
+						p.target.group, p.target.stream, err)
+				default:
+					h.verifier.retry(p)
+				}
+			}
+			timer.Reset(verificationDelay)
+		}
+	}
+}
+
+// findEvent searches the stream for the sampled event.
+func findEvent(ctx context.Context, client FilterLogEventsAPI, p pendingVerification) (bool, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   aws.String(p.target.group),
+		LogStreamNames: []string{p.target.stream},
+		StartTime:      aws.Int64(p.timestamp),
+		EndTime:        aws.Int64(p.timestamp + 1),
+	}
+	for {
+		output, err := client.FilterLogEvents(ctx, input)
+		if err != nil {
+			return false, err
+		}
+		for _, event := range output.Events {
+			if aws.ToString(event.Message) == p.message {
+				return true, nil
+			}
+		}
+		if output.NextToken == nil {
+			return false, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
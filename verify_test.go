@@ -0,0 +1,43 @@
+package cloudwatchhook_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchhook "github.com/josh-hogle/logrus-cloudwatch-hook"
+	"github.com/josh-hogle/logrus-cloudwatch-hook/chaos"
+)
+
+func TestVerification(t *testing.T) {
+	client := chaos.NewClient(chaos.Faults{})
+	clock := chaos.NewClock(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	hook, err := cloudwatchhook.NewCloudWatchLogsHook(aws.Config{}, "group", "stream",
+		cloudwatchhook.WithClient(client), cloudwatchhook.WithClock(clock), cloudwatchhook.WithVerification(1))
+	if err != nil {
+		t.Fatalf("unable to create hook: %v", err)
+	}
+	defer hook.Close()
+
+	// half of the events are accepted but silently lost
+	for i := 0; i < 4; i++ {
+		if i == 2 {
+			client.SetFaults(chaos.Faults{DropRate: 1})
+		}
+		if _, err := hook.Write([]byte(fmt.Sprintf("canary %d", i))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if stats := hook.Stats(); stats.Verified+stats.VerificationMissing == 4 {
+			break
+		}
+		clock.Advance(time.Minute)
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := hook.Stats()
+	if stats.Verified != 2 || stats.VerificationMissing != 2 || stats.VerificationRate != 0.5 {
+		t.Errorf("expected half of the events to be verified, got %+v", stats)
+	}
+}